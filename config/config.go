@@ -55,7 +55,19 @@ func LoadConfig(path string) IConfig {
 				}
 				return f
 			}(),
-			gcpbucket: envMap["APP_GCP_BUCKET"],
+			gcpbucket:  envMap["APP_GCP_BUCKET"],
+			notifyFile: envMap["APP_NOTIFY_FILE"],
+			coPurchaseRefresh: func() time.Duration {
+				// seconds, an hour when unset and 0 turns the refresh off
+				if envMap["APP_COPURCHASE_REFRESH"] == "" {
+					return time.Hour
+				}
+				t, err := strconv.Atoi(envMap["APP_COPURCHASE_REFRESH"])
+				if err != nil {
+					log.Fatalf("Error  Fail to load coPurchaseRefresh ENV %v", err)
+				}
+				return time.Duration(t) * time.Second
+			}(),
 		},
 		db: &db{
 			host: envMap["DB_HOST"],
@@ -98,6 +110,11 @@ func LoadConfig(path string) IConfig {
 				return r
 			}(),
 		},
+		payment: &payment{
+			promptPayId:   envMap["PAYMENT_PROMPTPAY_ID"],
+			merchantName:  envMap["PAYMENT_MERCHANT_NAME"],
+			webhookSecret: envMap["PAYMENT_WEBHOOK_SECRET"],
+		},
 	}
 }
 
@@ -105,12 +122,14 @@ type IConfig interface {
 	App() IAppConfig
 	Db() IDbConfig
 	Jwt() IJwtConfig
+	Payment() IPaymentConfig
 }
 
 type config struct {
-	app *app
-	db  *db
-	jwt *jwt
+	app     *app
+	db      *db
+	jwt     *jwt
+	payment *payment
 }
 
 type IAppConfig interface {
@@ -122,36 +141,42 @@ type IAppConfig interface {
 	BodyLimit() int
 	FileLimit() int
 	GcpBucket() string
+	NotifyFile() string
+	CoPurchaseRefresh() time.Duration
 	Host() string
 	Port() int
 }
 
 type app struct {
-	host         string
-	port         int
-	name         string
-	version      string
-	readTimeout  time.Duration
-	writeTimeout time.Duration
-	bodyLimit    int //bytes
-	fileLimit    int //bytes
-	gcpbucket    string
+	host              string
+	port              int
+	name              string
+	version           string
+	readTimeout       time.Duration
+	writeTimeout      time.Duration
+	bodyLimit         int //bytes
+	fileLimit         int //bytes
+	gcpbucket         string
+	notifyFile        string        //empty = standard log
+	coPurchaseRefresh time.Duration //0 = off
 }
 
 func (c *config) App() IAppConfig {
 	return c.app
 }
 
-func (a *app) Url() string                 { return fmt.Sprintf("%s:%d", a.host, a.port) }
-func (a *app) Name() string                { return a.name }
-func (a *app) Version() string             { return a.version }
-func (a *app) ReadTimeout() time.Duration  { return a.readTimeout }
-func (a *app) WriteTimeout() time.Duration { return a.writeTimeout }
-func (a *app) BodyLimit() int              { return a.bodyLimit }
-func (a *app) FileLimit() int              { return a.fileLimit }
-func (a *app) GcpBucket() string           { return a.gcpbucket }
-func (a *app) Host() string                { return a.host }
-func (a *app) Port() int                   { return a.port }
+func (a *app) Url() string                      { return fmt.Sprintf("%s:%d", a.host, a.port) }
+func (a *app) Name() string                     { return a.name }
+func (a *app) Version() string                  { return a.version }
+func (a *app) ReadTimeout() time.Duration       { return a.readTimeout }
+func (a *app) WriteTimeout() time.Duration      { return a.writeTimeout }
+func (a *app) BodyLimit() int                   { return a.bodyLimit }
+func (a *app) FileLimit() int                   { return a.fileLimit }
+func (a *app) GcpBucket() string                { return a.gcpbucket }
+func (a *app) NotifyFile() string               { return a.notifyFile }
+func (a *app) CoPurchaseRefresh() time.Duration { return a.coPurchaseRefresh }
+func (a *app) Host() string                     { return a.host }
+func (a *app) Port() int                        { return a.port }
 
 type IDbConfig interface {
 	Url() string //host:port
@@ -219,3 +244,23 @@ func (j *jwt) SetRefreshExpiresAt(r int) { j.refreshExpiresAt = r }
 func (j *jwt) GetKeyInfo() string {
 	return fmt.Sprintf("adminKey=%s, apiKey=%s", j.adminKey, j.apiKey)
 }
+
+type IPaymentConfig interface {
+	PromptPayId() string
+	MerchantName() string
+	WebhookSecret() []byte
+}
+
+type payment struct {
+	promptPayId   string //phone number or tax id
+	merchantName  string
+	webhookSecret string
+}
+
+func (c *config) Payment() IPaymentConfig {
+	return c.payment
+}
+
+func (p *payment) PromptPayId() string   { return p.promptPayId }
+func (p *payment) MerchantName() string  { return p.merchantName }
+func (p *payment) WebhookSecret() []byte { return []byte(p.webhookSecret) }
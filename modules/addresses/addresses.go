@@ -0,0 +1,56 @@
+package addresses
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type Address struct {
+	Id          string `db:"id" json:"id"`
+	UserId      string `db:"user_id" json:"user_id"`
+	Recipient   string `db:"recipient" json:"recipient" form:"recipient"`
+	Phone       string `db:"phone" json:"phone" form:"phone"`
+	Line        string `db:"line" json:"line" form:"line"`
+	Subdistrict string `db:"subdistrict" json:"subdistrict" form:"subdistrict"`
+	District    string `db:"district" json:"district" form:"district"`
+	Province    string `db:"province" json:"province" form:"province"`
+	Postcode    string `db:"postcode" json:"postcode" form:"postcode"`
+	IsDefault   bool   `db:"is_default" json:"is_default" form:"is_default"`
+	CreatedAt   string `db:"created_at" json:"created_at,omitempty"`
+	UpdatedAt   string `db:"updated_at" json:"updated_at,omitempty"`
+}
+
+func (obj *Address) Validate() error {
+	required := []struct {
+		field string
+		value string
+	}{
+		{"recipient", obj.Recipient},
+		{"phone", obj.Phone},
+		{"line", obj.Line},
+		{"subdistrict", obj.Subdistrict},
+		{"district", obj.District},
+		{"province", obj.Province},
+		{"postcode", obj.Postcode},
+	}
+	for _, r := range required {
+		if strings.Trim(r.value, " ") == "" {
+			return fmt.Errorf("%s is required", r.field)
+		}
+	}
+
+	// Thai mobile and landline numbers, e.g. 0812345678 or 021234567
+	if match, _ := regexp.MatchString(`^0[0-9]{8,9}$`, obj.Phone); !match {
+		return fmt.Errorf("phone is invalid")
+	}
+	if match, _ := regexp.MatchString(`^[1-9][0-9]{4}$`, obj.Postcode); !match {
+		return fmt.Errorf("postcode is invalid")
+	}
+	return nil
+}
+
+// String formats the address the way it is written on a parcel
+func (obj *Address) String() string {
+	return fmt.Sprintf("%s %s %s %s %s", obj.Line, obj.Subdistrict, obj.District, obj.Province, obj.Postcode)
+}
@@ -0,0 +1,161 @@
+package addresseshandlers
+
+import (
+	"strings"
+
+	"github.com/Tanapoowapat/GunplaShop/config"
+	"github.com/Tanapoowapat/GunplaShop/modules/addresses"
+	addressesusecase "github.com/Tanapoowapat/GunplaShop/modules/addresses/addressesUsecase"
+	"github.com/Tanapoowapat/GunplaShop/modules/entities"
+	"github.com/gofiber/fiber/v2"
+)
+
+type addressesHandlersErr string
+
+const (
+	FindAddressesErr     addressesHandlersErr = "Addresses-001"
+	FindOneAddressErr    addressesHandlersErr = "Addresses-002"
+	AddAddressErr        addressesHandlersErr = "Addresses-003"
+	UpdateAddressErr     addressesHandlersErr = "Addresses-004"
+	SetDefaultAddressErr addressesHandlersErr = "Addresses-005"
+	RemoveAddressErr     addressesHandlersErr = "Addresses-006"
+)
+
+type IAddressesHandlers interface {
+	FindAddresses(c *fiber.Ctx) error
+	FindOneAddress(c *fiber.Ctx) error
+	AddAddress(c *fiber.Ctx) error
+	UpdateAddress(c *fiber.Ctx) error
+	SetDefaultAddress(c *fiber.Ctx) error
+	RemoveAddress(c *fiber.Ctx) error
+}
+
+type addressesHandlers struct {
+	cfg              config.IConfig
+	addressesUsecase addressesusecase.IAddressesUsecase
+}
+
+func NewAddressesHandlers(cfg config.IConfig, addressesUsecase addressesusecase.IAddressesUsecase) IAddressesHandlers {
+	return &addressesHandlers{
+		cfg:              cfg,
+		addressesUsecase: addressesUsecase,
+	}
+}
+
+func (h *addressesHandlers) FindAddresses(c *fiber.Ctx) error {
+	userId := strings.Trim(c.Params("userId"), " ")
+
+	result, err := h.addressesUsecase.FindAddresses(userId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(FindAddressesErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, result).Res()
+}
+
+func (h *addressesHandlers) FindOneAddress(c *fiber.Ctx) error {
+	userId := strings.Trim(c.Params("userId"), " ")
+	addressId := strings.Trim(c.Params("address_id"), " ")
+
+	result, err := h.addressesUsecase.FindOneAddress(userId, addressId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrNotFound.Code,
+			string(FindOneAddressErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, result).Res()
+}
+
+func (h *addressesHandlers) AddAddress(c *fiber.Ctx) error {
+	req := new(addresses.Address)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(AddAddressErr),
+			err.Error(),
+		).Res()
+	}
+	req.Id = ""
+	req.UserId = strings.Trim(c.Params("userId"), " ")
+
+	if err := req.Validate(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(AddAddressErr),
+			err.Error(),
+		).Res()
+	}
+
+	result, err := h.addressesUsecase.AddAddress(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(AddAddressErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusCreated, result).Res()
+}
+
+func (h *addressesHandlers) UpdateAddress(c *fiber.Ctx) error {
+	req := new(addresses.Address)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(UpdateAddressErr),
+			err.Error(),
+		).Res()
+	}
+	req.Id = strings.Trim(c.Params("address_id"), " ")
+	req.UserId = strings.Trim(c.Params("userId"), " ")
+
+	result, err := h.addressesUsecase.UpdateAddress(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(UpdateAddressErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, result).Res()
+}
+
+func (h *addressesHandlers) SetDefaultAddress(c *fiber.Ctx) error {
+	userId := strings.Trim(c.Params("userId"), " ")
+	addressId := strings.Trim(c.Params("address_id"), " ")
+
+	result, err := h.addressesUsecase.SetDefaultAddress(userId, addressId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(SetDefaultAddressErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, result).Res()
+}
+
+func (h *addressesHandlers) RemoveAddress(c *fiber.Ctx) error {
+	userId := strings.Trim(c.Params("userId"), " ")
+	addressId := strings.Trim(c.Params("address_id"), " ")
+
+	if err := h.addressesUsecase.RemoveAddress(userId, addressId); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(RemoveAddressErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(
+		fiber.StatusOK,
+		&struct {
+			AddressId string `json:"address_id"`
+		}{
+			AddressId: addressId,
+		}).Res()
+}
@@ -0,0 +1,242 @@
+package addressesrepositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/addresses"
+	"github.com/jmoiron/sqlx"
+)
+
+type IAddressesRepositories interface {
+	FindAddresses(userId string) ([]*addresses.Address, error)
+	FindOneAddress(userId, addressId string) (*addresses.Address, error)
+	FindDefaultAddress(userId string) (*addresses.Address, error)
+	InsertAddress(req *addresses.Address) (string, error)
+	UpdateAddress(req *addresses.Address) error
+	SetDefaultAddress(userId, addressId string) error
+	DeleteAddress(userId, addressId string) error
+}
+
+type addressesRepositories struct {
+	db *sqlx.DB
+}
+
+func NewAddressesRepositories(db *sqlx.DB) IAddressesRepositories {
+	return &addressesRepositories{
+		db: db,
+	}
+}
+
+const selectAddress = `
+	SELECT
+		"a"."id",
+		"a"."user_id",
+		"a"."recipient",
+		"a"."phone",
+		"a"."line",
+		"a"."subdistrict",
+		"a"."district",
+		"a"."province",
+		"a"."postcode",
+		"a"."is_default",
+		"a"."created_at",
+		"a"."updated_at"
+	FROM "addresses" "a"`
+
+func (repo *addressesRepositories) FindAddresses(userId string) ([]*addresses.Address, error) {
+	query := selectAddress + `
+	WHERE "a"."user_id" = $1
+	ORDER BY "a"."is_default" DESC, "a"."created_at" ASC;`
+
+	result := make([]*addresses.Address, 0)
+	if err := repo.db.Select(&result, query, userId); err != nil {
+		return nil, fmt.Errorf("get addresses failed: %v", err)
+	}
+	return result, nil
+}
+
+func (repo *addressesRepositories) FindOneAddress(userId, addressId string) (*addresses.Address, error) {
+	query := selectAddress + `
+	WHERE "a"."user_id" = $1 AND "a"."id" = $2;`
+
+	address := new(addresses.Address)
+	if err := repo.db.Get(address, query, userId, addressId); err != nil {
+		return nil, fmt.Errorf("address not found: %v", err)
+	}
+	return address, nil
+}
+
+func (repo *addressesRepositories) FindDefaultAddress(userId string) (*addresses.Address, error) {
+	query := selectAddress + `
+	WHERE "a"."user_id" = $1 AND "a"."is_default";`
+
+	address := new(addresses.Address)
+	if err := repo.db.Get(address, query, userId); err != nil {
+		return nil, fmt.Errorf("default address not found: %v", err)
+	}
+	return address, nil
+}
+
+// The first address a user adds always becomes their default
+func (repo *addressesRepositories) InsertAddress(req *addresses.Address) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRowxContext(ctx, `SELECT COUNT(*) FROM "addresses" WHERE "user_id" = $1;`, req.UserId).Scan(&count); err != nil {
+		return "", fmt.Errorf("count addresses failed: %v", err)
+	}
+	if count == 0 {
+		req.IsDefault = true
+	}
+
+	if req.IsDefault {
+		if err := clearDefault(ctx, tx, req.UserId); err != nil {
+			return "", err
+		}
+	}
+
+	query := `
+	INSERT INTO "addresses" (
+		"user_id",
+		"recipient",
+		"phone",
+		"line",
+		"subdistrict",
+		"district",
+		"province",
+		"postcode",
+		"is_default"
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	RETURNING "id";`
+
+	var addressId string
+	if err := tx.QueryRowxContext(ctx, query,
+		req.UserId,
+		req.Recipient,
+		req.Phone,
+		req.Line,
+		req.Subdistrict,
+		req.District,
+		req.Province,
+		req.Postcode,
+		req.IsDefault,
+	).Scan(&addressId); err != nil {
+		return "", fmt.Errorf("insert address failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return addressId, nil
+}
+
+func (repo *addressesRepositories) UpdateAddress(req *addresses.Address) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	query := `
+	UPDATE "addresses" SET
+		"recipient" = $3,
+		"phone" = $4,
+		"line" = $5,
+		"subdistrict" = $6,
+		"district" = $7,
+		"province" = $8,
+		"postcode" = $9
+	WHERE "user_id" = $1 AND "id" = $2;`
+
+	result, err := repo.db.ExecContext(ctx, query,
+		req.UserId,
+		req.Id,
+		req.Recipient,
+		req.Phone,
+		req.Line,
+		req.Subdistrict,
+		req.District,
+		req.Province,
+		req.Postcode,
+	)
+	if err != nil {
+		return fmt.Errorf("update address failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("address not found")
+	}
+	return nil
+}
+
+func (repo *addressesRepositories) SetDefaultAddress(userId, addressId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := clearDefault(ctx, tx, userId); err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, `UPDATE "addresses" SET "is_default" = TRUE WHERE "user_id" = $1 AND "id" = $2;`, userId, addressId)
+	if err != nil {
+		return fmt.Errorf("set default address failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("address not found")
+	}
+	return tx.Commit()
+}
+
+// Removing the default address promotes the newest remaining one
+func (repo *addressesRepositories) DeleteAddress(userId, addressId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var wasDefault bool
+	if err := tx.QueryRowxContext(ctx, `
+	DELETE FROM "addresses"
+	WHERE "user_id" = $1 AND "id" = $2
+	RETURNING "is_default";`, userId, addressId).Scan(&wasDefault); err != nil {
+		return fmt.Errorf("delete address failed: %v", err)
+	}
+
+	if wasDefault {
+		query := `
+	UPDATE "addresses" SET "is_default" = TRUE
+	WHERE "id" = (
+		SELECT "id" FROM "addresses"
+		WHERE "user_id" = $1
+		ORDER BY "created_at" DESC
+		LIMIT 1
+	);`
+		if _, err := tx.ExecContext(ctx, query, userId); err != nil {
+			return fmt.Errorf("promote default address failed: %v", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func clearDefault(ctx context.Context, tx *sqlx.Tx, userId string) error {
+	if _, err := tx.ExecContext(ctx, `UPDATE "addresses" SET "is_default" = FALSE WHERE "user_id" = $1 AND "is_default";`, userId); err != nil {
+		return fmt.Errorf("clear default address failed: %v", err)
+	}
+	return nil
+}
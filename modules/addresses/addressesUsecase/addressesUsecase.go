@@ -0,0 +1,90 @@
+package addressesusecase
+
+import (
+	"github.com/Tanapoowapat/GunplaShop/modules/addresses"
+	addressesrepositories "github.com/Tanapoowapat/GunplaShop/modules/addresses/addressesRepositories"
+)
+
+type IAddressesUsecase interface {
+	FindAddresses(userId string) ([]*addresses.Address, error)
+	FindOneAddress(userId, addressId string) (*addresses.Address, error)
+	AddAddress(req *addresses.Address) (*addresses.Address, error)
+	UpdateAddress(req *addresses.Address) (*addresses.Address, error)
+	SetDefaultAddress(userId, addressId string) (*addresses.Address, error)
+	RemoveAddress(userId, addressId string) error
+}
+
+type addressesUsecase struct {
+	addressesRepo addressesrepositories.IAddressesRepositories
+}
+
+func NewAddressesUsecase(addressesRepo addressesrepositories.IAddressesRepositories) IAddressesUsecase {
+	return &addressesUsecase{
+		addressesRepo: addressesRepo,
+	}
+}
+
+func (u *addressesUsecase) FindAddresses(userId string) ([]*addresses.Address, error) {
+	return u.addressesRepo.FindAddresses(userId)
+}
+
+func (u *addressesUsecase) FindOneAddress(userId, addressId string) (*addresses.Address, error) {
+	return u.addressesRepo.FindOneAddress(userId, addressId)
+}
+
+func (u *addressesUsecase) AddAddress(req *addresses.Address) (*addresses.Address, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	addressId, err := u.addressesRepo.InsertAddress(req)
+	if err != nil {
+		return nil, err
+	}
+	return u.addressesRepo.FindOneAddress(req.UserId, addressId)
+}
+
+// UpdateAddress only overwrites the fields present in req
+func (u *addressesUsecase) UpdateAddress(req *addresses.Address) (*addresses.Address, error) {
+	address, err := u.addressesRepo.FindOneAddress(req.UserId, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := []struct {
+		dst *string
+		src string
+	}{
+		{&address.Recipient, req.Recipient},
+		{&address.Phone, req.Phone},
+		{&address.Line, req.Line},
+		{&address.Subdistrict, req.Subdistrict},
+		{&address.District, req.District},
+		{&address.Province, req.Province},
+		{&address.Postcode, req.Postcode},
+	}
+	for _, f := range fields {
+		if f.src != "" {
+			*f.dst = f.src
+		}
+	}
+
+	if err := address.Validate(); err != nil {
+		return nil, err
+	}
+	if err := u.addressesRepo.UpdateAddress(address); err != nil {
+		return nil, err
+	}
+	return u.addressesRepo.FindOneAddress(req.UserId, req.Id)
+}
+
+func (u *addressesUsecase) SetDefaultAddress(userId, addressId string) (*addresses.Address, error) {
+	if err := u.addressesRepo.SetDefaultAddress(userId, addressId); err != nil {
+		return nil, err
+	}
+	return u.addressesRepo.FindOneAddress(userId, addressId)
+}
+
+func (u *addressesUsecase) RemoveAddress(userId, addressId string) error {
+	return u.addressesRepo.DeleteAddress(userId, addressId)
+}
@@ -1,10 +1,63 @@
 package appinfo
 
+import "strings"
+
 type CategoryFiter struct {
 	Title string `json:"title" query:"title"`
 }
 
 type Category struct {
-	Id    int    `db:"id" json:"id"`
-	Title string `db:"title" json:"title"`
+	Id       int    `db:"id" json:"id"`
+	Title    string `db:"title" json:"title"`
+	ParentId *int   `db:"parent_id" json:"parent_id"` // nil = top level
+}
+
+// CategoryNode is a category in the tree, ProductCount counts the published
+// products in it or in any category below it
+type CategoryNode struct {
+	Id           int             `db:"id" json:"id"`
+	Title        string          `db:"title" json:"title"`
+	ParentId     *int            `db:"parent_id" json:"parent_id"`
+	ProductCount int             `db:"product_count" json:"product_count"`
+	Children     []*CategoryNode `db:"-" json:"children"`
+}
+
+// BuildCategoryTree links the nodes to their parents and returns the top
+// level ones. With a title only the matching categories are returned, each
+// with its whole branch below it.
+func BuildCategoryTree(nodes []*CategoryNode, title string) []*CategoryNode {
+	byId := make(map[int]*CategoryNode, len(nodes))
+	for _, node := range nodes {
+		node.Children = make([]*CategoryNode, 0)
+		byId[node.Id] = node
+	}
+
+	roots := make([]*CategoryNode, 0)
+	for _, node := range nodes {
+		if node.ParentId != nil {
+			if parent, ok := byId[*node.ParentId]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	title = strings.ToLower(strings.Trim(title, " "))
+	if title == "" {
+		return roots
+	}
+	return matchCategory(roots, title)
+}
+
+func matchCategory(nodes []*CategoryNode, title string) []*CategoryNode {
+	matched := make([]*CategoryNode, 0)
+	for _, node := range nodes {
+		if strings.Contains(strings.ToLower(node.Title), title) {
+			matched = append(matched, node)
+			continue
+		}
+		matched = append(matched, matchCategory(node.Children, title)...)
+	}
+	return matched
 }
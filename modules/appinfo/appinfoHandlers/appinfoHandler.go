@@ -104,6 +104,15 @@ func (h *appinfoHandlers) InsertCategory(c *fiber.Ctx) error {
 			"Category request cannot be empty",
 		).Res()
 	}
+	for _, category := range req {
+		if category.ParentId != nil && *category.ParentId <= 0 {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(InsertCategoryErrCode),
+				"Invalid Parent Id",
+			).Res()
+		}
+	}
 
 	if err := h.appinfo_usecase.InsertCategory(req); err != nil {
 		return entities.NewResponse(c).Error(
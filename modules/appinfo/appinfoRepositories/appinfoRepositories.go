@@ -11,6 +11,7 @@ import (
 
 type IAppinfoRepositories interface {
 	FindCategory(req *appinfo.CategoryFiter) ([]*appinfo.Category, error)
+	FindCategoryNodes() ([]*appinfo.CategoryNode, error)
 	InsertCategory(req []*appinfo.Category) error
 	DeleteCategory(category_id int) error
 }
@@ -26,7 +27,7 @@ func AppinfoRepositories(db *sqlx.DB) IAppinfoRepositories {
 }
 
 func (r *appinfoRepositories) FindCategory(req *appinfo.CategoryFiter) ([]*appinfo.Category, error) {
-	query := `SELECT "id", "title" FROM "categories"`
+	query := `SELECT "id", "title", "parent_id" FROM "categories"`
 	filterVals := make([]interface{}, 0)
 
 	// Add WHERE clause if title filter is provided
@@ -45,6 +46,49 @@ func (r *appinfoRepositories) FindCategory(req *appinfo.CategoryFiter) ([]*appin
 	return category, nil
 }
 
+// FindCategoryNodes lists every category with the number of published
+// products in it or below it
+func (r *appinfoRepositories) FindCategoryNodes() ([]*appinfo.CategoryNode, error) {
+	// "tree" pairs every category with itself and each category below it
+	query := `
+	WITH RECURSIVE "tree" AS (
+		SELECT
+			"c"."id" AS "ancestor_id",
+			"c"."id" AS "category_id"
+		FROM "categories" "c"
+		UNION
+		SELECT
+			"t"."ancestor_id",
+			"c"."id"
+		FROM "tree" "t"
+			JOIN "categories" "c" ON "c"."parent_id" = "t"."category_id"
+	), "counts" AS (
+		SELECT
+			"t"."ancestor_id",
+			COUNT(DISTINCT "pc"."product_id") AS "product_count"
+		FROM "tree" "t"
+			JOIN "products_categories" "pc" ON "pc"."category_id" = "t"."category_id"
+			JOIN "products" "p" ON "p"."id" = "pc"."product_id"
+		WHERE "p"."deleted_at" IS NULL
+			AND product_effective_status("p"."status", "p"."publish_at", "p"."unpublish_at") = 'published'
+		GROUP BY "t"."ancestor_id"
+	)
+	SELECT
+		"c"."id",
+		"c"."title",
+		"c"."parent_id",
+		COALESCE("n"."product_count", 0) AS "product_count"
+	FROM "categories" "c"
+		LEFT JOIN "counts" "n" ON "n"."ancestor_id" = "c"."id"
+	ORDER BY "c"."title" ASC;`
+
+	nodes := make([]*appinfo.CategoryNode, 0)
+	if err := r.db.Select(&nodes, query); err != nil {
+		return nil, fmt.Errorf("select category tree failed: %v", err)
+	}
+	return nodes, nil
+}
+
 func (r *appinfoRepositories) InsertCategory(req []*appinfo.Category) error {
 	ctx := context.Background()
 	tx, err := r.db.BeginTxx(ctx, nil)
@@ -54,9 +98,9 @@ func (r *appinfoRepositories) InsertCategory(req []*appinfo.Category) error {
 	defer tx.Rollback()
 
 	for _, category := range req {
-		query := `INSERT INTO "categories" ("title") VALUES ($1) RETURNING "id"`
+		query := `INSERT INTO "categories" ("title", "parent_id") VALUES ($1, $2) RETURNING "id"`
 		var categoryId int
-		if err := tx.QueryRowxContext(ctx, query, category.Title).Scan(&categoryId); err != nil {
+		if err := tx.QueryRowxContext(ctx, query, category.Title, category.ParentId).Scan(&categoryId); err != nil {
 			return fmt.Errorf("insert category failed: %v", err)
 		}
 		category.Id = categoryId
@@ -70,9 +114,28 @@ func (r *appinfoRepositories) InsertCategory(req []*appinfo.Category) error {
 }
 
 func (r appinfoRepositories) DeleteCategory(category_id int) error {
-	query := `DELETE FROM "categories" where "id" = $1`
 	ctx := context.Background()
 
+	// A category still holding subcategories or products has to be emptied first
+	check := `
+	SELECT
+		EXISTS (SELECT 1 FROM "categories" WHERE "parent_id" = $1) AS "has_children",
+		EXISTS (SELECT 1 FROM "products_categories" WHERE "category_id" = $1) AS "has_products";`
+	var used struct {
+		HasChildren bool `db:"has_children"`
+		HasProducts bool `db:"has_products"`
+	}
+	if err := r.db.GetContext(ctx, &used, check, category_id); err != nil {
+		return fmt.Errorf("delete category failed: %v", err)
+	}
+	if used.HasChildren {
+		return fmt.Errorf("category %d still has subcategories", category_id)
+	}
+	if used.HasProducts {
+		return fmt.Errorf("category %d still has products", category_id)
+	}
+
+	query := `DELETE FROM "categories" where "id" = $1`
 	if _, err := r.db.ExecContext(ctx, query, category_id); err != nil {
 		return fmt.Errorf("delete category failed: %v", err)
 	}
@@ -6,7 +6,7 @@ import (
 )
 
 type IAppinfoUsecase interface {
-	FindCategory(req *appinfo.CategoryFiter) ([]*appinfo.Category, error)
+	FindCategory(req *appinfo.CategoryFiter) ([]*appinfo.CategoryNode, error)
 	InsertCategory(req []*appinfo.Category) error
 	DeleteCategory(category_id int) error
 }
@@ -21,17 +21,18 @@ func AppinfoRepositories(appinforepositories appinforepositories.IAppinfoReposit
 	}
 }
 
-func (u *appinfoUsecase) FindCategory(req *appinfo.CategoryFiter) ([]*appinfo.Category, error) {
-	category, err := u.appinfo_repo.FindCategory(req)
+// FindCategory returns the category tree, or the branches whose title matches the filter
+func (u *appinfoUsecase) FindCategory(req *appinfo.CategoryFiter) ([]*appinfo.CategoryNode, error) {
+	nodes, err := u.appinfo_repo.FindCategoryNodes()
 	if err != nil {
 		return nil, err
 	}
-	return category, nil
+	return appinfo.BuildCategoryTree(nodes, req.Title), nil
 }
 
 func (u *appinfoUsecase) InsertCategory(req []*appinfo.Category) error {
 	if err := u.appinfo_repo.InsertCategory(req); err != nil {
-		return nil
+		return err
 	}
 	return nil
 }
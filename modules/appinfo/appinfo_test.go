@@ -0,0 +1,46 @@
+package appinfo
+
+import "testing"
+
+func testNodes() []*CategoryNode {
+	parent := func(id int) *int { return &id }
+	return []*CategoryNode{
+		{Id: 3, Title: "HG", ParentId: parent(2), ProductCount: 4},
+		{Id: 1, Title: "Gundam", ProductCount: 6},
+		{Id: 2, Title: "Universal Century", ParentId: parent(1), ProductCount: 4},
+		{Id: 4, Title: "HG", ParentId: parent(5), ProductCount: 2},
+		{Id: 5, Title: "Cosmic Era", ParentId: parent(1), ProductCount: 2},
+		{Id: 6, Title: "Tools"},
+	}
+}
+
+func TestBuildCategoryTree(t *testing.T) {
+	roots := BuildCategoryTree(testNodes(), "")
+	if len(roots) != 2 || roots[0].Id != 1 || roots[1].Id != 6 {
+		t.Fatalf("BuildCategoryTree returned roots %+v, want Gundam and Tools", roots)
+	}
+
+	gundam := roots[0]
+	if len(gundam.Children) != 2 || gundam.Children[0].Id != 2 || gundam.Children[1].Id != 5 {
+		t.Fatalf("Gundam children = %+v, want Universal Century and Cosmic Era", gundam.Children)
+	}
+	if uc := gundam.Children[0]; len(uc.Children) != 1 || uc.Children[0].Id != 3 {
+		t.Errorf("Universal Century children = %+v, want HG", uc.Children)
+	}
+	if tools := roots[1]; tools.Children == nil || len(tools.Children) != 0 {
+		t.Errorf("Tools children = %v, want an empty list", tools.Children)
+	}
+}
+
+func TestBuildCategoryTreeByTitle(t *testing.T) {
+	matched := BuildCategoryTree(testNodes(), " hg ")
+	if len(matched) != 2 || matched[0].Id != 3 || matched[1].Id != 4 {
+		t.Errorf("BuildCategoryTree(hg) = %+v, want both HG categories", matched)
+	}
+
+	// A matching category brings its branch, its children are not listed again
+	matched = BuildCategoryTree(testNodes(), "gundam")
+	if len(matched) != 1 || matched[0].Id != 1 || len(matched[0].Children) != 2 {
+		t.Errorf("BuildCategoryTree(gundam) = %+v, want the Gundam branch", matched)
+	}
+}
@@ -0,0 +1,39 @@
+package cart
+
+import (
+	"github.com/Tanapoowapat/GunplaShop/modules/products"
+)
+
+type Cart struct {
+	UserId     string      `json:"user_id"`
+	Items      []*CartItem `json:"items"`
+	TotalQty   int         `json:"total_qty"`
+	TotalPrice float64     `json:"total_price"`
+}
+
+type CartItem struct {
+	Id        string             `db:"id" json:"id"`
+	ProductId string             `db:"product_id" json:"-"`
+	VariantId string             `db:"variant_id" json:"-"`
+	Qty       int                `db:"qty" json:"qty"`
+	Product   *products.Products `json:"product"`
+	Variant   *products.Variant  `json:"variant,omitempty"`
+	Subtotal  float64            `json:"subtotal"`
+	CreatedAt string             `db:"created_at" json:"created_at"`
+	UpdatedAt string             `db:"updated_at" json:"updated_at"`
+}
+
+type CartItemReq struct {
+	Id        string `json:"-"`
+	UserId    string `json:"-"`
+	ProductId string `json:"product_id" form:"product_id"`
+	VariantId string `json:"variant_id" form:"variant_id"`
+	Qty       int    `json:"qty" form:"qty"`
+}
+
+// CheckoutReq ships to the user's default address when AddressId is empty
+type CheckoutReq struct {
+	UserId        string `json:"-"`
+	AddressId     string `json:"address_id" form:"address_id"`
+	PromotionCode string `json:"promotion_code" form:"promotion_code"`
+}
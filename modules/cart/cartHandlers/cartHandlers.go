@@ -0,0 +1,167 @@
+package carthandlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Tanapoowapat/GunplaShop/config"
+	"github.com/Tanapoowapat/GunplaShop/modules/cart"
+	cartusecase "github.com/Tanapoowapat/GunplaShop/modules/cart/cartUsecase"
+	"github.com/Tanapoowapat/GunplaShop/modules/entities"
+	"github.com/Tanapoowapat/GunplaShop/modules/inventory"
+	"github.com/Tanapoowapat/GunplaShop/modules/promotions"
+	"github.com/gofiber/fiber/v2"
+)
+
+type cartHandlersErr string
+
+const (
+	FindCartErr   cartHandlersErr = "Carts-001"
+	AddItemErr    cartHandlersErr = "Carts-002"
+	UpdateItemErr cartHandlersErr = "Carts-003"
+	RemoveItemErr cartHandlersErr = "Carts-004"
+	CheckoutErr   cartHandlersErr = "Carts-005"
+)
+
+type ICartHandlers interface {
+	FindCart(c *fiber.Ctx) error
+	AddItem(c *fiber.Ctx) error
+	UpdateItem(c *fiber.Ctx) error
+	RemoveItem(c *fiber.Ctx) error
+	Checkout(c *fiber.Ctx) error
+}
+
+type cartHandlers struct {
+	cfg         config.IConfig
+	cartUsecase cartusecase.ICartUsecase
+}
+
+func NewCartHandlers(cfg config.IConfig, cartUsecase cartusecase.ICartUsecase) ICartHandlers {
+	return &cartHandlers{
+		cfg:         cfg,
+		cartUsecase: cartUsecase,
+	}
+}
+
+func (h *cartHandlers) FindCart(c *fiber.Ctx) error {
+	userId := strings.Trim(c.Params("userId"), " ")
+
+	result, err := h.cartUsecase.FindCart(userId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(FindCartErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, result).Res()
+}
+
+func (h *cartHandlers) AddItem(c *fiber.Ctx) error {
+	req := new(cart.CartItemReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(AddItemErr),
+			err.Error(),
+		).Res()
+	}
+	req.UserId = strings.Trim(c.Params("userId"), " ")
+
+	if req.ProductId == "" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(AddItemErr),
+			"product id is empty",
+		).Res()
+	}
+	if req.Qty == 0 {
+		req.Qty = 1
+	}
+
+	result, err := h.cartUsecase.AddItem(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(AddItemErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusCreated, result).Res()
+}
+
+func (h *cartHandlers) UpdateItem(c *fiber.Ctx) error {
+	req := new(cart.CartItemReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(UpdateItemErr),
+			err.Error(),
+		).Res()
+	}
+	req.UserId = strings.Trim(c.Params("userId"), " ")
+	req.Id = strings.Trim(c.Params("item_id"), " ")
+
+	result, err := h.cartUsecase.UpdateItem(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(UpdateItemErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, result).Res()
+}
+
+func (h *cartHandlers) RemoveItem(c *fiber.Ctx) error {
+	userId := strings.Trim(c.Params("userId"), " ")
+	itemId := strings.Trim(c.Params("item_id"), " ")
+
+	result, err := h.cartUsecase.RemoveItem(userId, itemId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(RemoveItemErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, result).Res()
+}
+
+func (h *cartHandlers) Checkout(c *fiber.Ctx) error {
+	req := new(cart.CheckoutReq)
+	if err := c.BodyParser(req); err != nil && !errors.Is(err, fiber.ErrUnprocessableEntity) {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(CheckoutErr),
+			err.Error(),
+		).Res()
+	}
+	req.UserId = strings.Trim(c.Params("userId"), " ")
+
+	order, err := h.cartUsecase.Checkout(req)
+	if err != nil {
+		var stockErr *inventory.OutOfStockError
+		if errors.As(err, &stockErr) {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(CheckoutErr),
+				stockErr.Error(),
+			).Res()
+		}
+		var promotionErr *promotions.PromotionError
+		if errors.As(err, &promotionErr) {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(CheckoutErr),
+				promotionErr.Error(),
+			).Res()
+		}
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(CheckoutErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusCreated, order).Res()
+}
@@ -0,0 +1,127 @@
+package cartrepositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/cart"
+	"github.com/jmoiron/sqlx"
+)
+
+type ICartRepositories interface {
+	FindCartItems(userId string) ([]*cart.CartItem, error)
+	FindOneCartItem(userId, itemId string) (*cart.CartItem, error)
+	InsertCartItem(req *cart.CartItemReq) (string, error)
+	UpdateCartItem(req *cart.CartItemReq) error
+	DeleteCartItem(userId, itemId string) error
+}
+
+type cartRepositories struct {
+	db *sqlx.DB
+}
+
+func NewCartRepositories(db *sqlx.DB) ICartRepositories {
+	return &cartRepositories{
+		db: db,
+	}
+}
+
+func (repo *cartRepositories) FindCartItems(userId string) ([]*cart.CartItem, error) {
+	query := `
+	SELECT
+		"c"."id",
+		"c"."product_id",
+		COALESCE("c"."variant_id"::TEXT, '') AS "variant_id",
+		"c"."qty",
+		"c"."created_at",
+		"c"."updated_at"
+	FROM "carts" "c"
+	WHERE "c"."user_id" = $1
+	ORDER BY "c"."created_at" ASC;`
+
+	items := make([]*cart.CartItem, 0)
+	if err := repo.db.Select(&items, query, userId); err != nil {
+		return nil, fmt.Errorf("get cart items failed: %v", err)
+	}
+	return items, nil
+}
+
+func (repo *cartRepositories) FindOneCartItem(userId, itemId string) (*cart.CartItem, error) {
+	query := `
+	SELECT
+		"c"."id",
+		"c"."product_id",
+		COALESCE("c"."variant_id"::TEXT, '') AS "variant_id",
+		"c"."qty",
+		"c"."created_at",
+		"c"."updated_at"
+	FROM "carts" "c"
+	WHERE "c"."user_id" = $1 AND "c"."id" = $2;`
+
+	item := new(cart.CartItem)
+	if err := repo.db.Get(item, query, userId, itemId); err != nil {
+		return nil, fmt.Errorf("cart item not found: %v", err)
+	}
+	return item, nil
+}
+
+// Adding a product (variant) that is already in the cart increases its quantity
+func (repo *cartRepositories) InsertCartItem(req *cart.CartItemReq) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	query := `
+	INSERT INTO "carts" (
+		"user_id",
+		"product_id",
+		"variant_id",
+		"qty"
+	)
+	VALUES ($1, $2, NULLIF($3, '')::UUID, $4)
+	ON CONFLICT ("user_id", "product_id", COALESCE("variant_id", '00000000-0000-0000-0000-000000000000'::UUID)) DO UPDATE SET
+		"qty" = "carts"."qty" + EXCLUDED."qty"
+		RETURNING "id";`
+
+	var itemId string
+	if err := repo.db.QueryRowxContext(
+		ctx,
+		query,
+		req.UserId,
+		req.ProductId,
+		req.VariantId,
+		req.Qty,
+	).Scan(&itemId); err != nil {
+		return "", fmt.Errorf("insert cart item failed: %v", err)
+	}
+	return itemId, nil
+}
+
+func (repo *cartRepositories) UpdateCartItem(req *cart.CartItemReq) error {
+	query := `
+	UPDATE "carts" SET
+		"qty" = $1
+	WHERE "user_id" = $2 AND "id" = $3;`
+
+	result, err := repo.db.ExecContext(context.Background(), query, req.Qty, req.UserId, req.Id)
+	if err != nil {
+		return fmt.Errorf("update cart item failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("cart item not found")
+	}
+	return nil
+}
+
+func (repo *cartRepositories) DeleteCartItem(userId, itemId string) error {
+	query := `DELETE FROM "carts" WHERE "user_id" = $1 AND "id" = $2;`
+
+	result, err := repo.db.ExecContext(context.Background(), query, userId, itemId)
+	if err != nil {
+		return fmt.Errorf("delete cart item failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("cart item not found")
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package cartusecase
+
+import (
+	"fmt"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/cart"
+	cartrepositories "github.com/Tanapoowapat/GunplaShop/modules/cart/cartRepositories"
+	"github.com/Tanapoowapat/GunplaShop/modules/orders"
+	ordersusecase "github.com/Tanapoowapat/GunplaShop/modules/orders/ordersUsecase"
+	"github.com/Tanapoowapat/GunplaShop/modules/products"
+	productsrepositories "github.com/Tanapoowapat/GunplaShop/modules/products/productsRepositories"
+)
+
+type ICartUsecase interface {
+	FindCart(userId string) (*cart.Cart, error)
+	AddItem(req *cart.CartItemReq) (*cart.Cart, error)
+	UpdateItem(req *cart.CartItemReq) (*cart.Cart, error)
+	RemoveItem(userId, itemId string) (*cart.Cart, error)
+	Checkout(req *cart.CheckoutReq) (*orders.Order, error)
+}
+
+type cartUsecase struct {
+	cartRepo      cartrepositories.ICartRepositories
+	productsRepo  productsrepositories.IProductRepositorise
+	ordersUsecase ordersusecase.IOrdersUsecase
+}
+
+func NewCartUsecase(cartRepo cartrepositories.ICartRepositories, productsRepo productsrepositories.IProductRepositorise, ordersUsecase ordersusecase.IOrdersUsecase) ICartUsecase {
+	return &cartUsecase{
+		cartRepo:      cartRepo,
+		productsRepo:  productsRepo,
+		ordersUsecase: ordersUsecase,
+	}
+}
+
+// FindCart returns the cart with the current catalogue price of every item
+func (u *cartUsecase) FindCart(userId string) (*cart.Cart, error) {
+	items, err := u.cartRepo.FindCartItems(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &cart.Cart{
+		UserId: userId,
+		Items:  items,
+	}
+	for _, item := range items {
+		product, err := u.productsRepo.FindOneProducts(item.ProductId)
+		if err != nil {
+			return nil, err
+		}
+		item.Product = product
+		item.Subtotal = product.Price * float64(item.Qty)
+		if item.VariantId != "" {
+			variant, err := u.productsRepo.FindOneVariant(item.VariantId)
+			if err != nil {
+				return nil, err
+			}
+			item.Variant = variant
+			item.Subtotal = variant.Price * float64(item.Qty)
+		}
+
+		result.TotalQty += item.Qty
+		result.TotalPrice += item.Subtotal
+	}
+	return result, nil
+}
+
+func (u *cartUsecase) AddItem(req *cart.CartItemReq) (*cart.Cart, error) {
+	if req.Qty <= 0 {
+		return nil, fmt.Errorf("qty must be greater than 0")
+	}
+
+	//Check product exists
+	product, err := u.productsRepo.FindOneProducts(req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+	if err := product.Purchasable(); err != nil {
+		return nil, err
+	}
+	if req.VariantId != "" {
+		variant, err := u.productsRepo.FindOneVariant(req.VariantId)
+		if err != nil {
+			return nil, err
+		}
+		if variant.ProductId != req.ProductId {
+			return nil, fmt.Errorf("variant %s does not belong to product %s", req.VariantId, req.ProductId)
+		}
+	}
+
+	if _, err := u.cartRepo.InsertCartItem(req); err != nil {
+		return nil, err
+	}
+	return u.FindCart(req.UserId)
+}
+
+func (u *cartUsecase) UpdateItem(req *cart.CartItemReq) (*cart.Cart, error) {
+	if req.Qty <= 0 {
+		return u.RemoveItem(req.UserId, req.Id)
+	}
+
+	if err := u.cartRepo.UpdateCartItem(req); err != nil {
+		return nil, err
+	}
+	return u.FindCart(req.UserId)
+}
+
+func (u *cartUsecase) RemoveItem(userId, itemId string) (*cart.Cart, error) {
+	if err := u.cartRepo.DeleteCartItem(userId, itemId); err != nil {
+		return nil, err
+	}
+	return u.FindCart(userId)
+}
+
+// Checkout places an order with every item in the cart, the cart is emptied
+// in the order transaction
+func (u *cartUsecase) Checkout(req *cart.CheckoutReq) (*orders.Order, error) {
+	items, err := u.cartRepo.FindCartItems(req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("cart is empty")
+	}
+
+	orderReq := &orders.Order{
+		UserId:        req.UserId,
+		AddressId:     req.AddressId,
+		PromotionCode: req.PromotionCode,
+		Status:        orders.StatusWaiting,
+		Product:       make([]*orders.ProductOrder, 0),
+		FromCart:      true,
+	}
+	for _, item := range items {
+		product, err := u.productsRepo.FindOneProducts(item.ProductId)
+		if err != nil {
+			return nil, err
+		}
+		line := &orders.ProductOrder{
+			Qty:     item.Qty,
+			Product: product,
+		}
+		if item.VariantId != "" {
+			line.Variant = &products.Variant{Id: item.VariantId}
+		}
+		orderReq.Product = append(orderReq.Product, line)
+	}
+
+	return u.ordersUsecase.InsertOrder(orderReq)
+}
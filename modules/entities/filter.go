@@ -1,13 +1,110 @@
 package entities
 
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
 type PaginationReq struct {
-	Page      int `query:"page"`
-	Limit     int `query:"limit"`
-	TotalPage int `query:"total_page" json:"total_page"`
-	TotalItem int `query:"total_item json:"total_item""`
+	Page      int    `query:"page"`
+	Limit     int    `query:"limit"`
+	TotalPage int    `query:"total_page" json:"total_page"`
+	TotalItem int    `query:"total_item" json:"total_item"`
+	Paginate  string `query:"paginate"` // offset (default) | cursor
+	Cursor    string `query:"cursor"`   // next_cursor or prev_cursor of the previous page
+}
+
+// IsCursor reports whether the keyset pagination is asked for instead of page/offset
+func (obj *PaginationReq) IsCursor() bool {
+	return obj.Cursor != "" || strings.ToLower(obj.Paginate) == "cursor"
 }
 
 type SortReq struct {
 	OrderBy string `query:"order_by"`
 	Sort    string `query:"sort"` // DESC | ASC
 }
+
+// Cursor is the row a cursor page starts after (or before when Backward),
+// identified by its sort values and id
+type Cursor struct {
+	OrderBy  string `json:"o"`
+	Sort     string `json:"s"`
+	Values   []any  `json:"v"`
+	Id       string `json:"i"`
+	Backward bool   `json:"b,omitempty"`
+}
+
+func EncodeCursor(cursor *Cursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func DecodeCursor(token string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("cursor is invalid")
+	}
+	cursor := new(Cursor)
+	if err := json.Unmarshal(raw, cursor); err != nil || cursor.Id == "" {
+		return nil, fmt.Errorf("cursor is invalid")
+	}
+	return cursor, nil
+}
+
+// CursorPage drops the extra row fetched by a cursor query and returns the
+// next/prev tokens around rows. rows are in display order, key gives the
+// sort values and id of a row.
+func CursorPage[T any](req *PaginationReq, sort *SortReq, rows []T, key func(T) ([]any, string)) ([]T, string, string) {
+	var current *Cursor
+	if req.Cursor != "" {
+		current, _ = DecodeCursor(req.Cursor)
+	}
+	backward := current != nil && current.Backward
+
+	hasMore := len(rows) > req.Limit
+	if hasMore && backward {
+		rows = rows[len(rows)-req.Limit:]
+	} else if hasMore {
+		rows = rows[:req.Limit]
+	}
+	if len(rows) == 0 {
+		return rows, "", ""
+	}
+
+	token := func(row T, backward bool) string {
+		values, id := key(row)
+		return EncodeCursor(&Cursor{
+			OrderBy:  sort.OrderBy,
+			Sort:     sort.Sort,
+			Values:   values,
+			Id:       id,
+			Backward: backward,
+		})
+	}
+
+	var next, prev string
+	if hasMore || backward {
+		next = token(rows[len(rows)-1], false)
+	}
+	if (backward && hasMore) || (!backward && current != nil) {
+		prev = token(rows[0], true)
+	}
+	return rows, next, prev
+}
+
+// CheckCursor rejects a cursor that was issued for a different sort
+func (obj *PaginationReq) CheckCursor(sort *SortReq) error {
+	if obj.Cursor == "" {
+		return nil
+	}
+	cursor, err := DecodeCursor(obj.Cursor)
+	if err != nil {
+		return err
+	}
+	if cursor.OrderBy != sort.OrderBy || !strings.EqualFold(cursor.Sort, sort.Sort) {
+		return fmt.Errorf("cursor does not match order_by and sort")
+	}
+	return nil
+}
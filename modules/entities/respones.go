@@ -59,9 +59,12 @@ func (r *Response) Res() error {
 }
 
 type PaginateRes struct {
-	Data       any `json:"data"`
-	Page       int `json:"page"`
-	Limit      int `json:"limit"`
-	TotalPage  int `json:"total_page"`
-	TotalItems int `json:"total_item"`
+	Data       any    `json:"data"`
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+	TotalPage  int    `json:"total_page"`
+	TotalItems int    `json:"total_item"`
+	Facets     any    `json:"facets,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
@@ -0,0 +1,122 @@
+package entities
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SortColumn is a column a listing may be sorted by, Expr is put in the query
+// as is so it must never come from the request
+type SortColumn struct {
+	Expr string
+	Cast string // type of the cursor value, e.g. FLOAT
+}
+
+type SortTerm struct {
+	Key    string
+	Column SortColumn
+	Desc   bool
+}
+
+// ParseSort turns order_by=price,-created_at into sort terms. A leading "-"
+// sorts that column descending, "+" ascending, the others follow sort.
+// Columns outside the whitelist are rejected.
+func ParseSort(req *SortReq, columns map[string]SortColumn) ([]*SortTerm, error) {
+	desc := strings.ToUpper(req.Sort) == "DESC"
+
+	terms := make([]*SortTerm, 0)
+	seen := make(map[string]bool)
+	for _, key := range strings.Split(req.OrderBy, ",") {
+		key = strings.Trim(key, " ")
+		if key == "" {
+			continue
+		}
+
+		term := &SortTerm{Desc: desc}
+		switch key[0] {
+		case '-':
+			term.Desc = true
+			key = key[1:]
+		case '+':
+			term.Desc = false
+			key = key[1:]
+		}
+
+		column, ok := columns[key]
+		if !ok {
+			return nil, fmt.Errorf("cannot sort by %s", key)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("%s is sorted more than once", key)
+		}
+		seen[key] = true
+
+		term.Key = key
+		term.Column = column
+		terms = append(terms, term)
+	}
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("order_by is required")
+	}
+	return terms, nil
+}
+
+// OrderByClause renders the terms followed by the id, which keeps rows with
+// equal values in a stable order. The id is left out when a term already sorts
+// by it. reverse flips every direction, it is used to read a backward cursor page.
+func OrderByClause(terms []*SortTerm, id string, reverse bool) string {
+	columns := make([]string, 0, len(terms)+1)
+	sortsById := false
+	for _, term := range terms {
+		columns = append(columns, term.Column.Expr+" "+direction(term.Desc != reverse))
+		sortsById = sortsById || term.Column.Expr == id
+	}
+	if !sortsById {
+		columns = append(columns, id+" "+direction(idDesc(terms) != reverse))
+	}
+	return "ORDER BY " + strings.Join(columns, ", ")
+}
+
+// KeysetCondition renders the rows coming after the cursor row in the
+// OrderByClause order, or before it when backward. The cursor values are bound
+// to $start+1 ... $start+len(terms) and the cursor id to the next placeholder.
+// The id is always compared last, even when a term sorts by it, so the number
+// of placeholders only depends on the terms.
+func KeysetCondition(terms []*SortTerm, id SortColumn, backward bool, start int) string {
+	keys := make([]SortColumn, 0, len(terms)+1)
+	descs := make([]bool, 0, len(terms)+1)
+	for _, term := range terms {
+		keys = append(keys, term.Column)
+		descs = append(descs, term.Desc)
+	}
+	keys = append(keys, id)
+	descs = append(descs, idDesc(terms))
+
+	// (a > $1) OR (a = $1 AND b < $2) OR ...
+	ors := make([]string, 0, len(keys))
+	for i := range keys {
+		ands := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, fmt.Sprintf("%s = $%d::%s", keys[j].Expr, start+j+1, keys[j].Cast))
+		}
+		operator := ">"
+		if descs[i] != backward {
+			operator = "<"
+		}
+		ands = append(ands, fmt.Sprintf("%s %s $%d::%s", keys[i].Expr, operator, start+i+1, keys[i].Cast))
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return "(" + strings.Join(ors, " OR ") + ")"
+}
+
+// The id follows the direction of the first term
+func idDesc(terms []*SortTerm) bool {
+	return len(terms) > 0 && terms[0].Desc
+}
+
+func direction(desc bool) string {
+	if desc {
+		return "DESC"
+	}
+	return "ASC"
+}
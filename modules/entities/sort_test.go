@@ -0,0 +1,102 @@
+package entities
+
+import (
+	"testing"
+)
+
+var testColumns = map[string]SortColumn{
+	"id":         {Expr: `"p"."id"`, Cast: "VARCHAR"},
+	"price":      {Expr: `"p"."price"`, Cast: "FLOAT"},
+	"created_at": {Expr: `"p"."created_at"`, Cast: "TIMESTAMP"},
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *SortReq
+		want    string
+		wantErr bool
+	}{
+		{"single ascending", &SortReq{OrderBy: "price", Sort: "ASC"}, `ORDER BY "p"."price" ASC, "p"."id" ASC`, false},
+		{"single follows sort", &SortReq{OrderBy: "price", Sort: "desc"}, `ORDER BY "p"."price" DESC, "p"."id" DESC`, false},
+		{"minus is descending", &SortReq{OrderBy: "-price", Sort: "ASC"}, `ORDER BY "p"."price" DESC, "p"."id" DESC`, false},
+		{"plus is ascending", &SortReq{OrderBy: "+price", Sort: "DESC"}, `ORDER BY "p"."price" ASC, "p"."id" ASC`, false},
+		{"multi column", &SortReq{OrderBy: "price,-created_at", Sort: "ASC"}, `ORDER BY "p"."price" ASC, "p"."created_at" DESC, "p"."id" ASC`, false},
+		{"spaces are trimmed", &SortReq{OrderBy: " price , -created_at ", Sort: "ASC"}, `ORDER BY "p"."price" ASC, "p"."created_at" DESC, "p"."id" ASC`, false},
+		{"id is not repeated", &SortReq{OrderBy: "-id", Sort: "ASC"}, `ORDER BY "p"."id" DESC`, false},
+		{"id as a later term", &SortReq{OrderBy: "price,-id", Sort: "ASC"}, `ORDER BY "p"."price" ASC, "p"."id" DESC`, false},
+		{"unknown column", &SortReq{OrderBy: "price;DROP TABLE products", Sort: "ASC"}, "", true},
+		{"duplicated column", &SortReq{OrderBy: "price,-price", Sort: "ASC"}, "", true},
+		{"empty", &SortReq{OrderBy: "", Sort: "ASC"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			terms, err := ParseSort(tt.req, testColumns)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseSort(%q) expected an error", tt.req.OrderBy)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSort(%q) unexpected error: %v", tt.req.OrderBy, err)
+			}
+			if got := OrderByClause(terms, `"p"."id"`, false); got != tt.want {
+				t.Errorf("OrderByClause() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderByClauseReverse(t *testing.T) {
+	terms, _ := ParseSort(&SortReq{OrderBy: "price,-created_at", Sort: "ASC"}, testColumns)
+
+	want := `ORDER BY "p"."price" DESC, "p"."created_at" ASC, "p"."id" DESC`
+	if got := OrderByClause(terms, `"p"."id"`, true); got != want {
+		t.Errorf("OrderByClause() = %s, want %s", got, want)
+	}
+}
+
+func TestKeysetCondition(t *testing.T) {
+	terms, _ := ParseSort(&SortReq{OrderBy: "price,-created_at", Sort: "ASC"}, testColumns)
+
+	tests := []struct {
+		name     string
+		backward bool
+		want     string
+	}{
+		{
+			"forward",
+			false,
+			`(("p"."price" > $3::FLOAT) OR ("p"."price" = $3::FLOAT AND "p"."created_at" < $4::TIMESTAMP) OR ("p"."price" = $3::FLOAT AND "p"."created_at" = $4::TIMESTAMP AND "p"."id" > $5::VARCHAR))`,
+		},
+		{
+			"backward",
+			true,
+			`(("p"."price" < $3::FLOAT) OR ("p"."price" = $3::FLOAT AND "p"."created_at" > $4::TIMESTAMP) OR ("p"."price" = $3::FLOAT AND "p"."created_at" = $4::TIMESTAMP AND "p"."id" < $5::VARCHAR))`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KeysetCondition(terms, testColumns["id"], tt.backward, 2); got != tt.want {
+				t.Errorf("KeysetCondition() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	token := EncodeCursor(&Cursor{OrderBy: "price", Sort: "ASC", Values: []any{1500.5}, Id: "P000001"})
+
+	cursor, err := DecodeCursor(token)
+	if err != nil {
+		t.Fatalf("DecodeCursor() unexpected error: %v", err)
+	}
+	if cursor.Id != "P000001" || len(cursor.Values) != 1 || cursor.Values[0] != 1500.5 {
+		t.Errorf("DecodeCursor() = %+v", cursor)
+	}
+
+	if _, err := DecodeCursor("not a cursor"); err == nil {
+		t.Error("DecodeCursor() expected an error for a malformed token")
+	}
+}
@@ -1,6 +1,17 @@
 package file
 
-import "mime/multipart"
+import (
+	"fmt"
+	"math"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+
+	"github.com/Tanapoowapat/GunplaShop/pkg/utils"
+)
+
+// ImageExtensions are the image files an upload may be
+var ImageExtensions = []string{"png", "jpg", "jpeg"}
 
 type FileReq struct {
 	File        *multipart.FileHeader `form:"file"`
@@ -17,3 +28,29 @@ type FileRes struct {
 type DeleteFileReq struct {
 	Destination string `json:"destination"`
 }
+
+// NewImageReq checks an uploaded image against ImageExtensions and fileLimit
+// (bytes) and gives it a random name in the directory
+func NewImageReq(f *multipart.FileHeader, directory string, fileLimit int) (*FileReq, error) {
+	ext := strings.TrimPrefix(filepath.Ext(f.Filename), ".")
+	valid := false
+	for _, allowed := range ImageExtensions {
+		if ext == allowed {
+			valid = true
+		}
+	}
+	if !valid {
+		return nil, fmt.Errorf("invalid file")
+	}
+	if f.Size > int64(fileLimit) {
+		return nil, fmt.Errorf("file must be less than %d MB", int(math.Ceil(float64(fileLimit)/math.Pow(1024, 2))))
+	}
+
+	filename := utils.RandomFileName(ext)
+	return &FileReq{
+		File:        f,
+		Destination: directory + "/" + filename,
+		FileName:    filename,
+		Extension:   ext,
+	}, nil
+}
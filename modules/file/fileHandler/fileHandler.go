@@ -1,16 +1,10 @@
 package filehandler
 
 import (
-	"fmt"
-	"math"
-	"path/filepath"
-	"strings"
-
 	"github.com/Tanapoowapat/GunplaShop/config"
 	"github.com/Tanapoowapat/GunplaShop/modules/entities"
 	"github.com/Tanapoowapat/GunplaShop/modules/file"
 	filesusecase "github.com/Tanapoowapat/GunplaShop/modules/file/filesUsecase"
-	"github.com/Tanapoowapat/GunplaShop/pkg/utils"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -57,36 +51,16 @@ func (h *fileHandler) UploadFileToGCP(c *fiber.Ctx) error {
 	destination := c.FormValue("destination")
 
 	// File Validation
-	extMap := map[string]string{
-		"png":  "png",
-		"jpg":  "jpg",
-		"jpeg": "jpeg",
-	}
-
 	for _, f := range filesReq {
-		ext := strings.TrimPrefix(filepath.Ext(f.Filename), ".")
-		if extMap[ext] != ext || extMap[ext] == "" {
-			return entities.NewResponse(c).Error(
-				fiber.ErrBadRequest.Code,
-				string(UploadErr),
-				"Invalid File",
-			).Res()
-		}
-
-		if f.Size > int64(h.cfg.App().FileLimit()) {
+		fileReq, err := file.NewImageReq(f, destination, h.cfg.App().FileLimit())
+		if err != nil {
 			return entities.NewResponse(c).Error(
 				fiber.ErrBadRequest.Code,
 				string(UploadErr),
-				fmt.Sprintf("File must be less than %d MB", int(math.Ceil(float64(h.cfg.App().FileLimit())/math.Pow(1024, 2)))),
+				err.Error(),
 			).Res()
 		}
-		filename := utils.RandomFileName(ext)
-		req = append(req, &file.FileReq{
-			File:        f,
-			Destination: destination + "/" + filename,
-			FileName:    filename,
-			Extension:   ext,
-		})
+		req = append(req, fileReq)
 	}
 
 	res, err := h.usecase.UploadImageGCP(req)
@@ -141,36 +115,16 @@ func (h *fileHandler) UploadImageLocal(c *fiber.Ctx) error {
 	destination := c.FormValue("destination")
 
 	// File Validation
-	extMap := map[string]string{
-		"png":  "png",
-		"jpg":  "jpg",
-		"jpeg": "jpeg",
-	}
-
 	for _, f := range filesReq {
-		ext := strings.TrimPrefix(filepath.Ext(f.Filename), ".")
-		if extMap[ext] != ext || extMap[ext] == "" {
-			return entities.NewResponse(c).Error(
-				fiber.ErrBadRequest.Code,
-				string(UploadErr),
-				"Invalid File",
-			).Res()
-		}
-
-		if f.Size > int64(h.cfg.App().FileLimit()) {
+		fileReq, err := file.NewImageReq(f, destination, h.cfg.App().FileLimit())
+		if err != nil {
 			return entities.NewResponse(c).Error(
 				fiber.ErrBadRequest.Code,
 				string(UploadErr),
-				fmt.Sprintf("File must be less than %d MB", int(math.Ceil(float64(h.cfg.App().FileLimit())/math.Pow(1024, 2)))),
+				err.Error(),
 			).Res()
 		}
-		filename := utils.RandomFileName(ext)
-		req = append(req, &file.FileReq{
-			File:        f,
-			Destination: destination + "/" + filename,
-			FileName:    filename,
-			Extension:   ext,
-		})
+		req = append(req, fileReq)
 	}
 
 	res, err := h.usecase.UploadImageLocal(req)
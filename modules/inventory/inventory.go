@@ -0,0 +1,40 @@
+package inventory
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Stock struct {
+	ProductId string `db:"product_id" json:"product_id"`
+	Stock     int    `db:"stock" json:"stock"`
+}
+
+// StockReq takes stock from the variant when VariantId is set,
+// otherwise from the product itself
+type StockReq struct {
+	ProductId string `json:"product_id"`
+	VariantId string `json:"variant_id,omitempty"`
+	Qty       int    `json:"qty"`
+}
+
+type OutOfStockItem struct {
+	ProductId string `json:"product_id"`
+	VariantId string `json:"variant_id,omitempty"`
+	Title     string `json:"title"`
+	Requested int    `json:"requested"`
+	Available int    `json:"available"`
+}
+
+// OutOfStockError lists every order line that cannot be fulfilled
+type OutOfStockError struct {
+	Items []*OutOfStockItem
+}
+
+func (e *OutOfStockError) Error() string {
+	lines := make([]string, 0, len(e.Items))
+	for _, item := range e.Items {
+		lines = append(lines, fmt.Sprintf("%s (%s) requested %d, available %d", item.ProductId, item.Title, item.Requested, item.Available))
+	}
+	return "out of stock: " + strings.Join(lines, "; ")
+}
@@ -0,0 +1,79 @@
+package inventoryhandlers
+
+import (
+	"strings"
+
+	"github.com/Tanapoowapat/GunplaShop/config"
+	"github.com/Tanapoowapat/GunplaShop/modules/entities"
+	"github.com/Tanapoowapat/GunplaShop/modules/inventory"
+	inventoryusecase "github.com/Tanapoowapat/GunplaShop/modules/inventory/inventoryUsecase"
+	"github.com/gofiber/fiber/v2"
+)
+
+type inventoryHandlersErr string
+
+const (
+	FindStockErr   inventoryHandlersErr = "Inventory-001"
+	UpdateStockErr inventoryHandlersErr = "Inventory-002"
+)
+
+type IInventoryHandlers interface {
+	FindStock(c *fiber.Ctx) error
+	UpdateStock(c *fiber.Ctx) error
+}
+
+type inventoryHandlers struct {
+	cfg              config.IConfig
+	inventoryUsecase inventoryusecase.IInventoryUsecase
+}
+
+func NewInventoryHandlers(cfg config.IConfig, inventoryUsecase inventoryusecase.IInventoryUsecase) IInventoryHandlers {
+	return &inventoryHandlers{
+		cfg:              cfg,
+		inventoryUsecase: inventoryUsecase,
+	}
+}
+
+func (h *inventoryHandlers) FindStock(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("product_id"), " ")
+
+	stock, err := h.inventoryUsecase.FindStock(productId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(FindStockErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, stock).Res()
+}
+
+func (h *inventoryHandlers) UpdateStock(c *fiber.Ctx) error {
+	req := new(inventory.Stock)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(UpdateStockErr),
+			err.Error(),
+		).Res()
+	}
+	req.ProductId = strings.Trim(c.Params("product_id"), " ")
+
+	if req.Stock < 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(UpdateStockErr),
+			"stock must not be negative",
+		).Res()
+	}
+
+	stock, err := h.inventoryUsecase.UpdateStock(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(UpdateStockErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, stock).Res()
+}
@@ -0,0 +1,174 @@
+package inventoryrepositories
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/inventory"
+	"github.com/jmoiron/sqlx"
+)
+
+type IInventoryRepositories interface {
+	FindStock(productId string) (*inventory.Stock, error)
+	UpdateStock(req *inventory.Stock) error
+	ReserveStock(tx *sqlx.Tx, req []*inventory.StockReq) error
+	ReleaseStock(tx *sqlx.Tx, req []*inventory.StockReq) error
+}
+
+type inventoryRepositories struct {
+	db *sqlx.DB
+}
+
+func NewInventoryRepositories(db *sqlx.DB) IInventoryRepositories {
+	return &inventoryRepositories{
+		db: db,
+	}
+}
+
+func (repo *inventoryRepositories) FindStock(productId string) (*inventory.Stock, error) {
+	query := `
+	SELECT
+		"id" AS "product_id",
+		"stock"
+	FROM "products"
+	WHERE "id" = $1;`
+
+	stock := new(inventory.Stock)
+	if err := repo.db.Get(stock, query, productId); err != nil {
+		return nil, fmt.Errorf("get stock failed: %v", err)
+	}
+	return stock, nil
+}
+
+func (repo *inventoryRepositories) UpdateStock(req *inventory.Stock) error {
+	query := `
+	UPDATE "products" SET
+		"stock" = $1
+	WHERE "id" = $2;`
+
+	result, err := repo.db.ExecContext(context.Background(), query, req.Stock, req.ProductId)
+	if err != nil {
+		return fmt.Errorf("update stock failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("product not found")
+	}
+	return nil
+}
+
+// ReserveStock locks every product row and decrements its stock inside tx.
+// Nothing is decremented unless every line can be fulfilled.
+func (repo *inventoryRepositories) ReserveStock(tx *sqlx.Tx, req []*inventory.StockReq) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	lockQuery := `
+	SELECT
+		"title",
+		"stock"
+	FROM "products"
+	WHERE "id" = $1
+	FOR UPDATE;`
+
+	lockVariantQuery := `
+	SELECT
+		CONCAT("p"."title", ' - ', "v"."title"),
+		"v"."stock"
+	FROM "product_variants" "v"
+	JOIN "products" "p" ON "p"."id" = "v"."product_id"
+	WHERE "v"."id" = $1
+	AND "v"."product_id" = $2
+	FOR UPDATE OF "v";`
+
+	items := mergeStockReq(req)
+	outOfStock := make([]*inventory.OutOfStockItem, 0)
+	for _, item := range items {
+		var title string
+		var stock int
+		row := tx.QueryRowxContext(ctx, lockQuery, item.ProductId)
+		if item.VariantId != "" {
+			row = tx.QueryRowxContext(ctx, lockVariantQuery, item.VariantId, item.ProductId)
+		}
+		if err := row.Scan(&title, &stock); err != nil {
+			return fmt.Errorf("lock stock of %s failed: %v", item.ProductId, err)
+		}
+		if stock < item.Qty {
+			outOfStock = append(outOfStock, &inventory.OutOfStockItem{
+				ProductId: item.ProductId,
+				VariantId: item.VariantId,
+				Title:     title,
+				Requested: item.Qty,
+				Available: stock,
+			})
+		}
+	}
+	if len(outOfStock) > 0 {
+		return &inventory.OutOfStockError{Items: outOfStock}
+	}
+
+	return repo.adjustStock(ctx, tx, items, -1)
+}
+
+// ReleaseStock gives the reserved quantity back to the products inside tx
+func (repo *inventoryRepositories) ReleaseStock(tx *sqlx.Tx, req []*inventory.StockReq) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	return repo.adjustStock(ctx, tx, mergeStockReq(req), 1)
+}
+
+func (repo *inventoryRepositories) adjustStock(ctx context.Context, tx *sqlx.Tx, items []*inventory.StockReq, sign int) error {
+	query := `
+	UPDATE "products" SET
+		"stock" = "stock" + $1
+	WHERE "id" = $2;`
+
+	variantQuery := `
+	UPDATE "product_variants" SET
+		"stock" = "stock" + $1
+	WHERE "id" = $2;`
+
+	for _, item := range items {
+		var err error
+		if item.VariantId != "" {
+			_, err = tx.ExecContext(ctx, variantQuery, sign*item.Qty, item.VariantId)
+		} else {
+			_, err = tx.ExecContext(ctx, query, sign*item.Qty, item.ProductId)
+		}
+		if err != nil {
+			return fmt.Errorf("update stock of %s failed: %v", item.ProductId, err)
+		}
+	}
+	return nil
+}
+
+// mergeStockReq sums the qty of duplicated products/variants and sorts them by id,
+// so concurrent transactions always lock rows in the same order
+func mergeStockReq(req []*inventory.StockReq) []*inventory.StockReq {
+	type stockKey struct {
+		productId string
+		variantId string
+	}
+	qtyMap := make(map[stockKey]int)
+	for _, r := range req {
+		qtyMap[stockKey{r.ProductId, r.VariantId}] += r.Qty
+	}
+
+	items := make([]*inventory.StockReq, 0, len(qtyMap))
+	for key, qty := range qtyMap {
+		items = append(items, &inventory.StockReq{
+			ProductId: key.productId,
+			VariantId: key.variantId,
+			Qty:       qty,
+		})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].ProductId != items[j].ProductId {
+			return items[i].ProductId < items[j].ProductId
+		}
+		return items[i].VariantId < items[j].VariantId
+	})
+	return items
+}
@@ -0,0 +1,37 @@
+package inventoryusecase
+
+import (
+	"fmt"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/inventory"
+	inventoryrepositories "github.com/Tanapoowapat/GunplaShop/modules/inventory/inventoryRepositories"
+)
+
+type IInventoryUsecase interface {
+	FindStock(productId string) (*inventory.Stock, error)
+	UpdateStock(req *inventory.Stock) (*inventory.Stock, error)
+}
+
+type inventoryUsecase struct {
+	inventoryRepo inventoryrepositories.IInventoryRepositories
+}
+
+func NewInventoryUsecase(inventoryRepo inventoryrepositories.IInventoryRepositories) IInventoryUsecase {
+	return &inventoryUsecase{
+		inventoryRepo: inventoryRepo,
+	}
+}
+
+func (u *inventoryUsecase) FindStock(productId string) (*inventory.Stock, error) {
+	return u.inventoryRepo.FindStock(productId)
+}
+
+func (u *inventoryUsecase) UpdateStock(req *inventory.Stock) (*inventory.Stock, error) {
+	if req.Stock < 0 {
+		return nil, fmt.Errorf("stock must not be negative")
+	}
+	if err := u.inventoryRepo.UpdateStock(req); err != nil {
+		return nil, err
+	}
+	return u.inventoryRepo.FindStock(req.ProductId)
+}
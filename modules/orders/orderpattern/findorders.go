@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Tanapoowapat/GunplaShop/modules/entities"
 	"github.com/Tanapoowapat/GunplaShop/modules/orders"
 	"github.com/jmoiron/sqlx"
 )
@@ -18,6 +19,7 @@ type IFindOrderBuilder interface {
 	buildWhereSearch()
 	buildWhereStatus()
 	buildWhereDate()
+	buildWhereCursor()
 	buildSort()
 	buildPaginate()
 	closeQuery()
@@ -27,23 +29,39 @@ type IFindOrderBuilder interface {
 	setValues(data []any)
 	setLastIndex(n int)
 	getDb() *sqlx.DB
+	isBackward() bool
 	reset()
 }
 
 type findOrderBuilder struct {
 	db        *sqlx.DB
 	req       *orders.OrderFilter
+	cursor    *entities.Cursor
 	query     string
 	values    []any
 	lastIndex int
 }
 
 func FindOrderBuilder(db *sqlx.DB, req *orders.OrderFilter) IFindOrderBuilder {
-	return &findOrderBuilder{
+	b := &findOrderBuilder{
 		db:     db,
 		req:    req,
 		values: make([]any, 0),
 	}
+	// The handler has already rejected an invalid cursor
+	if req.Cursor != "" {
+		b.cursor, _ = entities.DecodeCursor(req.Cursor)
+	}
+	return b
+}
+
+// sortTerms falls back to the id when order_by was not validated
+func (b *findOrderBuilder) sortTerms() []*entities.SortTerm {
+	terms, err := entities.ParseSort(b.req.SortReq, orders.SortColumns)
+	if err != nil {
+		terms, _ = entities.ParseSort(&entities.SortReq{OrderBy: "id", Sort: b.req.Sort}, orders.SortColumns)
+	}
+	return terms
 }
 
 type findOrderEngineer struct {
@@ -64,6 +82,8 @@ func (b *findOrderBuilder) initQuery() {
 			"o"."user_id",
 			"o"."transfer_slip",
 			"o"."status",
+			"o"."is_preorder",
+			"o"."deposit",
 			(
 				SELECT
 					array_to_json(array_agg("pt"))
@@ -71,19 +91,28 @@ func (b *findOrderBuilder) initQuery() {
 					SELECT
 						"spo"."id",
 						"spo"."qty",
-						"spo"."product"
+						"spo"."price",
+						"spo"."subtotal",
+						"spo"."discount",
+						"spo"."product",
+						"spo"."variant"
 					FROM "products_orders" "spo"
 					WHERE "spo"."order_id" = "o"."id"
 				) AS "pt"
 			) AS "products",
 			"o"."address",
 			"o"."contact",
+			"o"."shipping_address",
+			"o"."total_price",
+			"o"."shipping_fee",
+			"o"."discount",
+			"o"."discounts",
 			(
 				SELECT
-					SUM(COALESCE(("po"."product"->>'price')::FLOAT*("po"."qty")::FLOAT, 0))
-				FROM "products_orders" "po"
-				WHERE "po"."order_id" = "o"."id"
-			) AS "total_paid",
+					COALESCE(SUM("rf"."amount"), 0)
+				FROM "refunds" "rf"
+				WHERE "rf"."order_id" = "o"."id"
+			) AS "refunded_amount",
 			"o"."created_at",
 			"o"."updated_at"
 		FROM "orders" "o"
@@ -165,17 +194,40 @@ func (b *findOrderBuilder) buildWhereDate() {
 	}
 }
 
-func (b *findOrderBuilder) buildSort() {
-	b.values = append(b.values, b.req.OrderBy)
+// buildWhereCursor keeps the rows after the cursor row in the sort order, or before it when paging backward
+func (b *findOrderBuilder) buildWhereCursor() {
+	terms := b.sortTerms()
+	if b.cursor == nil || len(b.cursor.Values) != len(terms) {
+		return
+	}
 
-	// Fix
-	b.query += fmt.Sprintf(`
-		ORDER BY $%d %s`, b.lastIndex+1, b.req.Sort)
+	condition := entities.KeysetCondition(terms, orders.SortColumns["id"], b.cursor.Backward, b.lastIndex)
+	b.values = append(b.values, b.cursor.Values...)
+	b.values = append(b.values, b.cursor.Id)
+	b.query += `
+		AND ` + condition
 
 	b.lastIndex = len(b.values)
 }
 
+func (b *findOrderBuilder) buildSort() {
+	// A backward page is read in reverse then flipped back in FindOrder
+	b.query += `
+		` + entities.OrderByClause(b.sortTerms(), orders.SortColumns["id"].Expr, b.isBackward())
+}
+
 func (b *findOrderBuilder) buildPaginate() {
+	// One extra row tells whether there is another page
+	if b.req.IsCursor() {
+		b.values = append(b.values, b.req.Limit+1)
+
+		b.query += fmt.Sprintf(`
+		LIMIT $%d`, b.lastIndex+1)
+
+		b.lastIndex = len(b.values)
+		return
+	}
+
 	b.values = append(
 		b.values,
 		(b.req.Page-1)*b.req.Limit,
@@ -206,6 +258,8 @@ func (b *findOrderBuilder) setLastIndex(n int) { b.lastIndex = n }
 
 func (b *findOrderBuilder) getDb() *sqlx.DB { return b.db }
 
+func (b *findOrderBuilder) isBackward() bool { return b.cursor != nil && b.cursor.Backward }
+
 func (b *findOrderBuilder) reset() {
 	b.query = ""
 	b.values = make([]any, 0)
@@ -220,6 +274,7 @@ func (en *findOrderEngineer) FindOrder() []*orders.Order {
 	en.builder.buildWhereSearch()
 	en.builder.buildWhereStatus()
 	en.builder.buildWhereDate()
+	en.builder.buildWhereCursor()
 	en.builder.buildSort()
 	en.builder.buildPaginate()
 	en.builder.closeQuery()
@@ -236,6 +291,11 @@ func (en *findOrderEngineer) FindOrder() []*orders.Order {
 	if err := json.Unmarshal(raw, &ordersData); err != nil {
 		log.Printf("unmarshal orders failed: %v\n", err)
 	}
+	if en.builder.isBackward() {
+		for i, j := 0, len(ordersData)-1; i < j; i, j = i+1, j-1 {
+			ordersData[i], ordersData[j] = ordersData[j], ordersData[i]
+		}
+	}
 
 	en.builder.reset()
 	return ordersData
@@ -0,0 +1,75 @@
+package orderpattern
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/entities"
+	"github.com/Tanapoowapat/GunplaShop/modules/orders"
+)
+
+func TestFindOrderSort(t *testing.T) {
+	tests := []struct {
+		orderBy string
+		sort    string
+		want    string
+	}{
+		{"id", "DESC", `ORDER BY "o"."id" DESC`},
+		{"created_at", "ASC", `ORDER BY "o"."created_at" ASC, "o"."id" ASC`},
+		{"total_price", "DESC", `ORDER BY "o"."total_price" DESC, "o"."id" DESC`},
+		{"status", "ASC", `ORDER BY "o"."status"::TEXT ASC, "o"."id" ASC`},
+		{"status,-created_at", "ASC", `ORDER BY "o"."status"::TEXT ASC, "o"."created_at" DESC, "o"."id" ASC`},
+		{"unknown", "DESC", `ORDER BY "o"."id" DESC`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.orderBy+" "+tt.sort, func(t *testing.T) {
+			b := FindOrderBuilder(nil, &orders.OrderFilter{
+				PaginationReq: &entities.PaginationReq{Page: 1, Limit: 5},
+				SortReq:       &entities.SortReq{OrderBy: tt.orderBy, Sort: tt.sort},
+			})
+			b.buildSort()
+
+			if !strings.HasSuffix(b.getQuery(), tt.want) {
+				t.Errorf("buildSort() = %s, want %s", b.getQuery(), tt.want)
+			}
+		})
+	}
+}
+
+func TestFindOrderCursorBinding(t *testing.T) {
+	req := &orders.OrderFilter{
+		Search:        "Bangkok",
+		Status:        "paid",
+		PaginationReq: &entities.PaginationReq{Page: 1, Limit: 5},
+		SortReq:       &entities.SortReq{OrderBy: "-total_price", Sort: "ASC"},
+	}
+	req.Cursor = entities.EncodeCursor(&entities.Cursor{OrderBy: "-total_price", Sort: "ASC", Values: []any{1500.0}, Id: "O000010"})
+
+	// The same steps as FindOrder, without the database
+	b := FindOrderBuilder(nil, req)
+	b.initQuery()
+	b.buildWhereSearch()
+	b.buildWhereStatus()
+	b.buildWhereDate()
+	b.buildWhereCursor()
+	b.buildSort()
+	b.buildPaginate()
+	b.closeQuery()
+
+	want := []any{"%bangkok%", "%bangkok%", "%bangkok%", "paid", 1500.0, "O000010", 6}
+	if !reflect.DeepEqual(b.getValues(), want) {
+		t.Errorf("values = %v, want %v", b.getValues(), want)
+	}
+	for _, part := range []string{
+		`LOWER("o"."user_id") LIKE $1 OR`,
+		`AND "o"."status" = $4`,
+		`(("o"."total_price" < $5::FLOAT) OR ("o"."total_price" = $5::FLOAT AND "o"."id" < $6::VARCHAR))`,
+		`ORDER BY "o"."total_price" DESC, "o"."id" DESC`,
+		`LIMIT $7`,
+	} {
+		if !strings.Contains(b.getQuery(), part) {
+			t.Errorf("query = %s, want %s", b.getQuery(), part)
+		}
+	}
+}
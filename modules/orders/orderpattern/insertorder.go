@@ -5,7 +5,11 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/Tanapoowapat/GunplaShop/modules/inventory"
+	inventoryrepositories "github.com/Tanapoowapat/GunplaShop/modules/inventory/inventoryRepositories"
 	"github.com/Tanapoowapat/GunplaShop/modules/orders"
+	"github.com/Tanapoowapat/GunplaShop/modules/promotions"
+	promotionsrepositories "github.com/Tanapoowapat/GunplaShop/modules/promotions/promotionsRepositories"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -13,20 +17,28 @@ type IInsertOrderBuilder interface {
 	initTransaction() error
 	insertOrder() error
 	insertProductOrder() error
+	reserveStock() error
+	redeemPromotions() error
+	insertStatusHistory() error
+	clearCart() error
 	commit() error
 	getOrdersId() string
 }
 
 type insertOrdersBuilder struct {
-	db  *sqlx.DB
-	req *orders.Order
-	tx  *sqlx.Tx
+	db             *sqlx.DB
+	req            *orders.Order
+	tx             *sqlx.Tx
+	inventoryRepo  inventoryrepositories.IInventoryRepositories
+	promotionsRepo promotionsrepositories.IPromotionsRepositories
 }
 
-func NewInsertOrderBuilder(db *sqlx.DB, req *orders.Order) IInsertOrderBuilder {
+func NewInsertOrderBuilder(db *sqlx.DB, req *orders.Order, inventoryRepo inventoryrepositories.IInventoryRepositories, promotionsRepo promotionsrepositories.IPromotionsRepositories) IInsertOrderBuilder {
 	return &insertOrdersBuilder{
-		db:  db,
-		req: req,
+		db:             db,
+		req:            req,
+		inventoryRepo:  inventoryRepo,
+		promotionsRepo: promotionsRepo,
 	}
 }
 
@@ -50,10 +62,18 @@ func (b *insertOrdersBuilder) insertOrder() error {
 		"contact",
 		"address",
 		"transfer_slip",
-		"status"
+		"status",
+		"total_price",
+		"shipping_fee",
+		"shipping_address",
+		"discount",
+		"discounts",
+		"is_preorder",
+		"deposit",
+		"stock_reserved"
 	)
 	VALUES
-	($1, $2, $3, $4, $5)
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 		RETURNING "id";`
 
 	if err := b.tx.QueryRowxContext(ctx, query,
@@ -62,6 +82,14 @@ func (b *insertOrdersBuilder) insertOrder() error {
 		b.req.Address,
 		b.req.TransferSlip,
 		b.req.Status,
+		b.req.TotalPrice,
+		b.req.ShippingFee,
+		b.req.ShippingAddress,
+		b.req.Discount,
+		b.req.Discounts,
+		b.req.IsPreorder,
+		b.req.Deposit,
+		!b.req.IsPreorder,
 	).Scan(&b.req.Id); err != nil {
 		b.tx.Rollback()
 		return fmt.Errorf("insert order fail: %v", err)
@@ -80,7 +108,11 @@ func (b *insertOrdersBuilder) insertProductOrder() error {
 	INSERT INTO "products_orders" (
 		"order_id",
 		"qty",
-		"product"
+		"price",
+		"subtotal",
+		"discount",
+		"product",
+		"variant"
 	)
 	VALUES`
 
@@ -90,18 +122,22 @@ func (b *insertOrdersBuilder) insertProductOrder() error {
 		values = append(values,
 			b.req.Id,
 			b.req.Product[i].Qty,
+			b.req.Product[i].Price,
+			b.req.Product[i].Subtotal,
+			b.req.Product[i].Discount,
 			b.req.Product[i].Product,
+			b.req.Product[i].Variant,
 		)
 
 		if i != len(b.req.Product)-1 {
 			query += fmt.Sprintf(`
-		($%d, $%d, $%d),`, lastIndex+1, lastIndex+2, lastIndex+3)
+		($%d, $%d, $%d, $%d, $%d, $%d, $%d),`, lastIndex+1, lastIndex+2, lastIndex+3, lastIndex+4, lastIndex+5, lastIndex+6, lastIndex+7)
 		} else {
 			query += fmt.Sprintf(`
-		($%d, $%d, $%d);`, lastIndex+1, lastIndex+2, lastIndex+3)
+		($%d, $%d, $%d, $%d, $%d, $%d, $%d);`, lastIndex+1, lastIndex+2, lastIndex+3, lastIndex+4, lastIndex+5, lastIndex+6, lastIndex+7)
 		}
 
-		lastIndex += 3
+		lastIndex += 7
 
 	}
 
@@ -113,6 +149,86 @@ func (b *insertOrdersBuilder) insertProductOrder() error {
 	return nil
 }
 
+func (b *insertOrdersBuilder) reserveStock() error {
+	// A pre-order takes its stock once the kit has arrived
+	if b.req.IsPreorder {
+		return nil
+	}
+
+	stockReq := make([]*inventory.StockReq, 0)
+	for i := range b.req.Product {
+		item := &inventory.StockReq{
+			ProductId: b.req.Product[i].Product.Id,
+			Qty:       b.req.Product[i].Qty,
+		}
+		if b.req.Product[i].Variant != nil {
+			item.VariantId = b.req.Product[i].Variant.Id
+		}
+		stockReq = append(stockReq, item)
+	}
+
+	if err := b.inventoryRepo.ReserveStock(b.tx, stockReq); err != nil {
+		b.tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+func (b *insertOrdersBuilder) redeemPromotions() error {
+	for _, discount := range b.req.Discounts {
+		if err := b.promotionsRepo.RedeemPromotion(b.tx, &promotions.Usage{
+			PromotionId: discount.PromotionId,
+			OrderId:     b.req.Id,
+			UserId:      b.req.UserId,
+			Amount:      discount.Amount,
+		}); err != nil {
+			b.tx.Rollback()
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *insertOrdersBuilder) insertStatusHistory() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	query := `
+	INSERT INTO "order_status_history" (
+		"order_id",
+		"to_status",
+		"changed_by"
+	)
+	VALUES ($1, $2, $3);`
+
+	changedBy := b.req.CreatedBy
+	if changedBy == "" {
+		changedBy = b.req.UserId
+	}
+	if _, err := b.tx.ExecContext(ctx, query, b.req.Id, b.req.Status, changedBy); err != nil {
+		b.tx.Rollback()
+		return fmt.Errorf("insert order status history fail: %v", err)
+	}
+	return nil
+}
+
+// clearCart empties the cart a checkout came from, so a failure cannot leave
+// both the order and the full cart behind
+func (b *insertOrdersBuilder) clearCart() error {
+	if !b.req.FromCart {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	if _, err := b.tx.ExecContext(ctx, `DELETE FROM "carts" WHERE "user_id" = $1;`, b.req.UserId); err != nil {
+		b.tx.Rollback()
+		return fmt.Errorf("clear cart failed: %v", err)
+	}
+	return nil
+}
+
 func (b *insertOrdersBuilder) commit() error {
 	if err := b.tx.Commit(); err != nil {
 		return err
@@ -134,16 +250,28 @@ func NewInsertOrderEngineer(b IInsertOrderBuilder) *insertOrdersEngineer {
 
 func (en *insertOrdersEngineer) InsertOrders() (string, error) {
 	if err := en.builder.initTransaction(); err != nil {
-		return "", nil
+		return "", err
 	}
 	if err := en.builder.insertOrder(); err != nil {
-		return "", nil
+		return "", err
 	}
 	if err := en.builder.insertProductOrder(); err != nil {
-		return "", nil
+		return "", err
+	}
+	if err := en.builder.reserveStock(); err != nil {
+		return "", err
+	}
+	if err := en.builder.redeemPromotions(); err != nil {
+		return "", err
+	}
+	if err := en.builder.insertStatusHistory(); err != nil {
+		return "", err
+	}
+	if err := en.builder.clearCart(); err != nil {
+		return "", err
 	}
 	if err := en.builder.commit(); err != nil {
-		return "", nil
+		return "", err
 	}
 
 	return en.builder.getOrdersId(), nil
@@ -1,10 +1,134 @@
 package orders
 
 import (
+	"fmt"
+	"math"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/addresses"
 	"github.com/Tanapoowapat/GunplaShop/modules/entities"
+	"github.com/Tanapoowapat/GunplaShop/modules/file"
 	"github.com/Tanapoowapat/GunplaShop/modules/products"
+	"github.com/Tanapoowapat/GunplaShop/modules/promotions"
+)
+
+const (
+	StatusWaiting   = "waiting"
+	StatusPaid      = "paid"
+	StatusShipping  = "shipping"
+	StatusCompleted = "completed"
+	StatusCanceled  = "canceled"
+
+	// Pre-orders only
+	StatusDepositPaid     = "deposit_paid"
+	StatusAwaitingRelease = "awaiting_release"
+	StatusReadyToShip     = "ready_to_ship"
 )
 
+// StatusTransitions maps every status to the statuses an admin may move it to
+var StatusTransitions = map[string][]string{
+	StatusWaiting:   {StatusPaid, StatusCanceled},
+	StatusPaid:      {StatusShipping, StatusCanceled},
+	StatusShipping:  {StatusCompleted},
+	StatusCompleted: {},
+	StatusCanceled:  {},
+}
+
+// PreorderTransitions replaces StatusTransitions for pre-orders. Stock is
+// taken when the order becomes ready_to_ship, the balance left after the
+// deposit is paid by moving it on to paid.
+var PreorderTransitions = map[string][]string{
+	StatusWaiting:         {StatusDepositPaid, StatusCanceled},
+	StatusDepositPaid:     {StatusAwaitingRelease, StatusCanceled},
+	StatusAwaitingRelease: {StatusReadyToShip, StatusCanceled},
+	StatusReadyToShip:     {StatusPaid, StatusShipping, StatusCanceled},
+	StatusPaid:            {StatusShipping, StatusCanceled},
+	StatusShipping:        {StatusCompleted},
+	StatusCompleted:       {},
+	StatusCanceled:        {},
+}
+
+// CustomerTransitions are the only moves a customer may make on their own order
+var CustomerTransitions = map[string][]string{
+	StatusWaiting: {StatusCanceled},
+}
+
+func CanTransition(from, to string, isAdmin, isPreorder bool) bool {
+	transitions := CustomerTransitions
+	if isAdmin && isPreorder {
+		transitions = PreorderTransitions
+	} else if isAdmin {
+		transitions = StatusTransitions
+	}
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// PaidStatus is the status an order moves to once its amount due is paid
+func (obj *Order) PaidStatus() string {
+	if obj.IsPreorder && obj.Status == StatusWaiting {
+		return StatusDepositPaid
+	}
+	return StatusPaid
+}
+
+// Balance is what is left to pay after the deposit of a pre-order
+func (obj *Order) Balance() float64 {
+	if !obj.IsPreorder {
+		return 0
+	}
+	return math.Max(obj.TotalPrice-obj.Deposit, 0)
+}
+
+// AmountDue is what the customer has to pay now: the deposit of a new
+// pre-order, its balance once it is ready to ship, or the whole order
+func (obj *Order) AmountDue() float64 {
+	switch {
+	case obj.Status == StatusWaiting && obj.IsPreorder:
+		return obj.Deposit
+	case obj.Status == StatusWaiting:
+		return obj.TotalPrice
+	case obj.Status == StatusReadyToShip:
+		return obj.Balance()
+	default:
+		return 0
+	}
+}
+
+type StatusTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *StatusTransitionError) Error() string {
+	return fmt.Sprintf("cannot change order status from %s to %s", e.From, e.To)
+}
+
+// SortColumns whitelists what an order listing may be sorted by
+var SortColumns = map[string]entities.SortColumn{
+	"id":          {Expr: `"o"."id"`, Cast: "VARCHAR"},
+	"created_at":  {Expr: `"o"."created_at"`, Cast: "TIMESTAMP"},
+	"total_price": {Expr: `"o"."total_price"`, Cast: "FLOAT"},
+	"status":      {Expr: `"o"."status"::TEXT`, Cast: "TEXT"},
+}
+
+// SortValue is the value of a SortColumns key, it is stored in the cursors
+func (obj *Order) SortValue(key string) any {
+	switch key {
+	case "created_at":
+		return obj.CreatedAt
+	case "total_price":
+		return obj.TotalPrice
+	case "status":
+		return obj.Status
+	default:
+		return obj.Id
+	}
+}
+
 type OrderFilter struct {
 	Search    string `query:"search"`
 	Status    string `query:"status"`
@@ -15,27 +139,120 @@ type OrderFilter struct {
 }
 
 type Order struct {
-	Id           string          `db:"id" json:"id"`
-	UserId       string          `db:"user_id" json:"user_id"`
-	TransferSlip *TransferSlip   `db:"transfer_slip" json:"transfer_slip"`
-	Product      []*ProductOrder `json:"products"`
-	Address      string          `db:"address" json:"address"`
-	Contact      string          `db:"contact" json:"contact"`
-	Status       string          `db:"status" json:"status"`
-	TotalPrice   float64         `db:"total_price" json:"total_price"`
-	CreatedAt    string          `db:"created_at" json:"created_at"`
-	UpdatedAt    string          `db:"updated_at" json:"updated_at"`
+	Id              string                 `db:"id" json:"id"`
+	UserId          string                 `db:"user_id" json:"user_id"`
+	TransferSlip    *TransferSlip          `db:"transfer_slip" json:"transfer_slip"`
+	Product         []*ProductOrder        `json:"products"`
+	Address         string                 `db:"address" json:"address"`
+	Contact         string                 `db:"contact" json:"contact"`
+	AddressId       string                 `db:"-" json:"address_id,omitempty"`
+	ShippingAddress *addresses.Address     `db:"shipping_address" json:"shipping_address"`
+	Status          string                 `db:"status" json:"status"`
+	IsPreorder      bool                   `db:"is_preorder" json:"is_preorder"`
+	TotalPrice      float64                `db:"total_price" json:"total_price"`
+	Deposit         float64                `db:"deposit" json:"deposit"` // paid up front on a pre-order
+	ShippingFee     float64                `db:"shipping_fee" json:"shipping_fee"`
+	Discount        float64                `db:"discount" json:"discount"`
+	PromotionCode   string                 `db:"-" json:"promotion_code,omitempty"`
+	Discounts       []*promotions.Discount `db:"discounts" json:"discounts"`
+	Shipment        *Shipment              `db:"-" json:"shipment"`
+	Refunded        float64                `db:"refunded_amount" json:"refunded_amount"`
+	Slips           []*TransferSlip        `json:"slips,omitempty"`
+	Timeline        []*StatusHistory       `json:"timeline,omitempty"`
+	Returns         []*ReturnRequest       `json:"returns,omitempty"`
+	Refunds         []*Refund              `json:"refunds,omitempty"`
+	CreatedAt       string                 `db:"created_at" json:"created_at"`
+	UpdatedAt       string                 `db:"updated_at" json:"updated_at"`
+	CreatedBy       string                 `db:"-" json:"-"` // who placed the order, the customer when empty
+	FromCart        bool                   `db:"-" json:"-"` // empty the user's cart in the same transaction
+	ApproveSlip     bool                   `db:"-" json:"-"` // approve TransferSlip in the same transaction
+}
+
+// PreorderReq moves every pre-order of a product from the previous status to Status
+type PreorderReq struct {
+	ProductId string `json:"-"`
+	Status    string `json:"status" form:"status"` // awaiting_release | ready_to_ship
+	ChangedBy string `json:"-"`
+}
+
+// PreorderStatusFrom is the status a pre-order must be in to be moved to the key
+var PreorderStatusFrom = map[string]string{
+	StatusAwaitingRelease: StatusDepositPaid,
+	StatusReadyToShip:     StatusAwaitingRelease,
+}
+
+type PreorderRes struct {
+	Updated []string          `json:"updated"`
+	Failed  []*PreorderFailed `json:"failed"`
+}
+
+// PreorderFailed is a pre-order left behind, e.g. there was not enough stock for it
+type PreorderFailed struct {
+	OrderId string `json:"order_id"`
+	Error   string `json:"error"`
+}
+
+type StatusHistory struct {
+	Id         string `db:"id" json:"id"`
+	OrderId    string `db:"order_id" json:"-"`
+	FromStatus string `db:"from_status" json:"from_status"`
+	ToStatus   string `db:"to_status" json:"to_status"`
+	ChangedBy  string `db:"changed_by" json:"changed_by"`
+	CreatedAt  string `db:"created_at" json:"created_at"`
 }
 
 type TransferSlip struct {
-	Id        string `json:"id"`
-	FileName  string `json:"filename"`
-	Url       string `json:"url"`
-	CreatedAt string `json:"created_at"`
+	Id         string `db:"id" json:"id"`
+	OrderId    string `db:"order_id" json:"order_id,omitempty"`
+	FileName   string `db:"filename" json:"filename"`
+	Url        string `db:"url" json:"url"`
+	Status     string `db:"status" json:"status,omitempty"`
+	Reason     string `db:"reason" json:"reason,omitempty"`
+	ReviewedBy string `db:"reviewed_by" json:"reviewed_by,omitempty"`
+	ReviewedAt string `db:"reviewed_at" json:"reviewed_at,omitempty"`
+	CreatedAt  string `db:"created_at" json:"created_at"`
+}
+
+type Shipment struct {
+	Id             string `db:"id" json:"id"`
+	OrderId        string `db:"order_id" json:"-"`
+	Carrier        string `db:"carrier" json:"carrier"`
+	TrackingNumber string `db:"tracking_number" json:"tracking_number"`
+	CreatedBy      string `db:"created_by" json:"-"`
+	CreatedAt      string `db:"created_at" json:"created_at"`
 }
 
 type ProductOrder struct {
-	Id      string             `db:"id" json:"id"`
-	Qty     int                `db:"qty" json:"qty"`
-	Product *products.Products `db:"product" json:"product"`
+	Id       string             `db:"id" json:"id"`
+	Qty      int                `db:"qty" json:"qty"`
+	Price    float64            `db:"price" json:"price"`
+	Subtotal float64            `db:"subtotal" json:"subtotal"`
+	Discount float64            `db:"discount" json:"discount"`
+	Product  *products.Products `db:"product" json:"product"`
+	Variant  *products.Variant  `db:"variant" json:"variant"`
+}
+
+type ReturnRequest struct {
+	Id             string          `db:"id" json:"id"`
+	OrderId        string          `db:"order_id" json:"order_id"`
+	ProductOrderId string          `db:"product_order_id" json:"product_order_id"`
+	UserId         string          `db:"user_id" json:"user_id"`
+	Qty            int             `db:"qty" json:"qty"`
+	Reason         string          `db:"reason" json:"reason"`
+	Images         []*file.FileRes `db:"-" json:"images"`
+	Status         string          `db:"status" json:"status"`
+	RefundAmount   float64         `db:"refund_amount" json:"refund_amount"`
+	AdminNote      string          `db:"admin_note" json:"admin_note"`
+	ReviewedBy     string          `db:"reviewed_by" json:"reviewed_by"`
+	ReviewedAt     string          `db:"reviewed_at" json:"reviewed_at"`
+	CreatedAt      string          `db:"created_at" json:"created_at"`
+}
+
+type Refund struct {
+	Id        string  `db:"id" json:"id"`
+	OrderId   string  `db:"order_id" json:"order_id"`
+	ReturnId  string  `db:"return_id" json:"return_id"`
+	Amount    float64 `db:"amount" json:"amount"`
+	CreatedBy string  `db:"created_by" json:"created_by"`
+	CreatedAt string  `db:"created_at" json:"created_at"`
 }
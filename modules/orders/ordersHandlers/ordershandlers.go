@@ -1,15 +1,17 @@
 package ordershandlers
 
 import (
+	"errors"
 	"strings"
 	"time"
 
 	"github.com/Tanapoowapat/GunplaShop/config"
 	"github.com/Tanapoowapat/GunplaShop/modules/entities"
+	"github.com/Tanapoowapat/GunplaShop/modules/inventory"
 	"github.com/Tanapoowapat/GunplaShop/modules/orders"
 	ordersusecase "github.com/Tanapoowapat/GunplaShop/modules/orders/ordersUsecase"
+	"github.com/Tanapoowapat/GunplaShop/modules/promotions"
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 )
 
 type OrdersHandlersErr string
@@ -19,6 +21,7 @@ const (
 	FindOrdersErr     OrdersHandlersErr = "Orders-002"
 	InsertOrderErr    OrdersHandlersErr = "Orders-003"
 	UpdateOrderErr    OrdersHandlersErr = "Orders-004"
+	PreorderErr       OrdersHandlersErr = "Orders-005"
 )
 
 type IOrdersHandlers interface {
@@ -26,6 +29,7 @@ type IOrdersHandlers interface {
 	FindOrder(c *fiber.Ctx) error
 	InsertOrder(c *fiber.Ctx) error
 	UpdateOrder(c *fiber.Ctx) error
+	TransitionPreorders(c *fiber.Ctx) error
 }
 
 type ordersHandlers struct {
@@ -50,6 +54,13 @@ func (handler *ordersHandlers) FindOnceOrders(c *fiber.Ctx) error {
 			err.Error()).Res()
 	}
 
+	// Customers can only see their own orders
+	if c.Locals("userRoleID").(int) != 2 && order.UserId != c.Locals("userId").(string) {
+		return entities.NewResponse(c).Error(fiber.ErrNotFound.Code,
+			string(FindOnceOrdersErr),
+			"order not found").Res()
+	}
+
 	return entities.NewResponse(c).Sucess(fiber.StatusOK, order).Res()
 }
 
@@ -76,12 +87,8 @@ func (handler *ordersHandlers) FindOrder(c *fiber.Ctx) error {
 	}
 
 	// Sort
-	orderByMap := map[string]string{
-		"id":         `"o"."id"`,
-		"created_at": `"o"."created_at"`,
-	}
-	if orderByMap[req.OrderBy] == "" {
-		req.OrderBy = orderByMap["id"]
+	if req.OrderBy == "" {
+		req.OrderBy = "id"
 	}
 
 	req.Sort = strings.ToUpper(req.Sort)
@@ -92,6 +99,13 @@ func (handler *ordersHandlers) FindOrder(c *fiber.Ctx) error {
 	if sortMap[req.Sort] == "" {
 		req.Sort = sortMap["DESC"]
 	}
+	if _, err := entities.ParseSort(req.SortReq, orders.SortColumns); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(FindOrdersErr),
+			err.Error(),
+		).Res()
+	}
 
 	// Date	YYYY-MM-DD
 	if req.StartDate != "" {
@@ -119,6 +133,14 @@ func (handler *ordersHandlers) FindOrder(c *fiber.Ctx) error {
 		req.EndDate = end.Format("2006-01-02")
 	}
 
+	if err := req.CheckCursor(req.SortReq); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(FindOrdersErr),
+			err.Error(),
+		).Res()
+	}
+
 	return entities.NewResponse(c).Sucess(
 		fiber.StatusOK,
 		handler.ordersUsecase.FindOrders(req),
@@ -126,7 +148,7 @@ func (handler *ordersHandlers) FindOrder(c *fiber.Ctx) error {
 }
 
 func (h *ordersHandlers) InsertOrder(c *fiber.Ctx) error {
-	userId := c.Locals("usersId").(string)
+	userId := c.Locals("userId").(string)
 
 	req := &orders.Order{
 		Product: make([]*orders.ProductOrder, 0),
@@ -150,18 +172,35 @@ func (h *ordersHandlers) InsertOrder(c *fiber.Ctx) error {
 		).Res()
 	}
 
-	if c.Locals("userRoleId").(int) != 2 {
+	if c.Locals("userRoleID").(int) != 2 {
 		req.UserId = userId
 	}
+	req.CreatedBy = userId
 
-	req.Status = "waiting"
+	req.Status = orders.StatusWaiting
 	req.TotalPrice = 0
 
 	order, err := h.ordersUsecase.InsertOrder(req)
 	if err != nil {
+		var stockErr *inventory.OutOfStockError
+		if errors.As(err, &stockErr) {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(InsertOrderErr),
+				stockErr.Error(),
+			).Res()
+		}
+		var promotionErr *promotions.PromotionError
+		if errors.As(err, &promotionErr) {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(InsertOrderErr),
+				promotionErr.Error(),
+			).Res()
+		}
 		return entities.NewResponse(c).Error(
 			fiber.ErrInternalServerError.Code,
-			string(FindOrdersErr),
+			string(InsertOrderErr),
 			err.Error(),
 		).Res()
 	}
@@ -177,43 +216,35 @@ func (h *ordersHandlers) UpdateOrder(c *fiber.Ctx) error {
 	}
 
 	req.Id = orderId
-	statusMap := map[string]string{
-		"waiting":   "waiting",
-		"shipping":  "shipping",
-		"completed": "completed",
-		"canceled":  "canceled",
-	}
+	req.Status = strings.ToLower(strings.Trim(req.Status, " "))
 
 	//Check User in Local Cache
-	if c.Locals("userRoleId").(int) == 2 {
-		req.Status = statusMap[strings.ToLower(req.Status)]
-	} else if strings.ToLower(req.Status) == statusMap["canceled"] {
-		req.Status = statusMap["canceled"]
-	}
+	userId := c.Locals("userId").(string)
+	isAdmin := c.Locals("userRoleID").(int) == 2
 
-	if req.TransferSlip != nil {
-		if req.TransferSlip.Id == "" {
-			req.TransferSlip.Id = uuid.NewString()
-		}
-		if req.TransferSlip.CreatedAt == "" {
-			loc, err := time.LoadLocation("Asia/Bangkok")
-			if err != nil {
-				return entities.NewResponse(c).Error(
-					fiber.ErrInternalServerError.Code,
-					string(UpdateOrderErr),
-					err.Error(),
-				).Res()
-			}
-			now := time.Now().In(loc)
-			// YYYY-MM-DD HH:MM:SS
-			// 2006-01-02 15:04:05
-			req.TransferSlip.CreatedAt = now.Format("2006-01-02 15:04:05")
+	// Slips are only attached through the slip review workflow
+	req.TransferSlip = nil
 
+	if req.Status == orders.StatusShipping {
+		if req.Shipment == nil || strings.Trim(req.Shipment.Carrier, " ") == "" || strings.Trim(req.Shipment.TrackingNumber, " ") == "" {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(UpdateOrderErr),
+				"shipment carrier and tracking_number are required",
+			).Res()
 		}
 	}
 
-	order, err := h.ordersUsecase.UpdateOrder(req)
+	order, err := h.ordersUsecase.UpdateOrder(req, userId, isAdmin)
 	if err != nil {
+		var transitionErr *orders.StatusTransitionError
+		if errors.As(err, &transitionErr) {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(UpdateOrderErr),
+				transitionErr.Error(),
+			).Res()
+		}
 		return entities.NewResponse(c).Error(
 			fiber.ErrInternalServerError.Code,
 			string(UpdateOrderErr),
@@ -223,3 +254,32 @@ func (h *ordersHandlers) UpdateOrder(c *fiber.Ctx) error {
 
 	return entities.NewResponse(c).Sucess(fiber.StatusOK, order).Res()
 }
+
+func (h *ordersHandlers) TransitionPreorders(c *fiber.Ctx) error {
+	req := new(orders.PreorderReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(fiber.ErrBadRequest.Code, string(PreorderErr), err.Error()).Res()
+	}
+	req.ProductId = strings.Trim(c.Params("product_id"), " ")
+	req.Status = strings.ToLower(strings.Trim(req.Status, " "))
+	req.ChangedBy = c.Locals("userId").(string)
+
+	res, err := h.ordersUsecase.TransitionPreorders(req)
+	if err != nil {
+		var transitionErr *orders.StatusTransitionError
+		if errors.As(err, &transitionErr) {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(PreorderErr),
+				transitionErr.Error(),
+			).Res()
+		}
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(PreorderErr),
+			err.Error(),
+		).Res()
+	}
+
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, res).Res()
+}
@@ -2,12 +2,18 @@ package ordersrepositories
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/Tanapoowapat/GunplaShop/modules/inventory"
+	inventoryrepositories "github.com/Tanapoowapat/GunplaShop/modules/inventory/inventoryRepositories"
 	"github.com/Tanapoowapat/GunplaShop/modules/orders"
 	"github.com/Tanapoowapat/GunplaShop/modules/orders/orderpattern"
+	promotionsrepositories "github.com/Tanapoowapat/GunplaShop/modules/promotions/promotionsRepositories"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -15,16 +21,21 @@ type IOrdersRepositories interface {
 	FindOnceOrders(orderId string) (*orders.Order, error)
 	FindOrders(req *orders.OrderFilter) ([]*orders.Order, int)
 	InsertOrder(req *orders.Order) (string, error)
-	UpdateOrder(req *orders.Order) error
+	UpdateOrder(req *orders.Order, history *orders.StatusHistory) error
+	FindPreorderIds(productId, status string) ([]string, error)
 }
 
 type ordersRepositories struct {
-	db *sqlx.DB
+	db             *sqlx.DB
+	inventoryRepo  inventoryrepositories.IInventoryRepositories
+	promotionsRepo promotionsrepositories.IPromotionsRepositories
 }
 
-func NewOrdersRepositories(db *sqlx.DB) IOrdersRepositories {
+func NewOrdersRepositories(db *sqlx.DB, inventoryRepo inventoryrepositories.IInventoryRepositories, promotionsRepo promotionsrepositories.IPromotionsRepositories) IOrdersRepositories {
 	return &ordersRepositories{
-		db: db,
+		db:             db,
+		inventoryRepo:  inventoryRepo,
+		promotionsRepo: promotionsRepo,
 	}
 }
 
@@ -38,6 +49,8 @@ func (repo *ordersRepositories) FindOnceOrders(orderId string) (*orders.Order, e
 			"o"."user_id",
 			"o"."transfer_slip",
 			"o"."status",
+			"o"."is_preorder",
+			"o"."deposit",
 			(
 				SELECT
 					array_to_json(array_agg("pt"))
@@ -45,19 +58,110 @@ func (repo *ordersRepositories) FindOnceOrders(orderId string) (*orders.Order, e
 					SELECT
 						"spo"."id",
 						"spo"."qty",
-						"spo"."product"
+						"spo"."price",
+						"spo"."subtotal",
+						"spo"."discount",
+						"spo"."product",
+						"spo"."variant"
 					FROM "products_orders" "spo"
 					WHERE "spo"."order_id" = "o"."id"
 				) AS "pt"
 			) AS "products",
 			"o"."address",
 			"o"."contact",
+			"o"."shipping_address",
+			"o"."total_price",
+			"o"."shipping_fee",
+			"o"."discount",
+			"o"."discounts",
 			(
 				SELECT
-					SUM(COALESCE(("po"."product"->>'price')::FLOAT*("po"."qty")::FLOAT, 0))
-				FROM "products_orders" "po"
-				WHERE "po"."order_id" = "o"."id"
-			) AS "total_paid",
+					COALESCE(SUM("rf"."amount"), 0)
+				FROM "refunds" "rf"
+				WHERE "rf"."order_id" = "o"."id"
+			) AS "refunded_amount",
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg("ht")), '[]'::json)
+				FROM (
+					SELECT
+						"h"."id",
+						"h"."from_status",
+						"h"."to_status",
+						"h"."changed_by",
+						"h"."created_at"
+					FROM "order_status_history" "h"
+					WHERE "h"."order_id" = "o"."id"
+					ORDER BY "h"."created_at" ASC
+				) AS "ht"
+			) AS "timeline",
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg("st")), '[]'::json)
+				FROM (
+					SELECT
+						"s"."id",
+						"s"."filename",
+						"s"."url",
+						"s"."status",
+						"s"."reason",
+						"s"."reviewed_by",
+						"s"."reviewed_at",
+						"s"."created_at"
+					FROM "transfer_slips" "s"
+					WHERE "s"."order_id" = "o"."id"
+					ORDER BY "s"."created_at" ASC
+				) AS "st"
+			) AS "slips",
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg("rt")), '[]'::json)
+				FROM (
+					SELECT
+						"r"."id",
+						"r"."product_order_id",
+						"r"."qty",
+						"r"."reason",
+						"r"."images",
+						"r"."status",
+						"r"."refund_amount",
+						"r"."admin_note",
+						"r"."reviewed_by",
+						"r"."reviewed_at",
+						"r"."created_at"
+					FROM "return_requests" "r"
+					WHERE "r"."order_id" = "o"."id"
+					ORDER BY "r"."created_at" ASC
+				) AS "rt"
+			) AS "returns",
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg("rft")), '[]'::json)
+				FROM (
+					SELECT
+						"rf"."id",
+						"rf"."return_id",
+						"rf"."amount",
+						"rf"."created_by",
+						"rf"."created_at"
+					FROM "refunds" "rf"
+					WHERE "rf"."order_id" = "o"."id"
+					ORDER BY "rf"."created_at" ASC
+				) AS "rft"
+			) AS "refunds",
+			(
+				SELECT
+					to_jsonb("sht")
+				FROM (
+					SELECT
+						"sh"."id",
+						"sh"."carrier",
+						"sh"."tracking_number",
+						"sh"."created_at"
+					FROM "shipments" "sh"
+					WHERE "sh"."order_id" = "o"."id"
+				) AS "sht"
+			) AS "shipment",
 			"o"."created_at",
 			"o"."updated_at"
 		FROM "orders" "o"
@@ -84,11 +188,16 @@ func (repo *ordersRepositories) FindOnceOrders(orderId string) (*orders.Order, e
 func (repo *ordersRepositories) FindOrders(req *orders.OrderFilter) ([]*orders.Order, int) {
 	builder := orderpattern.FindOrderBuilder(repo.db, req)
 	engineer := orderpattern.FindOrderEngineer(builder)
+
+	// Cursor pages skip the total count
+	if req.IsCursor() {
+		return engineer.FindOrder(), 0
+	}
 	return engineer.FindOrder(), engineer.CountOrder()
 }
 
 func (repo *ordersRepositories) InsertOrder(req *orders.Order) (string, error) {
-	builder := orderpattern.NewInsertOrderBuilder(repo.db, req)
+	builder := orderpattern.NewInsertOrderBuilder(repo.db, req, repo.inventoryRepo, repo.promotionsRepo)
 	orderId, err := orderpattern.NewInsertOrderEngineer(builder).InsertOrders()
 
 	if err != nil {
@@ -97,41 +206,195 @@ func (repo *ordersRepositories) InsertOrder(req *orders.Order) (string, error) {
 	return orderId, nil
 }
 
-func (repo *ordersRepositories) UpdateOrder(req *orders.Order) error {
+// UpdateOrder applies req and, when history is given, records the status change.
+// It fails if the status was changed by someone else since history.FromStatus was read.
+func (repo *ordersRepositories) UpdateOrder(req *orders.Order, history *orders.StatusHistory) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the order so a cancel can only release the stock once
+	var oldStatus string
+	var stockReserved bool
+	if err := tx.QueryRowxContext(ctx, `SELECT "status", "stock_reserved" FROM "orders" WHERE "id" = $1 FOR UPDATE;`, req.Id).Scan(&oldStatus, &stockReserved); err != nil {
+		return fmt.Errorf("get order failed: %v", err)
+	}
+
+	if history != nil && history.FromStatus != oldStatus {
+		return fmt.Errorf("order status has been changed to %s", oldStatus)
+	}
+
+	if req.Status == orders.StatusCanceled && oldStatus != orders.StatusCanceled {
+		if stockReserved {
+			if err := repo.releaseStock(ctx, tx, req.Id); err != nil {
+				return err
+			}
+		}
+		if err := repo.promotionsRepo.ReleasePromotion(tx, req.Id); err != nil {
+			return err
+		}
+	}
+
+	// A pre-order takes its stock when the kit has arrived
+	if req.Status == orders.StatusReadyToShip && !stockReserved {
+		if err := repo.reserveStock(ctx, tx, req.Id); err != nil {
+			return err
+		}
+	}
+
+	// The slip is approved with the status change, so neither happens without the other
+	if req.ApproveSlip && req.TransferSlip != nil {
+		query := `
+	UPDATE "transfer_slips" SET
+		"status" = 'approved',
+		"reason" = $1,
+		"reviewed_by" = $2,
+		"reviewed_at" = now()
+	WHERE "id" = $3 AND "order_id" = $4 AND "status" = 'pending'
+		RETURNING "status", "reviewed_at"::TEXT;`
 
-	query := `UPDATE "orders" SET`
+		slip := req.TransferSlip
+		if err := tx.QueryRowxContext(ctx, query, slip.Reason, slip.ReviewedBy, slip.Id, req.Id).Scan(&slip.Status, &slip.ReviewedAt); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("slip has already been reviewed")
+			}
+			return fmt.Errorf("approve slip failed: %v", err)
+		}
+	}
 
-	queryWhereStack := make([]string, 0)
+	queryFields := make([]string, 0)
 	values := make([]any, 0)
-	lastIndex := 1
 
 	if req.Status != "" {
 		values = append(values, req.Status)
-		queryWhereStack = append(queryWhereStack, fmt.Sprintf(`"status" = $%d`, lastIndex))
-		lastIndex++
+		queryFields = append(queryFields, fmt.Sprintf(`
+		"status" = $%d`, len(values)))
 	}
 
 	if req.TransferSlip != nil {
 		values = append(values, req.TransferSlip)
-		queryWhereStack = append(queryWhereStack, fmt.Sprintf(`"transfer_slip" = $%d`, lastIndex))
-		lastIndex++
+		queryFields = append(queryFields, fmt.Sprintf(`
+		"transfer_slip" = $%d`, len(values)))
+	}
+
+	if len(queryFields) != 0 {
+		values = append(values, req.Id)
+		query := fmt.Sprintf(`
+	UPDATE "orders" SET%s
+	WHERE "id" = $%d;`, strings.Join(queryFields, ","), len(values))
+
+		if _, err := tx.ExecContext(ctx, query, values...); err != nil {
+			return fmt.Errorf("update order fail: %v", err)
+		}
+	}
+
+	if req.Shipment != nil {
+		query := `
+	INSERT INTO "shipments" (
+		"order_id",
+		"carrier",
+		"tracking_number",
+		"created_by"
+	)
+	VALUES ($1, $2, $3, NULLIF($4, ''));`
+
+		if _, err := tx.ExecContext(ctx, query, req.Id, req.Shipment.Carrier, req.Shipment.TrackingNumber, req.Shipment.CreatedBy); err != nil {
+			return fmt.Errorf("insert shipment fail: %v", err)
+		}
 	}
 
-	values = append(values, req.Id)
-	queryClose := fmt.Sprintf(`WHERE "id" = $%d`, lastIndex)
+	if history != nil {
+		query := `
+	INSERT INTO "order_status_history" (
+		"order_id",
+		"from_status",
+		"to_status",
+		"changed_by"
+	)
+	VALUES ($1, $2, $3, NULLIF($4, ''));`
 
-	for i := range queryWhereStack {
-		if i != len(queryWhereStack)-1 {
-			query += strings.Replace(queryWhereStack[i], "?", ",", 1)
-		} else {
-			query += strings.Replace(queryWhereStack[i], "?", ",", 1)
+		if _, err := tx.ExecContext(ctx, query, req.Id, history.FromStatus, history.ToStatus, history.ChangedBy); err != nil {
+			return fmt.Errorf("insert order status history fail: %v", err)
 		}
 	}
 
-	query += queryClose
-	if _, err := repo.db.ExecContext(context.Background(), query, values...); err != nil {
-		return fmt.Errorf("update order fail: %v", err)
+	return tx.Commit()
+}
+
+func (repo *ordersRepositories) releaseStock(ctx context.Context, tx *sqlx.Tx, orderId string) error {
+	stockReq, err := repo.findOrderStock(ctx, tx, orderId)
+	if err != nil {
+		return err
+	}
+	return repo.inventoryRepo.ReleaseStock(tx, stockReq)
+}
+
+func (repo *ordersRepositories) reserveStock(ctx context.Context, tx *sqlx.Tx, orderId string) error {
+	stockReq, err := repo.findOrderStock(ctx, tx, orderId)
+	if err != nil {
+		return err
+	}
+	if err := repo.inventoryRepo.ReserveStock(tx, stockReq); err != nil {
+		return err
 	}
 
+	if _, err := tx.ExecContext(ctx, `UPDATE "orders" SET "stock_reserved" = TRUE WHERE "id" = $1;`, orderId); err != nil {
+		return fmt.Errorf("update order stock failed: %v", err)
+	}
 	return nil
 }
+
+// findOrderStock is the stock held by the lines of an order
+func (repo *ordersRepositories) findOrderStock(ctx context.Context, tx *sqlx.Tx, orderId string) ([]*inventory.StockReq, error) {
+	query := `
+	SELECT
+		"po"."product"->>'id' AS "product_id",
+		COALESCE("po"."variant"->>'id', '') AS "variant_id",
+		"po"."qty"
+	FROM "products_orders" "po"
+	WHERE "po"."order_id" = $1;`
+
+	rows, err := tx.QueryxContext(ctx, query, orderId)
+	if err != nil {
+		return nil, fmt.Errorf("get products order failed: %v", err)
+	}
+	defer rows.Close()
+
+	stockReq := make([]*inventory.StockReq, 0)
+	for rows.Next() {
+		item := new(inventory.StockReq)
+		if err := rows.Scan(&item.ProductId, &item.VariantId, &item.Qty); err != nil {
+			return nil, fmt.Errorf("scan products order failed: %v", err)
+		}
+		stockReq = append(stockReq, item)
+	}
+	return stockReq, nil
+}
+
+// FindPreorderIds lists the pre-orders of a product in a status, oldest first
+func (repo *ordersRepositories) FindPreorderIds(productId, status string) ([]string, error) {
+	query := `
+	SELECT
+		"o"."id"
+	FROM "orders" "o"
+	WHERE "o"."is_preorder" = TRUE
+		AND "o"."status" = $2
+		AND EXISTS (
+			SELECT 1
+			FROM "products_orders" "po"
+			WHERE "po"."order_id" = "o"."id"
+				AND "po"."product"->>'id' = $1
+		)
+	ORDER BY "o"."created_at" ASC;`
+
+	orderIds := make([]string, 0)
+	if err := repo.db.Select(&orderIds, query, productId, status); err != nil {
+		return nil, fmt.Errorf("get pre-orders failed: %v", err)
+	}
+	return orderIds, nil
+}
@@ -3,29 +3,44 @@ package ordersusecase
 import (
 	"fmt"
 	"math"
+	"strings"
+	"time"
 
+	"github.com/Tanapoowapat/GunplaShop/modules/addresses"
+	addressesrepositories "github.com/Tanapoowapat/GunplaShop/modules/addresses/addressesRepositories"
 	"github.com/Tanapoowapat/GunplaShop/modules/entities"
 	"github.com/Tanapoowapat/GunplaShop/modules/orders"
 	ordersrepositories "github.com/Tanapoowapat/GunplaShop/modules/orders/ordersRepositories"
 	productsrepositories "github.com/Tanapoowapat/GunplaShop/modules/products/productsRepositories"
+	"github.com/Tanapoowapat/GunplaShop/modules/promotions"
+	promotionsrepositories "github.com/Tanapoowapat/GunplaShop/modules/promotions/promotionsRepositories"
+	"github.com/Tanapoowapat/GunplaShop/modules/shipping"
+	shippingrepositories "github.com/Tanapoowapat/GunplaShop/modules/shipping/shippingRepositories"
 )
 
 type IOrdersUsecase interface {
 	FindOnceOrders(orderId string) (*orders.Order, error)
 	FindOrders(req *orders.OrderFilter) *entities.PaginateRes
 	InsertOrder(req *orders.Order) (*orders.Order, error)
-	UpdateOrder(req *orders.Order) (*orders.Order, error)
+	UpdateOrder(req *orders.Order, userId string, isAdmin bool) (*orders.Order, error)
+	TransitionPreorders(req *orders.PreorderReq) (*orders.PreorderRes, error)
 }
 
 type ordersUsecase struct {
-	ordersRepo   ordersrepositories.IOrdersRepositories
-	productsRepo productsrepositories.IProductRepositorise
+	ordersRepo     ordersrepositories.IOrdersRepositories
+	productsRepo   productsrepositories.IProductRepositorise
+	addressesRepo  addressesrepositories.IAddressesRepositories
+	shippingRepo   shippingrepositories.IShippingRepositories
+	promotionsRepo promotionsrepositories.IPromotionsRepositories
 }
 
-func NewOrdersUsecase(ordersRepo ordersrepositories.IOrdersRepositories, productsRepo productsrepositories.IProductRepositorise) IOrdersUsecase {
+func NewOrdersUsecase(ordersRepo ordersrepositories.IOrdersRepositories, productsRepo productsrepositories.IProductRepositorise, addressesRepo addressesrepositories.IAddressesRepositories, shippingRepo shippingrepositories.IShippingRepositories, promotionsRepo promotionsrepositories.IPromotionsRepositories) IOrdersUsecase {
 	return &ordersUsecase{
-		ordersRepo:   ordersRepo,
-		productsRepo: productsRepo,
+		ordersRepo:     ordersRepo,
+		productsRepo:   productsRepo,
+		addressesRepo:  addressesRepo,
+		shippingRepo:   shippingRepo,
+		promotionsRepo: promotionsRepo,
 	}
 }
 
@@ -35,6 +50,22 @@ func (usecase *ordersUsecase) FindOnceOrders(orderId string) (*orders.Order, err
 
 func (usecase *ordersUsecase) FindOrders(req *orders.OrderFilter) *entities.PaginateRes {
 	order, count := usecase.ordersRepo.FindOrders(req)
+
+	if req.IsCursor() {
+		res := &entities.PaginateRes{
+			Limit: req.Limit,
+		}
+		terms, _ := entities.ParseSort(req.SortReq, orders.SortColumns)
+		res.Data, res.NextCursor, res.PrevCursor = entities.CursorPage(req.PaginationReq, req.SortReq, order, func(o *orders.Order) ([]any, string) {
+			values := make([]any, 0, len(terms))
+			for _, term := range terms {
+				values = append(values, o.SortValue(term.Key))
+			}
+			return values, o.Id
+		})
+		return res
+	}
+
 	return &entities.PaginateRes{
 		Data:       order,
 		Page:       req.Page,
@@ -45,21 +76,94 @@ func (usecase *ordersUsecase) FindOrders(req *orders.OrderFilter) *entities.Pagi
 }
 
 func (usecase *ordersUsecase) InsertOrder(req *orders.Order) (*orders.Order, error) {
-	//check if product exits
+	// Prices always come from the catalogue, never from the request
+	req.TotalPrice = 0
+	req.IsPreorder = false
+	req.Deposit = 0
+	weight := 0
 	for i := range req.Product {
 		if req.Product[i].Product == nil {
 			return nil, fmt.Errorf("product is empty")
 		}
+		if req.Product[i].Qty <= 0 {
+			return nil, fmt.Errorf("qty of product %s must be greater than 0", req.Product[i].Product.Id)
+		}
 
 		//Find Product
 		product, err := usecase.productsRepo.FindOneProducts(req.Product[i].Product.Id)
 		if err != nil {
 			return nil, err
 		}
+		if err := product.Purchasable(); err != nil {
+			return nil, err
+		}
+
+		//Set price, a chosen variant overrides the product's one
+		price := product.Price
+		if variant := req.Product[i].Variant; variant != nil && variant.Id != "" {
+			variant, err = usecase.productsRepo.FindOneVariant(variant.Id)
+			if err != nil {
+				return nil, err
+			}
+			if variant.ProductId != product.Id {
+				return nil, fmt.Errorf("variant %s does not belong to product %s", variant.Id, product.Id)
+			}
+			variant.Images = nil
+			req.Product[i].Variant = variant
+			price = variant.Price
+		} else {
+			req.Product[i].Variant = nil
+		}
+		// A pre-order holds a single kit so it can be released when that kit arrives
+		if i > 0 && (product.Preorder || req.IsPreorder) && product.Id != req.Product[0].Product.Id {
+			return nil, fmt.Errorf("pre-order product must be ordered on its own")
+		}
+		if product.Preorder {
+			req.IsPreorder = true
+			req.Deposit += product.Deposit * float64(req.Product[i].Qty)
+		}
 
-		//Set price
-		req.TotalPrice += req.Product[i].Product.Price * float64(req.Product[i].Qty)
 		req.Product[i].Product = product
+		req.Product[i].Price = price
+		req.Product[i].Subtotal = price * float64(req.Product[i].Qty)
+		req.TotalPrice += req.Product[i].Subtotal
+		weight += product.Weight * req.Product[i].Qty
+	}
+
+	if err := usecase.applyPromotion(req); err != nil {
+		return nil, err
+	}
+
+	// Ship to the chosen address, or the user's default one.
+	// The order keeps its own copy so later edits to the address book do not change it.
+	var address *addresses.Address
+	var err error
+	if req.AddressId != "" {
+		address, err = usecase.addressesRepo.FindOneAddress(req.UserId, req.AddressId)
+	} else {
+		address, err = usecase.addressesRepo.FindDefaultAddress(req.UserId)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("shipping address is required: %v", err)
+	}
+	req.ShippingAddress = address
+	req.Address = address.String()
+	req.Contact = fmt.Sprintf("%s %s", address.Recipient, address.Phone)
+
+	rates, err := usecase.shippingRepo.FindRates(true)
+	if err != nil {
+		return nil, err
+	}
+	rate := shipping.SelectRate(rates, address.Province, weight)
+	if rate == nil {
+		return nil, fmt.Errorf("no shipping rate available for %s", address.Province)
+	}
+	req.ShippingFee = rate.Fee(req.TotalPrice)
+	req.TotalPrice += req.ShippingFee
+
+	// Without a deposit the pre-order is paid in full up front
+	if req.IsPreorder && (req.Deposit <= 0 || req.Deposit > req.TotalPrice) {
+		req.Deposit = req.TotalPrice
 	}
 
 	orderId, err := usecase.ordersRepo.InsertOrder(req)
@@ -76,16 +180,137 @@ func (usecase *ordersUsecase) InsertOrder(req *orders.Order) (*orders.Order, err
 
 }
 
-func (u *ordersUsecase) UpdateOrder(req *orders.Order) (*orders.Order, error) {
+// applyPromotion validates req.PromotionCode against the priced lines and
+// subtracts the discount from the order and each eligible line
+func (usecase *ordersUsecase) applyPromotion(req *orders.Order) error {
+	req.Discount = 0
+	req.Discounts = make([]*promotions.Discount, 0)
+	for i := range req.Product {
+		req.Product[i].Discount = 0
+	}
 
-	if err := u.ordersRepo.UpdateOrder(req); err != nil {
-		return nil, err
+	code := strings.Trim(req.PromotionCode, " ")
+	if code == "" {
+		return nil
+	}
+
+	promotion, err := usecase.promotionsRepo.FindPromotionByCode(code)
+	if err != nil {
+		return err
+	}
+
+	items := make([]*promotions.Item, 0, len(req.Product))
+	for _, line := range req.Product {
+		item := &promotions.Item{
+			ProductId:   line.Product.Id,
+			CategoryIds: line.Product.CategoryIds(),
+			Subtotal:    line.Subtotal,
+		}
+		items = append(items, item)
+	}
+
+	discount, err := promotion.Apply(items, time.Now())
+	if err != nil {
+		return err
 	}
+	for i := range req.Product {
+		req.Product[i].Discount = items[i].Discount
+	}
+	req.Discount = discount.Amount
+	req.Discounts = append(req.Discounts, discount)
+	req.TotalPrice -= discount.Amount
+	return nil
+}
 
+func (u *ordersUsecase) UpdateOrder(req *orders.Order, userId string, isAdmin bool) (*orders.Order, error) {
 	order, err := u.ordersRepo.FindOnceOrders(req.Id)
 	if err != nil {
 		return nil, err
 	}
+	if !isAdmin && order.UserId != userId {
+		return nil, fmt.Errorf("order not found")
+	}
+
+	var history *orders.StatusHistory
+	if req.Status == "" || req.Status == order.Status {
+		req.Status = ""
+	} else {
+		if !orders.CanTransition(order.Status, req.Status, isAdmin, order.IsPreorder) {
+			return nil, &orders.StatusTransitionError{
+				From: order.Status,
+				To:   req.Status,
+			}
+		}
+		if order.Status == orders.StatusReadyToShip && req.Status == orders.StatusShipping && order.Balance() > 0 {
+			return nil, fmt.Errorf("balance of %.2f must be paid before shipping", order.Balance())
+		}
+		history = &orders.StatusHistory{
+			OrderId:    req.Id,
+			FromStatus: order.Status,
+			ToStatus:   req.Status,
+			ChangedBy:  userId,
+		}
+	}
+
+	// A shipment is recorded only when the order is handed to the carrier
+	if history == nil || req.Status != orders.StatusShipping {
+		req.Shipment = nil
+	} else {
+		if req.Shipment == nil || req.Shipment.Carrier == "" || req.Shipment.TrackingNumber == "" {
+			return nil, fmt.Errorf("carrier and tracking number are required to ship an order")
+		}
+		req.Shipment.OrderId = req.Id
+		req.Shipment.CreatedBy = userId
+	}
+
+	if err := u.ordersRepo.UpdateOrder(req, history); err != nil {
+		return nil, err
+	}
+
+	order, err = u.ordersRepo.FindOnceOrders(req.Id)
+	if err != nil {
+		return nil, err
+	}
 
 	return order, nil
 }
+
+// TransitionPreorders moves the pre-orders of a product one step, oldest
+// first. An order that cannot move, e.g. the stock ran out before its turn,
+// is reported and left where it is.
+func (u *ordersUsecase) TransitionPreorders(req *orders.PreorderReq) (*orders.PreorderRes, error) {
+	from, ok := orders.PreorderStatusFrom[req.Status]
+	if !ok {
+		return nil, &orders.StatusTransitionError{
+			From: "pre-order",
+			To:   req.Status,
+		}
+	}
+	if _, err := u.productsRepo.FindOneProducts(req.ProductId); err != nil {
+		return nil, err
+	}
+
+	orderIds, err := u.ordersRepo.FindPreorderIds(req.ProductId, from)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &orders.PreorderRes{
+		Updated: make([]string, 0),
+		Failed:  make([]*orders.PreorderFailed, 0),
+	}
+	for _, orderId := range orderIds {
+		if _, err := u.UpdateOrder(&orders.Order{
+			Id:     orderId,
+			Status: req.Status,
+		}, req.ChangedBy, true); err != nil {
+			res.Failed = append(res.Failed, &orders.PreorderFailed{
+				OrderId: orderId,
+				Error:   err.Error(),
+			})
+			continue
+		}
+		res.Updated = append(res.Updated, orderId)
+	}
+	return res, nil
+}
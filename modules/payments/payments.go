@@ -0,0 +1,44 @@
+package payments
+
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusRefunded  = "refunded"
+)
+
+const (
+	EventSucceeded = "payment.succeeded"
+	EventFailed    = "payment.failed"
+	EventRefunded  = "payment.refunded"
+)
+
+type PaymentIntent struct {
+	Id             string  `db:"id" json:"id"`
+	OrderId        string  `db:"order_id" json:"order_id"`
+	Provider       string  `db:"provider" json:"provider"`
+	ProviderRef    string  `db:"provider_ref" json:"provider_ref"`
+	Amount         float64 `db:"amount" json:"amount"`
+	Currency       string  `db:"currency" json:"currency"`
+	Payload        string  `db:"payload" json:"payload"` // e.g. PromptPay QR string
+	Status         string  `db:"status" json:"status"`
+	RefundedAmount float64 `db:"refunded_amount" json:"refunded_amount"`
+	CreatedAt      string  `db:"created_at" json:"created_at"`
+	UpdatedAt      string  `db:"updated_at" json:"updated_at"`
+}
+
+type IntentReq struct {
+	Provider string `json:"provider" form:"provider"`
+}
+
+type RefundReq struct {
+	Amount float64 `json:"amount" form:"amount"`
+}
+
+type WebhookEvent struct {
+	Event          string  `json:"event"`
+	IntentId       string  `json:"intent_id"`
+	ProviderRef    string  `json:"provider_ref"`
+	Amount         float64 `json:"amount"`
+	RefundedAmount float64 `json:"refunded_amount"` // total refunded so far, sent with payment.refunded
+}
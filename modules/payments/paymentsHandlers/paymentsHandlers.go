@@ -0,0 +1,131 @@
+package paymentshandlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Tanapoowapat/GunplaShop/config"
+	"github.com/Tanapoowapat/GunplaShop/modules/entities"
+	"github.com/Tanapoowapat/GunplaShop/modules/payments"
+	paymentsusecase "github.com/Tanapoowapat/GunplaShop/modules/payments/paymentsUsecase"
+	"github.com/gofiber/fiber/v2"
+)
+
+type paymentsHandlersErr string
+
+const (
+	CreateIntentErr     paymentsHandlersErr = "Payments-001"
+	FindOrderIntentsErr paymentsHandlersErr = "Payments-002"
+	CaptureErr          paymentsHandlersErr = "Payments-003"
+	RefundErr           paymentsHandlersErr = "Payments-004"
+	WebhookErr          paymentsHandlersErr = "Payments-005"
+)
+
+type IPaymentsHandlers interface {
+	CreateIntent(c *fiber.Ctx) error
+	FindOrderIntents(c *fiber.Ctx) error
+	Capture(c *fiber.Ctx) error
+	Refund(c *fiber.Ctx) error
+	Webhook(c *fiber.Ctx) error
+}
+
+type paymentsHandlers struct {
+	cfg             config.IConfig
+	paymentsUsecase paymentsusecase.IPaymentsUsecase
+}
+
+func NewPaymentsHandlers(cfg config.IConfig, paymentsUsecase paymentsusecase.IPaymentsUsecase) IPaymentsHandlers {
+	return &paymentsHandlers{
+		cfg:             cfg,
+		paymentsUsecase: paymentsUsecase,
+	}
+}
+
+func (h *paymentsHandlers) CreateIntent(c *fiber.Ctx) error {
+	req := new(payments.IntentReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(CreateIntentErr),
+			err.Error(),
+		).Res()
+	}
+	userId := strings.Trim(c.Params("userId"), " ")
+	orderId := strings.Trim(c.Params("order_id"), " ")
+
+	intent, err := h.paymentsUsecase.CreateIntent(userId, orderId, req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(CreateIntentErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusCreated, intent).Res()
+}
+
+func (h *paymentsHandlers) FindOrderIntents(c *fiber.Ctx) error {
+	userId := strings.Trim(c.Params("userId"), " ")
+	orderId := strings.Trim(c.Params("order_id"), " ")
+	isAdmin := c.Locals("userRoleID").(int) == 2
+
+	intents, err := h.paymentsUsecase.FindOrderIntents(userId, orderId, isAdmin)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(FindOrderIntentsErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, intents).Res()
+}
+
+func (h *paymentsHandlers) Capture(c *fiber.Ctx) error {
+	intentId := strings.Trim(c.Params("intent_id"), " ")
+
+	intent, err := h.paymentsUsecase.Capture(intentId, c.Locals("userId").(string))
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(CaptureErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, intent).Res()
+}
+
+func (h *paymentsHandlers) Refund(c *fiber.Ctx) error {
+	req := new(payments.RefundReq)
+	if err := c.BodyParser(req); err != nil && !errors.Is(err, fiber.ErrUnprocessableEntity) {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(RefundErr),
+			err.Error(),
+		).Res()
+	}
+	intentId := strings.Trim(c.Params("intent_id"), " ")
+
+	intent, err := h.paymentsUsecase.Refund(intentId, req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(RefundErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, intent).Res()
+}
+
+func (h *paymentsHandlers) Webhook(c *fiber.Ctx) error {
+	provider := strings.Trim(c.Params("provider"), " ")
+	signature := c.Get("X-Signature")
+
+	if err := h.paymentsUsecase.HandleWebhook(provider, signature, c.Body()); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(WebhookErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, nil).Res()
+}
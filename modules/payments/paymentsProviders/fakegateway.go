@@ -0,0 +1,104 @@
+package paymentsproviders
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/orders"
+	"github.com/Tanapoowapat/GunplaShop/modules/payments"
+	"github.com/google/uuid"
+)
+
+const FakeGateway = "fake"
+
+// fakeGateway keeps every payment in memory, it is meant for tests and local development
+type fakeGateway struct {
+	secret   []byte
+	mu       sync.Mutex
+	captured map[string]bool
+	refunded map[string]float64
+}
+
+type IFakeGateway interface {
+	IPaymentProvider
+	Webhook(event string, intent *payments.PaymentIntent) (signature string, body []byte)
+	IsCaptured(providerRef string) bool
+	RefundedAmount(providerRef string) float64
+}
+
+func NewFakeGateway(secret []byte) IFakeGateway {
+	return &fakeGateway{
+		secret:   secret,
+		captured: make(map[string]bool),
+		refunded: make(map[string]float64),
+	}
+}
+
+func (g *fakeGateway) Name() string { return FakeGateway }
+
+func (g *fakeGateway) CreateIntent(order *orders.Order) (*payments.PaymentIntent, error) {
+	if order.AmountDue() <= 0 {
+		return nil, fmt.Errorf("amount must be greater than 0")
+	}
+	ref := "fake_" + uuid.NewString()
+	return &payments.PaymentIntent{
+		OrderId:     order.Id,
+		Provider:    FakeGateway,
+		ProviderRef: ref,
+		Amount:      order.AmountDue(),
+		Currency:    "THB",
+		Payload:     ref,
+		Status:      payments.StatusPending,
+	}, nil
+}
+
+func (g *fakeGateway) Capture(intent *payments.PaymentIntent) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.captured[intent.ProviderRef] {
+		return fmt.Errorf("payment has already been captured")
+	}
+	g.captured[intent.ProviderRef] = true
+	return nil
+}
+
+func (g *fakeGateway) Refund(intent *payments.PaymentIntent, amount float64) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.refunded[intent.ProviderRef]+amount > intent.Amount {
+		return fmt.Errorf("refund exceeds payment amount")
+	}
+	g.refunded[intent.ProviderRef] += amount
+	return nil
+}
+
+func (g *fakeGateway) VerifyWebhook(signature string, body []byte) (*payments.WebhookEvent, error) {
+	return verifyWebhook(g.secret, signature, body)
+}
+
+// Webhook builds a signed callback as the gateway would send it
+func (g *fakeGateway) Webhook(event string, intent *payments.PaymentIntent) (string, []byte) {
+	body, _ := json.Marshal(&payments.WebhookEvent{
+		Event:          event,
+		IntentId:       intent.Id,
+		ProviderRef:    intent.ProviderRef,
+		Amount:         intent.Amount,
+		RefundedAmount: g.RefundedAmount(intent.ProviderRef),
+	})
+	return SignWebhook(g.secret, body), body
+}
+
+func (g *fakeGateway) IsCaptured(providerRef string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.captured[providerRef]
+}
+
+func (g *fakeGateway) RefundedAmount(providerRef string) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.refunded[providerRef]
+}
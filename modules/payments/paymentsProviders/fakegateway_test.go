@@ -0,0 +1,33 @@
+package paymentsproviders
+
+import (
+	"testing"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/payments"
+)
+
+func TestVerifyWebhook(t *testing.T) {
+	gateway := NewFakeGateway([]byte("secret"))
+	intent := &payments.PaymentIntent{Id: "1", ProviderRef: "fake_1", Amount: 850}
+
+	signature, body := gateway.Webhook(payments.EventSucceeded, intent)
+	event, err := gateway.VerifyWebhook(signature, body)
+	if err != nil {
+		t.Fatalf("VerifyWebhook unexpected error: %v", err)
+	}
+	if event.Event != payments.EventSucceeded || event.IntentId != "1" || event.ProviderRef != "fake_1" || event.Amount != 850 {
+		t.Errorf("VerifyWebhook = %+v", event)
+	}
+
+	tampered := append([]byte{}, body...)
+	tampered[len(tampered)-2] = '9'
+	if _, err := gateway.VerifyWebhook(signature, tampered); err == nil {
+		t.Error("VerifyWebhook expected an error for a modified body")
+	}
+	if _, err := NewFakeGateway([]byte("other")).VerifyWebhook(signature, body); err == nil {
+		t.Error("VerifyWebhook expected an error for another secret")
+	}
+	if _, err := NewFakeGateway(nil).VerifyWebhook(signature, body); err == nil {
+		t.Error("VerifyWebhook expected an error without a secret")
+	}
+}
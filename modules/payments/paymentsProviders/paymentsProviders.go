@@ -0,0 +1,42 @@
+package paymentsproviders
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/orders"
+	"github.com/Tanapoowapat/GunplaShop/modules/payments"
+)
+
+type IPaymentProvider interface {
+	Name() string
+	CreateIntent(order *orders.Order) (*payments.PaymentIntent, error)
+	Capture(intent *payments.PaymentIntent) error
+	Refund(intent *payments.PaymentIntent, amount float64) error
+	VerifyWebhook(signature string, body []byte) (*payments.WebhookEvent, error)
+}
+
+// SignWebhook returns the hex HMAC-SHA256 of body, sent in the X-Signature header
+func SignWebhook(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func verifyWebhook(secret []byte, signature string, body []byte) (*payments.WebhookEvent, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("webhook secret is not configured")
+	}
+	if !hmac.Equal([]byte(SignWebhook(secret, body)), []byte(signature)) {
+		return nil, fmt.Errorf("invalid webhook signature")
+	}
+
+	event := new(payments.WebhookEvent)
+	if err := json.Unmarshal(body, event); err != nil {
+		return nil, fmt.Errorf("unmarshal webhook failed: %v", err)
+	}
+	return event, nil
+}
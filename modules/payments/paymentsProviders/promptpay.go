@@ -0,0 +1,136 @@
+package paymentsproviders
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Tanapoowapat/GunplaShop/config"
+	"github.com/Tanapoowapat/GunplaShop/modules/orders"
+	"github.com/Tanapoowapat/GunplaShop/modules/payments"
+)
+
+const PromptPay = "promptpay"
+
+// EMVCo tags used by the Thai QR payment standard
+const (
+	qrPayloadFormat    = "00"
+	qrPointOfInitiate  = "01"
+	qrMerchantAccount  = "29"
+	qrCountryCode      = "58"
+	qrMerchantName     = "59"
+	qrCurrency         = "53"
+	qrAmount           = "54"
+	qrAdditionalData   = "62"
+	qrReferenceLabel   = "05"
+	qrCrc              = "63"
+	promptPayAid       = "A000000677010111"
+	promptPayPhone     = "01"
+	promptPayTaxId     = "02"
+	promptPayEWalletId = "03"
+	currencyTHB        = "764"
+)
+
+type promptPayProvider struct {
+	cfg config.IPaymentConfig
+}
+
+// NewPromptPayProvider generates PromptPay QR payloads locally, the payment itself
+// is confirmed by a signed webhook from the bank notification service
+func NewPromptPayProvider(cfg config.IPaymentConfig) IPaymentProvider {
+	return &promptPayProvider{
+		cfg: cfg,
+	}
+}
+
+func (p *promptPayProvider) Name() string { return PromptPay }
+
+func (p *promptPayProvider) CreateIntent(order *orders.Order) (*payments.PaymentIntent, error) {
+	payload, err := PromptPayPayload(p.cfg.PromptPayId(), p.cfg.MerchantName(), order.Id, order.AmountDue())
+	if err != nil {
+		return nil, err
+	}
+	return &payments.PaymentIntent{
+		OrderId:     order.Id,
+		Provider:    PromptPay,
+		ProviderRef: order.Id,
+		Amount:      order.AmountDue(),
+		Currency:    "THB",
+		Payload:     payload,
+		Status:      payments.StatusPending,
+	}, nil
+}
+
+// Transfers settle immediately, there is nothing to capture
+func (p *promptPayProvider) Capture(intent *payments.PaymentIntent) error {
+	return nil
+}
+
+// PromptPay has no refund API, refunds are transferred back by hand
+func (p *promptPayProvider) Refund(intent *payments.PaymentIntent, amount float64) error {
+	return nil
+}
+
+func (p *promptPayProvider) VerifyWebhook(signature string, body []byte) (*payments.WebhookEvent, error) {
+	return verifyWebhook(p.cfg.WebhookSecret(), signature, body)
+}
+
+// PromptPayPayload builds a dynamic EMVCo QR string for a phone number,
+// national/tax id or e-wallet id
+func PromptPayPayload(id, merchantName, reference string, amount float64) (string, error) {
+	id = regexp.MustCompile(`[^0-9]`).ReplaceAllString(id, "")
+
+	var account string
+	switch len(id) {
+	case 10:
+		// 0812345678 -> 0066812345678
+		account = emvField(promptPayPhone, "0066"+id[1:])
+	case 13:
+		account = emvField(promptPayTaxId, id)
+	case 15:
+		account = emvField(promptPayEWalletId, id)
+	default:
+		return "", fmt.Errorf("invalid promptpay id")
+	}
+	if amount <= 0 {
+		return "", fmt.Errorf("amount must be greater than 0")
+	}
+
+	var b strings.Builder
+	b.WriteString(emvField(qrPayloadFormat, "01"))
+	b.WriteString(emvField(qrPointOfInitiate, "12"))
+	b.WriteString(emvField(qrMerchantAccount, emvField("00", promptPayAid)+account))
+	b.WriteString(emvField(qrCountryCode, "TH"))
+	if merchantName != "" {
+		b.WriteString(emvField(qrMerchantName, merchantName))
+	}
+	b.WriteString(emvField(qrCurrency, currencyTHB))
+	b.WriteString(emvField(qrAmount, fmt.Sprintf("%.2f", amount)))
+	if reference != "" {
+		b.WriteString(emvField(qrAdditionalData, emvField(qrReferenceLabel, reference)))
+	}
+
+	// The checksum covers the crc tag and length as well
+	b.WriteString(qrCrc + "04")
+	return b.String() + fmt.Sprintf("%04X", crc16(b.String())), nil
+}
+
+func emvField(tag, value string) string {
+	return fmt.Sprintf("%s%02d%s", tag, len(value), value)
+}
+
+// CRC-16/CCITT-FALSE
+func crc16(data string) uint16 {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,81 @@
+package paymentsproviders
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+// readEmv splits a payload into its top level tag -> value pairs
+func readEmv(t *testing.T, payload string) map[string]string {
+	t.Helper()
+	fields := make(map[string]string)
+	for i := 0; i < len(payload); {
+		if i+4 > len(payload) {
+			t.Fatalf("payload %q is truncated at %d", payload, i)
+		}
+		size, err := strconv.Atoi(payload[i+2 : i+4])
+		if err != nil || i+4+size > len(payload) {
+			t.Fatalf("payload %q has a bad length at %d", payload, i)
+		}
+		fields[payload[i:i+2]] = payload[i+4 : i+4+size]
+		i += 4 + size
+	}
+	return fields
+}
+
+func TestCrc16(t *testing.T) {
+	// The CRC-16/CCITT-FALSE check value
+	if got := crc16("123456789"); got != 0x29B1 {
+		t.Errorf("crc16(123456789) = %04X, want 29B1", got)
+	}
+}
+
+func TestPromptPayPayload(t *testing.T) {
+	tests := []struct {
+		id      string
+		account string
+	}{
+		{"081-234-5678", "0016A00000067701011101130066812345678"},
+		{"1234567890123", "0016A00000067701011102131234567890123"},
+		{"123456789012345", "0016A0000006770101110315123456789012345"},
+	}
+
+	for _, tt := range tests {
+		payload, err := PromptPayPayload(tt.id, "Gunpla Shop", "O000001", 1250.5)
+		if err != nil {
+			t.Fatalf("PromptPayPayload(%s) unexpected error: %v", tt.id, err)
+		}
+
+		fields := readEmv(t, payload)
+		want := map[string]string{
+			qrPayloadFormat:   "01",
+			qrPointOfInitiate: "12",
+			qrMerchantAccount: tt.account,
+			qrCountryCode:     "TH",
+			qrMerchantName:    "Gunpla Shop",
+			qrCurrency:        currencyTHB,
+			qrAmount:          "1250.50",
+			qrAdditionalData:  "0507O000001",
+		}
+		for tag, value := range want {
+			if fields[tag] != value {
+				t.Errorf("PromptPayPayload(%s) tag %s = %q, want %q", tt.id, tag, fields[tag], value)
+			}
+		}
+
+		body := payload[:len(payload)-4]
+		if crc := fmt.Sprintf("%04X", crc16(body)); fields[qrCrc] != crc {
+			t.Errorf("PromptPayPayload(%s) crc = %s, want %s", tt.id, fields[qrCrc], crc)
+		}
+	}
+}
+
+func TestPromptPayPayloadRejectsBadInput(t *testing.T) {
+	if _, err := PromptPayPayload("12345", "", "", 100); err == nil {
+		t.Error("PromptPayPayload expected an error for a short id")
+	}
+	if _, err := PromptPayPayload("0812345678", "", "", 0); err == nil {
+		t.Error("PromptPayPayload expected an error for a zero amount")
+	}
+}
@@ -0,0 +1,168 @@
+package paymentsrepositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/payments"
+	"github.com/jmoiron/sqlx"
+)
+
+type IPaymentsRepositories interface {
+	FindOneIntent(intentId string) (*payments.PaymentIntent, error)
+	FindOrderIntents(orderId string) ([]*payments.PaymentIntent, error)
+	InsertIntent(req *payments.PaymentIntent) (string, error)
+	UpdateIntentStatus(intentId, from, to string) (bool, error)
+	AddRefundedAmount(intentId string, amount float64) error
+	SetRefundedAmount(intentId string, total float64) error
+}
+
+type paymentsRepositories struct {
+	db *sqlx.DB
+}
+
+func NewPaymentsRepositories(db *sqlx.DB) IPaymentsRepositories {
+	return &paymentsRepositories{
+		db: db,
+	}
+}
+
+const selectIntentQuery = `
+	SELECT
+		"pi"."id",
+		"pi"."order_id",
+		"pi"."provider",
+		"pi"."provider_ref",
+		"pi"."amount",
+		"pi"."currency",
+		"pi"."payload",
+		"pi"."status",
+		"pi"."refunded_amount",
+		"pi"."created_at",
+		"pi"."updated_at"
+	FROM "payment_intents" "pi"`
+
+func (repo *paymentsRepositories) FindOneIntent(intentId string) (*payments.PaymentIntent, error) {
+	query := selectIntentQuery + `
+	WHERE "pi"."id" = $1;`
+
+	intent := new(payments.PaymentIntent)
+	if err := repo.db.Get(intent, query, intentId); err != nil {
+		return nil, fmt.Errorf("payment intent not found: %v", err)
+	}
+	return intent, nil
+}
+
+func (repo *paymentsRepositories) FindOrderIntents(orderId string) ([]*payments.PaymentIntent, error) {
+	query := selectIntentQuery + `
+	WHERE "pi"."order_id" = $1
+	ORDER BY "pi"."created_at" DESC;`
+
+	intents := make([]*payments.PaymentIntent, 0)
+	if err := repo.db.Select(&intents, query, orderId); err != nil {
+		return nil, fmt.Errorf("get payment intents failed: %v", err)
+	}
+	return intents, nil
+}
+
+func (repo *paymentsRepositories) InsertIntent(req *payments.PaymentIntent) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	query := `
+	INSERT INTO "payment_intents" (
+		"order_id",
+		"provider",
+		"provider_ref",
+		"amount",
+		"currency",
+		"payload",
+		"status"
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING "id";`
+
+	var intentId string
+	if err := repo.db.QueryRowxContext(
+		ctx,
+		query,
+		req.OrderId,
+		req.Provider,
+		req.ProviderRef,
+		req.Amount,
+		req.Currency,
+		req.Payload,
+		req.Status,
+	).Scan(&intentId); err != nil {
+		return "", fmt.Errorf("insert payment intent failed: %v", err)
+	}
+	return intentId, nil
+}
+
+// UpdateIntentStatus moves the intent from one status to another and reports
+// whether it did, an intent that is no longer in from is left as it is
+func (repo *paymentsRepositories) UpdateIntentStatus(intentId, from, to string) (bool, error) {
+	query := `
+	UPDATE "payment_intents" SET
+		"status" = $1
+	WHERE "id" = $2 AND "status" = $3;`
+
+	result, err := repo.db.ExecContext(context.Background(), query, to, intentId, from)
+	if err != nil {
+		return false, fmt.Errorf("update payment intent failed: %v", err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// refundedStatus keeps a partly refunded intent succeeded, it becomes refunded
+// once the new refunded total covers the whole amount
+func refundedStatus(total string) string {
+	return `
+		"status" = CASE
+			WHEN ROUND((` + total + `)::NUMERIC, 2) >= ROUND("amount"::NUMERIC, 2) THEN 'refunded'::payment_status
+			ELSE "status"
+		END`
+}
+
+// AddRefundedAmount records a refund made from the shop, it fails when the
+// refund would exceed the paid amount
+func (repo *paymentsRepositories) AddRefundedAmount(intentId string, amount float64) error {
+	query := `
+	UPDATE "payment_intents" SET
+		"refunded_amount" = "refunded_amount" + $1,` + refundedStatus(`"refunded_amount" + $1`) + `
+	WHERE "id" = $2
+		AND "status" IN ('succeeded', 'refunded')
+		AND ROUND(("refunded_amount" + $1)::NUMERIC, 2) <= ROUND("amount"::NUMERIC, 2);`
+
+	result, err := repo.db.ExecContext(context.Background(), query, amount, intentId)
+	if err != nil {
+		return fmt.Errorf("refund payment intent failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("refund exceeds payment amount")
+	}
+	return nil
+}
+
+// SetRefundedAmount records the refunded total reported by the provider, so a
+// refund made from the shop and its confirmation are counted once and a
+// replayed webhook changes nothing
+func (repo *paymentsRepositories) SetRefundedAmount(intentId string, total float64) error {
+	query := `
+	UPDATE "payment_intents" SET
+		"refunded_amount" = GREATEST("refunded_amount", $1),` + refundedStatus(`GREATEST("refunded_amount", $1)`) + `
+	WHERE "id" = $2
+		AND "status" IN ('succeeded', 'refunded')
+		AND ROUND($1::NUMERIC, 2) <= ROUND("amount"::NUMERIC, 2);`
+
+	result, err := repo.db.ExecContext(context.Background(), query, total, intentId)
+	if err != nil {
+		return fmt.Errorf("refund payment intent failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("refund exceeds payment amount")
+	}
+	return nil
+}
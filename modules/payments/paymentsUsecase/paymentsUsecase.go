@@ -0,0 +1,233 @@
+package paymentsusecase
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/orders"
+	ordersusecase "github.com/Tanapoowapat/GunplaShop/modules/orders/ordersUsecase"
+	"github.com/Tanapoowapat/GunplaShop/modules/payments"
+	paymentsproviders "github.com/Tanapoowapat/GunplaShop/modules/payments/paymentsProviders"
+	paymentsrepositories "github.com/Tanapoowapat/GunplaShop/modules/payments/paymentsRepositories"
+)
+
+type IPaymentsUsecase interface {
+	CreateIntent(userId, orderId string, req *payments.IntentReq) (*payments.PaymentIntent, error)
+	FindOrderIntents(userId, orderId string, isAdmin bool) ([]*payments.PaymentIntent, error)
+	Capture(intentId, adminId string) (*payments.PaymentIntent, error)
+	Refund(intentId string, req *payments.RefundReq) (*payments.PaymentIntent, error)
+	RefundOrder(orderId string, amount float64) (float64, error)
+	HandleWebhook(provider, signature string, body []byte) error
+}
+
+type paymentsUsecase struct {
+	paymentsRepo  paymentsrepositories.IPaymentsRepositories
+	ordersUsecase ordersusecase.IOrdersUsecase
+	providers     map[string]paymentsproviders.IPaymentProvider
+}
+
+func NewPaymentsUsecase(paymentsRepo paymentsrepositories.IPaymentsRepositories, ordersUsecase ordersusecase.IOrdersUsecase, providers ...paymentsproviders.IPaymentProvider) IPaymentsUsecase {
+	providerMap := make(map[string]paymentsproviders.IPaymentProvider)
+	for _, p := range providers {
+		providerMap[p.Name()] = p
+	}
+	return &paymentsUsecase{
+		paymentsRepo:  paymentsRepo,
+		ordersUsecase: ordersUsecase,
+		providers:     providerMap,
+	}
+}
+
+func (u *paymentsUsecase) provider(name string) (paymentsproviders.IPaymentProvider, error) {
+	p, ok := u.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("payment provider %s is not supported", name)
+	}
+	return p, nil
+}
+
+func (u *paymentsUsecase) CreateIntent(userId, orderId string, req *payments.IntentReq) (*payments.PaymentIntent, error) {
+	p, err := u.provider(req.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := u.ordersUsecase.FindOnceOrders(orderId)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserId != userId {
+		return nil, fmt.Errorf("order not found")
+	}
+	if order.AmountDue() <= 0 {
+		return nil, fmt.Errorf("order status is %s, payment is not required", order.Status)
+	}
+
+	intent, err := p.CreateIntent(order)
+	if err != nil {
+		return nil, err
+	}
+	intentId, err := u.paymentsRepo.InsertIntent(intent)
+	if err != nil {
+		return nil, err
+	}
+	return u.paymentsRepo.FindOneIntent(intentId)
+}
+
+func (u *paymentsUsecase) FindOrderIntents(userId, orderId string, isAdmin bool) ([]*payments.PaymentIntent, error) {
+	order, err := u.ordersUsecase.FindOnceOrders(orderId)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin && order.UserId != userId {
+		return nil, fmt.Errorf("order not found")
+	}
+	return u.paymentsRepo.FindOrderIntents(orderId)
+}
+
+func (u *paymentsUsecase) Capture(intentId, adminId string) (*payments.PaymentIntent, error) {
+	intent, err := u.paymentsRepo.FindOneIntent(intentId)
+	if err != nil {
+		return nil, err
+	}
+	if intent.Status != payments.StatusPending {
+		return nil, fmt.Errorf("payment status is %s, nothing to capture", intent.Status)
+	}
+	p, err := u.provider(intent.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Capture(intent); err != nil {
+		return nil, err
+	}
+	if err := u.markSucceeded(intent, adminId); err != nil {
+		return nil, err
+	}
+	return u.paymentsRepo.FindOneIntent(intentId)
+}
+
+func (u *paymentsUsecase) Refund(intentId string, req *payments.RefundReq) (*payments.PaymentIntent, error) {
+	intent, err := u.paymentsRepo.FindOneIntent(intentId)
+	if err != nil {
+		return nil, err
+	}
+	if intent.Status != payments.StatusSucceeded && intent.Status != payments.StatusRefunded {
+		return nil, fmt.Errorf("payment status is %s, nothing to refund", intent.Status)
+	}
+	if req.Amount <= 0 {
+		req.Amount = intent.Amount - intent.RefundedAmount
+	}
+
+	p, err := u.provider(intent.Provider)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Refund(intent, req.Amount); err != nil {
+		return nil, err
+	}
+	if err := u.paymentsRepo.AddRefundedAmount(intentId, req.Amount); err != nil {
+		return nil, err
+	}
+	return u.paymentsRepo.FindOneIntent(intentId)
+}
+
+// RefundOrder refunds up to amount across the order's settled payments and returns
+// how much went back through a provider, the rest (e.g. bank transfer slips) is manual
+func (u *paymentsUsecase) RefundOrder(orderId string, amount float64) (float64, error) {
+	intents, err := u.paymentsRepo.FindOrderIntents(orderId)
+	if err != nil {
+		return 0, err
+	}
+
+	refunded := 0.0
+	for _, intent := range intents {
+		if intent.Status != payments.StatusSucceeded && intent.Status != payments.StatusRefunded {
+			continue
+		}
+		left := math.Round((amount-refunded)*100) / 100
+		if left <= 0 {
+			break
+		}
+		part := math.Min(intent.Amount-intent.RefundedAmount, left)
+		if part <= 0 {
+			continue
+		}
+
+		p, err := u.provider(intent.Provider)
+		if err != nil {
+			return refunded, err
+		}
+		if err := p.Refund(intent, part); err != nil {
+			return refunded, err
+		}
+		if err := u.paymentsRepo.AddRefundedAmount(intent.Id, part); err != nil {
+			return refunded, err
+		}
+		refunded += part
+	}
+	return refunded, nil
+}
+
+// HandleWebhook verifies a provider callback and moves the payment and its order along
+func (u *paymentsUsecase) HandleWebhook(provider, signature string, body []byte) error {
+	p, err := u.provider(provider)
+	if err != nil {
+		return err
+	}
+	event, err := p.VerifyWebhook(signature, body)
+	if err != nil {
+		return err
+	}
+
+	intent, err := u.paymentsRepo.FindOneIntent(event.IntentId)
+	if err != nil {
+		return err
+	}
+	if intent.Provider != provider || intent.ProviderRef != event.ProviderRef {
+		return fmt.Errorf("payment intent does not match webhook")
+	}
+
+	switch event.Event {
+	case payments.EventSucceeded:
+		if event.Amount < intent.Amount {
+			return fmt.Errorf("paid amount %.2f is less than %.2f", event.Amount, intent.Amount)
+		}
+		return u.markSucceeded(intent, "")
+	case payments.EventFailed:
+		// Only a pending payment can fail, a late or replayed event changes nothing
+		_, err := u.paymentsRepo.UpdateIntentStatus(intent.Id, payments.StatusPending, payments.StatusFailed)
+		return err
+	case payments.EventRefunded:
+		return u.paymentsRepo.SetRefundedAmount(intent.Id, event.RefundedAmount)
+	default:
+		return fmt.Errorf("webhook event %s is not supported", event.Event)
+	}
+}
+
+// markSucceeded only moves a pending intent, so a replayed webhook never takes a
+// failed or refunded payment back to succeeded. The order is paid before the
+// intent is marked, a failed order update leaves the intent pending and the
+// provider's retry finishes the job
+func (u *paymentsUsecase) markSucceeded(intent *payments.PaymentIntent, changedBy string) error {
+	if intent.Status != payments.StatusPending {
+		return nil
+	}
+
+	order, err := u.ordersUsecase.FindOnceOrders(intent.OrderId)
+	if err != nil {
+		return err
+	}
+	if due := order.AmountDue(); due > 0 {
+		if intent.Amount < due {
+			return fmt.Errorf("payment of %.2f does not cover the %.2f due", intent.Amount, due)
+		}
+		if _, err := u.ordersUsecase.UpdateOrder(&orders.Order{
+			Id:     intent.OrderId,
+			Status: order.PaidStatus(),
+		}, changedBy, true); err != nil {
+			return err
+		}
+	}
+	_, err = u.paymentsRepo.UpdateIntentStatus(intent.Id, payments.StatusPending, payments.StatusSucceeded)
+	return err
+}
@@ -0,0 +1,184 @@
+package paymentsusecase
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/entities"
+	"github.com/Tanapoowapat/GunplaShop/modules/orders"
+	"github.com/Tanapoowapat/GunplaShop/modules/payments"
+	paymentsproviders "github.com/Tanapoowapat/GunplaShop/modules/payments/paymentsProviders"
+)
+
+type fakePaymentsRepo struct {
+	intents map[string]*payments.PaymentIntent
+}
+
+func (r *fakePaymentsRepo) FindOneIntent(intentId string) (*payments.PaymentIntent, error) {
+	intent, ok := r.intents[intentId]
+	if !ok {
+		return nil, fmt.Errorf("payment intent not found")
+	}
+	copied := *intent
+	return &copied, nil
+}
+
+func (r *fakePaymentsRepo) FindOrderIntents(orderId string) ([]*payments.PaymentIntent, error) {
+	return nil, nil
+}
+
+func (r *fakePaymentsRepo) InsertIntent(req *payments.PaymentIntent) (string, error) {
+	req.Id = fmt.Sprint(len(r.intents) + 1)
+	r.intents[req.Id] = req
+	return req.Id, nil
+}
+
+// The fake follows the conditions of the SQL in paymentsRepositories
+
+func (r *fakePaymentsRepo) UpdateIntentStatus(intentId, from, to string) (bool, error) {
+	intent := r.intents[intentId]
+	if intent.Status != from {
+		return false, nil
+	}
+	intent.Status = to
+	return true, nil
+}
+
+func (r *fakePaymentsRepo) AddRefundedAmount(intentId string, amount float64) error {
+	return r.SetRefundedAmount(intentId, r.intents[intentId].RefundedAmount+amount)
+}
+
+func (r *fakePaymentsRepo) SetRefundedAmount(intentId string, total float64) error {
+	intent := r.intents[intentId]
+	if (intent.Status != payments.StatusSucceeded && intent.Status != payments.StatusRefunded) || total > intent.Amount {
+		return fmt.Errorf("refund exceeds payment amount")
+	}
+	intent.RefundedAmount = math.Max(intent.RefundedAmount, total)
+	if intent.RefundedAmount >= intent.Amount {
+		intent.Status = payments.StatusRefunded
+	}
+	return nil
+}
+
+type fakeOrdersUsecase struct {
+	order   *orders.Order
+	fail    bool
+	updates int
+}
+
+func (u *fakeOrdersUsecase) FindOnceOrders(orderId string) (*orders.Order, error) {
+	copied := *u.order
+	return &copied, nil
+}
+
+func (u *fakeOrdersUsecase) FindOrders(req *orders.OrderFilter) *entities.PaginateRes {
+	return nil
+}
+
+func (u *fakeOrdersUsecase) InsertOrder(req *orders.Order) (*orders.Order, error) {
+	return nil, nil
+}
+
+func (u *fakeOrdersUsecase) UpdateOrder(req *orders.Order, userId string, isAdmin bool) (*orders.Order, error) {
+	if u.fail {
+		return nil, fmt.Errorf("update order failed")
+	}
+	u.updates++
+	u.order.Status = req.Status
+	return u.order, nil
+}
+
+func (u *fakeOrdersUsecase) TransitionPreorders(req *orders.PreorderReq) (*orders.PreorderRes, error) {
+	return nil, nil
+}
+
+func TestHandleWebhookPaysOrder(t *testing.T) {
+	gateway := paymentsproviders.NewFakeGateway([]byte("secret"))
+	repo := &fakePaymentsRepo{intents: make(map[string]*payments.PaymentIntent)}
+	ordersUsecase := &fakeOrdersUsecase{order: &orders.Order{Id: "O000001", UserId: "U000001", Status: orders.StatusWaiting, TotalPrice: 850}}
+	usecase := NewPaymentsUsecase(repo, ordersUsecase, gateway)
+
+	intent, err := usecase.CreateIntent("U000001", "O000001", &payments.IntentReq{Provider: paymentsproviders.FakeGateway})
+	if err != nil {
+		t.Fatalf("CreateIntent unexpected error: %v", err)
+	}
+	signature, body := gateway.Webhook(payments.EventSucceeded, intent)
+
+	// The order update fails, the intent stays pending so the retry is not skipped
+	ordersUsecase.fail = true
+	if err := usecase.HandleWebhook(paymentsproviders.FakeGateway, signature, body); err == nil {
+		t.Fatal("HandleWebhook expected an error when the order cannot be updated")
+	}
+	if repo.intents[intent.Id].Status != payments.StatusPending {
+		t.Errorf("intent status = %s after a failed order update, want pending", repo.intents[intent.Id].Status)
+	}
+
+	ordersUsecase.fail = false
+	for i := 0; i < 2; i++ {
+		if err := usecase.HandleWebhook(paymentsproviders.FakeGateway, signature, body); err != nil {
+			t.Fatalf("HandleWebhook delivery %d unexpected error: %v", i+1, err)
+		}
+	}
+	if repo.intents[intent.Id].Status != payments.StatusSucceeded {
+		t.Errorf("intent status = %s, want succeeded", repo.intents[intent.Id].Status)
+	}
+	if ordersUsecase.order.Status != orders.StatusPaid || ordersUsecase.updates != 1 {
+		t.Errorf("order status = %s after %d updates, want paid once", ordersUsecase.order.Status, ordersUsecase.updates)
+	}
+
+	if err := usecase.HandleWebhook(paymentsproviders.FakeGateway, "bad", body); err == nil {
+		t.Error("HandleWebhook expected an error for a bad signature")
+	}
+}
+
+func TestHandleWebhookRefunds(t *testing.T) {
+	gateway := paymentsproviders.NewFakeGateway([]byte("secret"))
+	repo := &fakePaymentsRepo{intents: make(map[string]*payments.PaymentIntent)}
+	ordersUsecase := &fakeOrdersUsecase{order: &orders.Order{Id: "O000001", UserId: "U000001", Status: orders.StatusWaiting, TotalPrice: 850}}
+	usecase := NewPaymentsUsecase(repo, ordersUsecase, gateway)
+
+	intent, err := usecase.CreateIntent("U000001", "O000001", &payments.IntentReq{Provider: paymentsproviders.FakeGateway})
+	if err != nil {
+		t.Fatalf("CreateIntent unexpected error: %v", err)
+	}
+	paid, paidBody := gateway.Webhook(payments.EventSucceeded, intent)
+	if err := usecase.HandleWebhook(paymentsproviders.FakeGateway, paid, paidBody); err != nil {
+		t.Fatalf("HandleWebhook(succeeded) unexpected error: %v", err)
+	}
+
+	// A partial refund from the shop and the provider's confirmation count once
+	if _, err := usecase.Refund(intent.Id, &payments.RefundReq{Amount: 350}); err != nil {
+		t.Fatalf("Refund unexpected error: %v", err)
+	}
+	refunded, refundedBody := gateway.Webhook(payments.EventRefunded, intent)
+	for i := 0; i < 2; i++ {
+		if err := usecase.HandleWebhook(paymentsproviders.FakeGateway, refunded, refundedBody); err != nil {
+			t.Fatalf("HandleWebhook(refunded) delivery %d unexpected error: %v", i+1, err)
+		}
+	}
+	if got := repo.intents[intent.Id]; got.RefundedAmount != 350 || got.Status != payments.StatusSucceeded {
+		t.Errorf("intent after a partial refund = %.2f %s, want 350.00 succeeded", got.RefundedAmount, got.Status)
+	}
+
+	if _, err := usecase.Refund(intent.Id, &payments.RefundReq{}); err != nil {
+		t.Fatalf("Refund unexpected error: %v", err)
+	}
+	if got := repo.intents[intent.Id]; got.RefundedAmount != 850 || got.Status != payments.StatusRefunded {
+		t.Errorf("intent after a full refund = %.2f %s, want 850.00 refunded", got.RefundedAmount, got.Status)
+	}
+
+	// Late or replayed events never move a refunded payment back
+	failed, failedBody := gateway.Webhook(payments.EventFailed, intent)
+	for _, event := range []struct {
+		signature string
+		body      []byte
+	}{{paid, paidBody}, {failed, failedBody}} {
+		if err := usecase.HandleWebhook(paymentsproviders.FakeGateway, event.signature, event.body); err != nil {
+			t.Fatalf("HandleWebhook replay unexpected error: %v", err)
+		}
+	}
+	if got := repo.intents[intent.Id]; got.Status != payments.StatusRefunded || ordersUsecase.updates != 1 {
+		t.Errorf("intent after replays = %s with %d order updates, want refunded with 1", got.Status, ordersUsecase.updates)
+	}
+}
@@ -1,24 +1,412 @@
 package products
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/Tanapoowapat/GunplaShop/modules/appinfo"
 	"github.com/Tanapoowapat/GunplaShop/modules/entities"
 )
 
+// Grades and Scales are the values a kit may be tagged with
+var Grades = []string{"EG", "HG", "RG", "MG", "MGEX", "PG", "SD", "RE/100", "FM"}
+
+var Scales = []string{"1/144", "1/100", "1/60", "1/48", "non-scale"}
+
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+	StatusArchived  = "archived"
+)
+
+var Statuses = []string{StatusDraft, StatusPublished, StatusArchived}
+
+// EffectivePrice and EffectiveStatus apply the scheduled prices and
+// publish_at/unpublish_at at query time, see migration 000020
+const (
+	EffectivePrice  = `product_effective_price("p"."id", "p"."price")`
+	EffectiveStatus = `product_effective_status("p"."status", "p"."publish_at", "p"."unpublish_at")`
+	LowestPrice     = `product_lowest_price("p"."id", "p"."price")` // see migration 000021
+)
+
 type Products struct {
-	Id          string             `json:"id"`
-	Title       string             `json:"title"`
-	Description string             `json:"description"`
-	Category    *appinfo.Category  `json:"category"`
-	CreatedAt   string             `json:"created_at"`
-	UpdatedAt   string             `json:"updated_at"`
-	Price       float64            `json:"price"`
-	Images      []*entities.Images `json:"media"`
+	Id           string              `json:"id"`
+	Title        string              `json:"title"`
+	Description  string              `json:"description"`
+	Categories   []*appinfo.Category `json:"categories"`
+	CreatedAt    string              `json:"created_at"`
+	UpdatedAt    string              `json:"updated_at"`
+	Price        float64             `json:"price"`      // the scheduled sale price when one is running
+	BasePrice    float64             `json:"base_price"` // the price set on the product
+	Stock        int                 `json:"stock"`
+	Weight       int                 `json:"weight"` // grams, used for shipping rates
+	Status       string              `json:"status"` // draft | published | archived
+	DeletedAt    string              `json:"deleted_at,omitempty"`
+	PublishAt    string              `json:"publish_at,omitempty"`
+	UnpublishAt  string              `json:"unpublish_at,omitempty"`
+	SoldOut      bool                `json:"sold_out"`
+	Preorder     bool                `json:"preorder"`
+	ReleaseDate  string              `json:"release_date"` // YYYY-MM-DD, pre-orders only
+	Deposit      float64             `json:"deposit"`      // per unit, 0 = paid in full up front
+	Grade        string              `json:"grade"`
+	Scale        string              `json:"scale"`
+	Series       string              `json:"series"`
+	Manufacturer string              `json:"manufacturer"`
+	Rating       float64             `json:"rating"` // average of the approved reviews
+	ReviewCount  int                 `json:"review_count"`
+	Images       []*entities.Images  `json:"media"`
+	Variants     []*Variant          `json:"variants"`
+	Snippet      string              `json:"snippet,omitempty"` // description with the search terms highlighted
+	LowestPrice  float64             `json:"lowest_price_30d"`  // lowest price of the 30 days before the current sale, for sale labels
+	ChangedBy    string              `json:"-"`                 // admin recorded in the history
+}
+
+// RecommendLimit is the number of products in each recommendation list
+const RecommendLimit = 8
+
+type Recommendations struct {
+	Related        []*RecommendedProduct `json:"related"`         // same series or category
+	BoughtTogether []*RecommendedProduct `json:"bought_together"` // in the same orders
+}
+
+// RecommendedProduct is the card of a recommended product, Orders is the
+// number of orders it shared with the product, bought together only
+type RecommendedProduct struct {
+	Id     string  `db:"id" json:"id"`
+	Title  string  `db:"title" json:"title"`
+	Price  float64 `db:"price" json:"price"`
+	Grade  string  `db:"grade" json:"grade"`
+	Scale  string  `db:"scale" json:"scale"`
+	Series string  `db:"series" json:"series"`
+	Rating float64 `db:"rating" json:"rating"`
+	Image  string  `db:"image" json:"image"`
+	Orders int     `db:"orders" json:"orders,omitempty"`
+}
+
+// History is one changed field of a product
+type History struct {
+	Id        string `db:"id" json:"id"`
+	ProductId string `db:"product_id" json:"product_id"`
+	Field     string `db:"field" json:"field"`
+	OldValue  string `db:"old_value" json:"old_value"`
+	NewValue  string `db:"new_value" json:"new_value"`
+	ChangedBy string `db:"changed_by" json:"changed_by"`
+	CreatedAt string `db:"created_at" json:"created_at"`
+}
+
+// Variant is a sellable release of a product, e.g. a clear version, with its own price and stock
+type Variant struct {
+	Id        string             `json:"id"`
+	ProductId string             `json:"product_id"`
+	Sku       string             `json:"sku"`
+	Title     string             `json:"title"`
+	Price     float64            `json:"price"`
+	Stock     int                `json:"stock"`
+	Images    []*entities.Images `json:"media"`
+	CreatedAt string             `json:"created_at,omitempty"`
+	UpdatedAt string             `json:"updated_at,omitempty"`
+	ChangedBy string             `json:"-"`
+}
+
+// String is the variant as recorded in the product history, the stock is left
+// out as the inventory keeps its own movements
+func (obj *Variant) String() string {
+	return fmt.Sprintf("%s %s %s %g", obj.Id, obj.Sku, obj.Title, obj.Price)
+}
+
+// SortColumns whitelists what a product listing may be sorted by,
+// order_by=relevance is handled by the search instead
+var SortColumns = map[string]entities.SortColumn{
+	"id":           {Expr: `"p"."id"`, Cast: "VARCHAR"},
+	"title":        {Expr: `"p"."title"`, Cast: "VARCHAR"},
+	"price":        {Expr: EffectivePrice, Cast: "FLOAT"},
+	"created_at":   {Expr: `"p"."created_at"`, Cast: "TIMESTAMP"},
+	"rating":       {Expr: `"p"."rating_avg"`, Cast: "FLOAT"},
+	"review_count": {Expr: `"p"."review_count"`, Cast: "INT"},
+}
+
+// CategoryIds are the ids of the categories the product is in, without duplicates
+func (obj *Products) CategoryIds() []int {
+	ids := make([]int, 0, len(obj.Categories))
+	seen := make(map[int]bool)
+	for _, category := range obj.Categories {
+		if category != nil && !seen[category.Id] {
+			seen[category.Id] = true
+			ids = append(ids, category.Id)
+		}
+	}
+	return ids
+}
+
+// ValidateCategories rejects invalid category ids. A new product needs at
+// least one category, an update without categories keeps the current ones.
+func (obj *Products) ValidateCategories(required bool) error {
+	if required && len(obj.Categories) == 0 {
+		return fmt.Errorf("at least one category is required")
+	}
+	for _, category := range obj.Categories {
+		if category == nil || category.Id <= 0 {
+			return fmt.Errorf("category id is invalid")
+		}
+	}
+	return nil
+}
+
+// SortValue is the value of a SortColumns key, it is stored in the cursors
+func (obj *Products) SortValue(key string) any {
+	switch key {
+	case "id":
+		return obj.Id
+	case "price":
+		return obj.Price
+	case "created_at":
+		return obj.CreatedAt
+	case "rating":
+		return obj.Rating
+	case "review_count":
+		return obj.ReviewCount
+	default:
+		return obj.Title
+	}
+}
+
+// Listed reports whether customers can see the product, sold out products are still listed
+func (obj *Products) Listed() bool {
+	return obj.DeletedAt == "" && obj.Status == StatusPublished
+}
+
+// Purchasable rejects a product customers cannot buy right now
+func (obj *Products) Purchasable() error {
+	if !obj.Listed() {
+		return fmt.Errorf("product %s is not available", obj.Id)
+	}
+	if obj.SoldOut {
+		return fmt.Errorf("product %s is sold out", obj.Id)
+	}
+	return nil
+}
+
+type AvailabilityReq struct {
+	SoldOut bool `json:"sold_out" form:"sold_out"`
+}
+
+// ScheduleReq sets when a product goes live and when it is taken down,
+// RFC 3339 times, an empty one clears that schedule
+type ScheduleReq struct {
+	PublishAt   string `json:"publish_at" form:"publish_at"`
+	UnpublishAt string `json:"unpublish_at" form:"unpublish_at"`
+	ChangedBy   string `json:"-" form:"-"`
+}
+
+func (obj *ScheduleReq) Validate() error {
+	publishAt, err := parseTime(obj.PublishAt, "publish_at")
+	if err != nil {
+		return err
+	}
+	unpublishAt, err := parseTime(obj.UnpublishAt, "unpublish_at")
+	if err != nil {
+		return err
+	}
+	if !publishAt.IsZero() && !unpublishAt.IsZero() && !unpublishAt.After(publishAt) {
+		return fmt.Errorf("unpublish_at must be after publish_at")
+	}
+	return nil
+}
+
+// Price is a sale price running from StartAt until EndAt, or for good when EndAt is empty
+type Price struct {
+	Id        string  `db:"id" json:"id"`
+	ProductId string  `db:"product_id" json:"product_id"`
+	SalePrice float64 `db:"sale_price" json:"sale_price"`
+	StartAt   string  `db:"start_at" json:"start_at"`
+	EndAt     string  `db:"end_at" json:"end_at"`
+	CreatedBy string  `db:"created_by" json:"created_by"`
+	CreatedAt string  `db:"created_at" json:"created_at"`
+}
+
+func (obj *Price) String() string {
+	if obj.EndAt == "" {
+		return fmt.Sprintf("%g from %s", obj.SalePrice, obj.StartAt)
+	}
+	return fmt.Sprintf("%g from %s until %s", obj.SalePrice, obj.StartAt, obj.EndAt)
+}
+
+func (obj *Price) Validate() error {
+	if obj.SalePrice <= 0 {
+		return fmt.Errorf("sale_price must be greater than 0")
+	}
+	startAt, err := parseTime(obj.StartAt, "start_at")
+	if err != nil {
+		return err
+	}
+	if startAt.IsZero() {
+		return fmt.Errorf("start_at is required")
+	}
+	endAt, err := parseTime(obj.EndAt, "end_at")
+	if err != nil {
+		return err
+	}
+	if !endAt.IsZero() && !endAt.After(startAt) {
+		return fmt.Errorf("end_at must be after start_at")
+	}
+	return nil
+}
+
+func parseTime(value, field string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC 3339 time", field)
+	}
+	return t, nil
+}
+
+// ScheduledChange is a publish, unpublish or price change still to come
+type ScheduledChange struct {
+	ProductId string  `db:"product_id" json:"product_id"`
+	Title     string  `db:"title" json:"title"`
+	Change    string  `db:"change" json:"change"` // publish | unpublish | price_start | price_end
+	At        string  `db:"at" json:"at"`
+	PriceId   string  `db:"price_id" json:"price_id,omitempty"`
+	SalePrice float64 `db:"sale_price" json:"sale_price,omitempty"`
+}
+
+type PreorderReq struct {
+	Preorder    bool    `json:"preorder" form:"preorder"`
+	ReleaseDate string  `json:"release_date" form:"release_date"`
+	Deposit     float64 `json:"deposit" form:"deposit"`
+	ChangedBy   string  `json:"-" form:"-"`
+}
+
+func (obj *PreorderReq) Validate() error {
+	if !obj.Preorder {
+		obj.ReleaseDate = ""
+		obj.Deposit = 0
+		return nil
+	}
+	if _, err := time.Parse("2006-01-02", obj.ReleaseDate); err != nil {
+		return fmt.Errorf("release_date must be YYYY-MM-DD")
+	}
+	if obj.Deposit < 0 {
+		return fmt.Errorf("deposit must not be negative")
+	}
+	return nil
 }
 
 type ProductFilter struct {
-	Id     string `query:"id"`
-	Search string `query:"search"` // title & description
+	Id           string  `query:"id"`
+	Search       string  `query:"search"`       // full-text, e.g. "strike freedom -sd"
+	Grade        string  `query:"grade"`        // comma separated, e.g. HG,RG
+	Scale        string  `query:"scale"`        // comma separated
+	Series       string  `query:"series"`       // comma separated
+	Manufacturer string  `query:"manufacturer"` // comma separated
+	Category     string  `query:"category"`     // comma separated category ids
+	MinPrice     float64 `query:"min_price"`
+	MaxPrice     float64 `query:"max_price"` // 0 = no upper bound
+	InStock      bool    `query:"in_stock"`  // product or any of its variants has stock
+	Status       string  `query:"status"`    // comma separated, admins only
+	Deleted      bool    `query:"deleted"`   // list the soft deleted products instead, admins only
 	*entities.PaginationReq
 	*entities.SortReq
 }
+
+func (obj *ProductFilter) Validate() error {
+	if obj.MinPrice < 0 || obj.MaxPrice < 0 {
+		return fmt.Errorf("price range must not be negative")
+	}
+	if obj.MaxPrice > 0 && obj.MinPrice > obj.MaxPrice {
+		return fmt.Errorf("min_price must not be greater than max_price")
+	}
+	for _, status := range strings.Split(obj.Status, ",") {
+		if status = strings.Trim(status, " "); status != "" && !contains(Statuses, status) {
+			return fmt.Errorf("status must be one of %s", strings.Join(Statuses, ", "))
+		}
+	}
+	for _, id := range strings.Split(obj.Category, ",") {
+		if id = strings.Trim(id, " "); id == "" {
+			continue
+		}
+		if _, err := strconv.Atoi(id); err != nil {
+			return fmt.Errorf("category must be a comma separated list of ids")
+		}
+	}
+	return nil
+}
+
+// PriceBuckets are the price ranges counted in the search facets, Max 0 = no upper bound
+var PriceBuckets = []*PriceFacet{
+	{Min: 0, Max: 500},
+	{Min: 500, Max: 1000},
+	{Min: 1000, Max: 2000},
+	{Min: 2000, Max: 5000},
+	{Min: 5000, Max: 0},
+}
+
+// Facets summarises every product matching the current search
+type Facets struct {
+	Categories  []*CategoryFacet `json:"categories"`
+	PriceRanges []*PriceFacet    `json:"price_ranges"`
+}
+
+type CategoryFacet struct {
+	Id    int    `json:"id"`
+	Title string `json:"title"`
+	Count int    `json:"count"`
+}
+
+type PriceFacet struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int     `json:"count"`
+}
+
+// NormalizeAttributes trims the kit attributes and rejects unknown grades, scales and statuses.
+// Empty attributes are left alone so updates can skip them.
+func (obj *Products) NormalizeAttributes() error {
+	obj.Grade = strings.ToUpper(strings.Trim(obj.Grade, " "))
+	obj.Scale = strings.ToLower(strings.Trim(obj.Scale, " "))
+	obj.Series = strings.Trim(obj.Series, " ")
+	obj.Manufacturer = strings.Trim(obj.Manufacturer, " ")
+	obj.Status = strings.ToLower(strings.Trim(obj.Status, " "))
+
+	if obj.Grade != "" && !contains(Grades, obj.Grade) {
+		return fmt.Errorf("grade must be one of %s", strings.Join(Grades, ", "))
+	}
+	if obj.Scale != "" && !contains(Scales, obj.Scale) {
+		return fmt.Errorf("scale must be one of %s", strings.Join(Scales, ", "))
+	}
+	if obj.Status != "" && !contains(Statuses, obj.Status) {
+		return fmt.Errorf("status must be one of %s", strings.Join(Statuses, ", "))
+	}
+	return nil
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (obj *Variant) Validate() error {
+	obj.Sku = strings.ToUpper(strings.Trim(obj.Sku, " "))
+	if obj.Sku == "" {
+		return fmt.Errorf("sku is required")
+	}
+	if strings.Trim(obj.Title, " ") == "" {
+		return fmt.Errorf("title is required")
+	}
+	if obj.Price < 0 {
+		return fmt.Errorf("price must not be negative")
+	}
+	if obj.Stock < 0 {
+		return fmt.Errorf("stock must not be negative")
+	}
+	return nil
+}
@@ -1,13 +1,18 @@
 package productshandlers
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/Tanapoowapat/GunplaShop/config"
 	"github.com/Tanapoowapat/GunplaShop/modules/appinfo"
 	"github.com/Tanapoowapat/GunplaShop/modules/entities"
-	"github.com/Tanapoowapat/GunplaShop/modules/file"
 	filesusecase "github.com/Tanapoowapat/GunplaShop/modules/file/filesUsecase"
 	"github.com/Tanapoowapat/GunplaShop/modules/products"
 	productsusecase "github.com/Tanapoowapat/GunplaShop/modules/products/productsUsercase"
@@ -22,6 +27,20 @@ const (
 	AddProductErr    productsHandlerErr = "Products-003"
 	DeleteProductErr productsHandlerErr = "Products-004"
 	UpdateProductErr productsHandlerErr = "Products-005"
+	AddVariantErr    productsHandlerErr = "Products-006"
+	UpdateVariantErr productsHandlerErr = "Products-007"
+	DeleteVariantErr productsHandlerErr = "Products-008"
+	AvailabilityErr  productsHandlerErr = "Products-009"
+	PreorderErr      productsHandlerErr = "Products-010"
+	RestoreErr       productsHandlerErr = "Products-011"
+	ScheduleErr      productsHandlerErr = "Products-012"
+	AddPriceErr      productsHandlerErr = "Products-013"
+	DeletePriceErr   productsHandlerErr = "Products-014"
+	UpcomingErr      productsHandlerErr = "Products-015"
+	HistoryErr       productsHandlerErr = "Products-016"
+	ImportErr        productsHandlerErr = "Products-017"
+	ExportErr        productsHandlerErr = "Products-018"
+	RecommendErr     productsHandlerErr = "Products-019"
 )
 
 type IProductsHandler interface {
@@ -30,6 +49,20 @@ type IProductsHandler interface {
 	AddProducts(c *fiber.Ctx) error
 	DeleteProducts(c *fiber.Ctx) error
 	UpdateProducts(c *fiber.Ctx) error
+	AddVariant(c *fiber.Ctx) error
+	UpdateVariant(c *fiber.Ctx) error
+	DeleteVariant(c *fiber.Ctx) error
+	SetAvailability(c *fiber.Ctx) error
+	SetPreorder(c *fiber.Ctx) error
+	RestoreProducts(c *fiber.Ctx) error
+	SetSchedule(c *fiber.Ctx) error
+	AddPrice(c *fiber.Ctx) error
+	DeletePrice(c *fiber.Ctx) error
+	FindUpcomingChanges(c *fiber.Ctx) error
+	FindHistory(c *fiber.Ctx) error
+	ImportProducts(c *fiber.Ctx) error
+	ExportProducts(c *fiber.Ctx) error
+	FindRecommendations(c *fiber.Ctx) error
 }
 
 type productsHandler struct {
@@ -56,6 +89,15 @@ func (handler *productsHandler) FindOneProduct(c *fiber.Ctx) error {
 			err.Error(),
 		).Res()
 	}
+
+	// Only admins see drafts, archived and deleted products
+	if roleId, _ := c.Locals("userRoleID").(int); roleId != 2 && (product.DeletedAt != "" || product.Status != products.StatusPublished) {
+		return entities.NewResponse(c).Error(
+			fiber.ErrNotFound.Code,
+			string(FindOneErr),
+			"product not found",
+		).Res()
+	}
 	return entities.NewResponse(c).Sucess(
 		fiber.StatusOK,
 		product,
@@ -77,6 +119,12 @@ func (handler *productsHandler) FindProducts(c *fiber.Ctx) error {
 		).Res()
 	}
 
+	// Customers only see the published catalogue
+	if roleId, _ := c.Locals("userRoleID").(int); roleId != 2 {
+		req.Status = products.StatusPublished
+		req.Deleted = false
+	}
+
 	if req.Page < 1 {
 		req.Page = 1
 	}
@@ -87,12 +135,50 @@ func (handler *productsHandler) FindProducts(c *fiber.Ctx) error {
 
 	if req.OrderBy == "" {
 		req.OrderBy = "title"
+		if req.Search != "" && !req.IsCursor() {
+			req.OrderBy = "relevance"
+		}
 	}
 
 	if req.Sort == "" {
 		req.Sort = "ASC"
 	}
 
+	if req.OrderBy != "relevance" {
+		if _, err := entities.ParseSort(req.SortReq, products.SortColumns); err != nil {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(FindProductErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	if req.IsCursor() {
+		if req.OrderBy == "relevance" {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(FindProductErr),
+				"relevance sort does not support cursor pagination",
+			).Res()
+		}
+		if err := req.CheckCursor(req.SortReq); err != nil {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(FindProductErr),
+				err.Error(),
+			).Res()
+		}
+	}
+
+	if err := req.Validate(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(FindProductErr),
+			err.Error(),
+		).Res()
+	}
+
 	products := handler.prodUsecase.FindProducts(req)
 
 	return entities.NewResponse(c).Sucess(
@@ -103,8 +189,8 @@ func (handler *productsHandler) FindProducts(c *fiber.Ctx) error {
 
 func (h *productsHandler) AddProducts(c *fiber.Ctx) error {
 	req := &products.Products{
-		Category: &appinfo.Category{},
-		Images:   []*entities.Images{},
+		Categories: make([]*appinfo.Category, 0),
+		Images:     []*entities.Images{},
 	}
 
 	if err := c.BodyParser(req); err != nil {
@@ -115,14 +201,42 @@ func (h *productsHandler) AddProducts(c *fiber.Ctx) error {
 		).Res()
 	}
 
-	if req.Category.Id <= 0 {
+	if err := req.ValidateCategories(true); err != nil {
 		return entities.NewResponse(c).Error(
 			fiber.ErrBadRequest.Code,
-			string(FindOneErr),
-			"Category id Invalid",
+			string(AddProductErr),
+			err.Error(),
 		).Res()
 	}
 
+	if req.Stock < 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(AddProductErr),
+			"Stock must not be negative",
+		).Res()
+	}
+
+	if req.Weight < 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(AddProductErr),
+			"Weight must not be negative",
+		).Res()
+	}
+
+	if err := req.NormalizeAttributes(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(AddProductErr),
+			err.Error(),
+		).Res()
+	}
+	if req.Status == "" {
+		req.Status = products.StatusDraft
+	}
+	req.ChangedBy = c.Locals("userId").(string)
+
 	product, err := h.prodUsecase.AddProduct(req)
 	if err != nil {
 		return entities.NewResponse(c).Error(
@@ -136,35 +250,34 @@ func (h *productsHandler) AddProducts(c *fiber.Ctx) error {
 
 }
 
+// DeleteProducts soft deletes the product, its images are kept so it can be restored
 func (h *productsHandler) DeleteProducts(c *fiber.Ctx) error {
 	productId := strings.Trim(c.Params("product_id"), " ")
 
-	product, err := h.prodUsecase.FindOneProducts(productId)
-	if err != nil {
-		return entities.NewResponse(c).Error(fiber.ErrInternalServerError.Code, string(DeleteProductErr), err.Error()).Res()
+	if err := h.prodUsecase.DeleteProduct(productId, c.Locals("userId").(string)); err != nil {
+		return entities.NewResponse(c).Error(fiber.ErrNotFound.Code, string(DeleteProductErr), err.Error()).Res()
 	}
 
-	deleteFileReq := make([]*file.DeleteFileReq, 0)
-	for _, p := range product.Images {
-		deleteFileReq = append(deleteFileReq, &file.DeleteFileReq{
-			Destination: fmt.Sprintf("images/products/%s", p.FileName),
-		})
-	}
+	return entities.NewResponse(c).Sucess(fiber.StatusNoContent, nil).Res()
 
-	//Delete From Local
-	if err := h.fileUsecase.DeleteImageLocal(deleteFileReq); err != nil {
-		return entities.NewResponse(c).Error(fiber.ErrInternalServerError.Code, string(DeleteProductErr), err.Error()).Res()
-	}
+}
 
-	return entities.NewResponse(c).Sucess(fiber.StatusNoContent, nil).Res()
+func (h *productsHandler) RestoreProducts(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("product_id"), " ")
+
+	product, err := h.prodUsecase.RestoreProduct(productId, c.Locals("userId").(string))
+	if err != nil {
+		return entities.NewResponse(c).Error(fiber.ErrNotFound.Code, string(RestoreErr), err.Error()).Res()
+	}
 
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, product).Res()
 }
 
 func (h *productsHandler) UpdateProducts(c *fiber.Ctx) error {
 	productId := strings.Trim(c.Params("product_id"), " ")
 	req := &products.Products{
-		Images:   make([]*entities.Images, 0),
-		Category: &appinfo.Category{},
+		Images:     make([]*entities.Images, 0),
+		Categories: make([]*appinfo.Category, 0),
 	}
 	if err := c.BodyParser(req); err != nil {
 		return entities.NewResponse(c).Error(
@@ -174,6 +287,23 @@ func (h *productsHandler) UpdateProducts(c *fiber.Ctx) error {
 		).Res()
 	}
 	req.Id = productId
+	req.ChangedBy = c.Locals("userId").(string)
+
+	if err := req.ValidateCategories(false); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(UpdateProductErr),
+			err.Error(),
+		).Res()
+	}
+
+	if err := req.NormalizeAttributes(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(AddProductErr),
+			err.Error(),
+		).Res()
+	}
 
 	product, err := h.prodUsecase.UpdateProduct(req)
 	if err != nil {
@@ -186,3 +316,436 @@ func (h *productsHandler) UpdateProducts(c *fiber.Ctx) error {
 	return entities.NewResponse(c).Sucess(fiber.StatusOK, product).Res()
 
 }
+
+func (h *productsHandler) AddVariant(c *fiber.Ctx) error {
+	req := &products.Variant{
+		Images: make([]*entities.Images, 0),
+	}
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(AddVariantErr),
+			err.Error(),
+		).Res()
+	}
+	req.ProductId = strings.Trim(c.Params("product_id"), " ")
+	req.ChangedBy = c.Locals("userId").(string)
+
+	if err := req.Validate(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(AddVariantErr),
+			err.Error(),
+		).Res()
+	}
+
+	variant, err := h.prodUsecase.AddVariant(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(AddVariantErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusCreated, variant).Res()
+}
+
+func (h *productsHandler) UpdateVariant(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("product_id"), " ")
+	variantId := strings.Trim(c.Params("variant_id"), " ")
+
+	// Fields missing from the body keep their current value
+	req, err := h.prodUsecase.FindOneVariant(productId, variantId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrNotFound.Code,
+			string(UpdateVariantErr),
+			err.Error(),
+		).Res()
+	}
+	req.Images = make([]*entities.Images, 0)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(UpdateVariantErr),
+			err.Error(),
+		).Res()
+	}
+	req.Id = variantId
+	req.ProductId = productId
+	req.ChangedBy = c.Locals("userId").(string)
+
+	if err := req.Validate(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(UpdateVariantErr),
+			err.Error(),
+		).Res()
+	}
+
+	variant, err := h.prodUsecase.UpdateVariant(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(UpdateVariantErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, variant).Res()
+}
+
+func (h *productsHandler) DeleteVariant(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("product_id"), " ")
+	variantId := strings.Trim(c.Params("variant_id"), " ")
+
+	if err := h.prodUsecase.RemoveVariant(productId, variantId, c.Locals("userId").(string)); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(DeleteVariantErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(
+		fiber.StatusOK,
+		&struct {
+			VariantId string `json:"variant_id"`
+		}{
+			VariantId: variantId,
+		}).Res()
+}
+
+func (h *productsHandler) SetAvailability(c *fiber.Ctx) error {
+	req := new(products.AvailabilityReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(AvailabilityErr),
+			err.Error(),
+		).Res()
+	}
+	productId := strings.Trim(c.Params("product_id"), " ")
+
+	product, err := h.prodUsecase.SetSoldOut(productId, req.SoldOut, c.Locals("userId").(string))
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(AvailabilityErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, product).Res()
+}
+
+func (h *productsHandler) SetPreorder(c *fiber.Ctx) error {
+	req := new(products.PreorderReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(PreorderErr),
+			err.Error(),
+		).Res()
+	}
+	if err := req.Validate(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(PreorderErr),
+			err.Error(),
+		).Res()
+	}
+	productId := strings.Trim(c.Params("product_id"), " ")
+	req.ChangedBy = c.Locals("userId").(string)
+
+	product, err := h.prodUsecase.SetPreorder(productId, req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(PreorderErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, product).Res()
+}
+
+func (h *productsHandler) SetSchedule(c *fiber.Ctx) error {
+	req := new(products.ScheduleReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(ScheduleErr),
+			err.Error(),
+		).Res()
+	}
+	if err := req.Validate(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(ScheduleErr),
+			err.Error(),
+		).Res()
+	}
+	productId := strings.Trim(c.Params("product_id"), " ")
+	req.ChangedBy = c.Locals("userId").(string)
+
+	product, err := h.prodUsecase.SetSchedule(productId, req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(ScheduleErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, product).Res()
+}
+
+func (h *productsHandler) AddPrice(c *fiber.Ctx) error {
+	req := new(products.Price)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(AddPriceErr),
+			err.Error(),
+		).Res()
+	}
+	req.ProductId = strings.Trim(c.Params("product_id"), " ")
+	req.CreatedBy = c.Locals("userId").(string)
+
+	if err := req.Validate(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(AddPriceErr),
+			err.Error(),
+		).Res()
+	}
+
+	price, err := h.prodUsecase.AddPrice(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(AddPriceErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusCreated, price).Res()
+}
+
+func (h *productsHandler) DeletePrice(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("product_id"), " ")
+	priceId := strings.Trim(c.Params("price_id"), " ")
+
+	if err := h.prodUsecase.RemovePrice(productId, priceId, c.Locals("userId").(string)); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrNotFound.Code,
+			string(DeletePriceErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusNoContent, nil).Res()
+}
+
+// FindUpcomingChanges lists the scheduled changes of the next ?days=30 days
+func (h *productsHandler) FindUpcomingChanges(c *fiber.Ctx) error {
+	days := c.QueryInt("days", 30)
+	if days < 1 || days > 365 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(UpcomingErr),
+			"days must be between 1 and 365",
+		).Res()
+	}
+
+	changes, err := h.prodUsecase.FindUpcomingChanges(days)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(UpcomingErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, changes).Res()
+}
+
+func (h *productsHandler) FindHistory(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("product_id"), " ")
+
+	history, err := h.prodUsecase.FindHistory(productId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(HistoryErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, history).Res()
+}
+
+// ImportProducts reads a CSV or JSON lines file, sent as the "file" form field
+// or as the raw body, and reports on every row. Nothing is written unless
+// mode=commit.
+func (h *productsHandler) ImportProducts(c *fiber.Ctx) error {
+	req := new(products.ImportReq)
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(ImportErr),
+			err.Error(),
+		).Res()
+	}
+
+	req.Mode = strings.ToLower(strings.Trim(req.Mode, " "))
+	if req.Mode == "" {
+		req.Mode = products.ImportDryRun
+	}
+	if req.Mode != products.ImportDryRun && req.Mode != products.ImportCommit {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(ImportErr),
+			fmt.Sprintf("mode must be %s or %s", products.ImportDryRun, products.ImportCommit),
+		).Res()
+	}
+
+	var body io.Reader = bytes.NewReader(c.Body())
+	if file, err := c.FormFile("file"); err == nil {
+		if req.Format == "" {
+			req.Format = strings.TrimPrefix(filepath.Ext(file.Filename), ".")
+		}
+		f, err := file.Open()
+		if err != nil {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(ImportErr),
+				err.Error(),
+			).Res()
+		}
+		defer f.Close()
+		body = f
+	}
+	req.Format = strings.ToLower(req.Format)
+
+	lines, err := products.ReadImport(req.Format, body)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(ImportErr),
+			err.Error(),
+		).Res()
+	}
+
+	res, err := h.prodUsecase.ImportProducts(req, lines, c.Locals("userId").(string))
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(ImportErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, res).Res()
+}
+
+// ExportProducts sends every product matching the admin filters in the
+// import format, so the file can be edited and imported back
+func (h *productsHandler) ExportProducts(c *fiber.Ctx) error {
+	req := &products.ProductFilter{}
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(ExportErr),
+			err.Error(),
+		).Res()
+	}
+	if err := req.Validate(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(ExportErr),
+			err.Error(),
+		).Res()
+	}
+
+	format := strings.ToLower(c.Query("format", products.FormatCSV))
+	contentType := map[string]string{
+		products.FormatCSV:   "text/csv; charset=utf-8",
+		products.FormatJSONL: "application/x-ndjson",
+	}[format]
+	if contentType == "" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(ExportErr),
+			fmt.Sprintf("format must be %s or %s", products.FormatCSV, products.FormatJSONL),
+		).Res()
+	}
+
+	// The file is built before anything is sent, so a failed export is an error response
+	// rather than a cut short file
+	file, size, err := h.buildExport(req, format)
+	if err != nil {
+		log.Printf("export products failed: %v\n", err)
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(ExportErr),
+			"export products failed",
+		).Res()
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="products.%s"`, format))
+	return c.SendStream(file, int(size))
+}
+
+// buildExport writes the export to an unlinked temporary file and returns it
+// rewound, the file is closed once the response has been sent
+func (h *productsHandler) buildExport(req *products.ProductFilter, format string) (*os.File, int64, error) {
+	file, err := os.CreateTemp("", "products-export-*")
+	if err != nil {
+		return nil, 0, err
+	}
+	os.Remove(file.Name())
+
+	w := bufio.NewWriter(file)
+	writer, err := products.NewTransferWriter(format, w)
+	if err == nil {
+		err = h.prodUsecase.ExportProducts(req, func(product *products.Products) error {
+			return writer.Write(products.NewTransferRow(product))
+		})
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	var size int64
+	if err == nil {
+		size, err = file.Seek(0, io.SeekCurrent)
+	}
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	return file, size, nil
+}
+
+// FindRecommendations lists the related and frequently bought together
+// products of a product customers can see
+func (h *productsHandler) FindRecommendations(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("product_id"), " ")
+
+	product, err := h.prodUsecase.FindOneProducts(productId)
+	if err != nil || product.DeletedAt != "" || product.Status != products.StatusPublished {
+		return entities.NewResponse(c).Error(
+			fiber.ErrNotFound.Code,
+			string(RecommendErr),
+			"product not found",
+		).Res()
+	}
+
+	recommendations, err := h.prodUsecase.FindRecommendations(productId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(RecommendErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, recommendations).Res()
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Tanapoowapat/GunplaShop/modules/entities"
 	"github.com/Tanapoowapat/GunplaShop/modules/products"
 	"github.com/Tanapoowapat/GunplaShop/pkg/utils"
 	"github.com/jmoiron/sqlx"
@@ -18,29 +19,49 @@ type IFindProductBuilder interface {
 	openJsonQuery()
 	initQuery()
 	countQuery()
+	openFacetQuery()
 	whereQuery()
+	cursorQuery()
 	sort()
 	paginate()
 	resetQuery()
 	closeJsonQuery()
+	closeFacetQuery()
 	Result() []*products.Products
+	Rows() ([]*products.Products, error)
 	Count() int
+	Facets() *products.Facets
 	PrintQuery()
 }
 
 type findProductBuilder struct {
 	db             *sqlx.DB
 	req            *products.ProductFilter
+	cursor         *entities.Cursor
 	query          string
 	lastStackIndex int
 	values         []any
 }
 
 func NewFindProductBuilder(db *sqlx.DB, req *products.ProductFilter) IFindProductBuilder {
-	return &findProductBuilder{
+	b := &findProductBuilder{
 		db:  db,
 		req: req,
 	}
+	// The handler has already rejected an invalid cursor
+	if req.Cursor != "" {
+		b.cursor, _ = entities.DecodeCursor(req.Cursor)
+	}
+	return b
+}
+
+// sortTerms falls back to the title when order_by was not validated
+func (b *findProductBuilder) sortTerms() []*entities.SortTerm {
+	terms, err := entities.ParseSort(b.req.SortReq, products.SortColumns)
+	if err != nil {
+		terms, _ = entities.ParseSort(&entities.SortReq{OrderBy: "title", Sort: b.req.Sort}, products.SortColumns)
+	}
+	return terms
 }
 
 func (b *findProductBuilder) openJsonQuery() {
@@ -50,24 +71,52 @@ func (b *findProductBuilder) openJsonQuery() {
 	FROM (`
 }
 func (b *findProductBuilder) initQuery() {
+	// Highlight the search terms in the description
+	snippet := `''`
+	if b.req.Search != "" {
+		b.values = append(b.values, b.req.Search)
+		snippet = fmt.Sprintf(`ts_headline('english', "p"."description", websearch_to_tsquery('english', $%d), 'StartSel=<b>, StopSel=</b>, MaxFragments=2, MaxWords=20, MinWords=5')`, len(b.values))
+	}
+
 	b.query += `
 		SELECT
 			"p"."id",
+			` + snippet + ` AS "snippet",
 			"p"."title",
 			"p"."description",
-			"p"."price",
+			` + products.EffectivePrice + ` AS "price",
+			"p"."price" AS "base_price",
+			` + products.LowestPrice + ` AS "lowest_price_30d",
+			"p"."stock",
+			"p"."weight",
+			` + products.EffectiveStatus + ` AS "status",
+			COALESCE("p"."publish_at"::TEXT, '') AS "publish_at",
+			COALESCE("p"."unpublish_at"::TEXT, '') AS "unpublish_at",
+			COALESCE("p"."deleted_at"::TEXT, '') AS "deleted_at",
+			"p"."sold_out",
+			"p"."preorder",
+			COALESCE("p"."release_date"::TEXT, '') AS "release_date",
+			"p"."deposit",
+			"p"."grade",
+			"p"."scale",
+			"p"."series",
+			"p"."manufacturer",
+			"p"."rating_avg" AS "rating",
+			"p"."review_count",
 			(
 				SELECT
-					to_jsonb("ct")
+					COALESCE(array_to_json(array_agg("ct")), '[]'::json)
 				FROM (
 					SELECT
 						"c"."id",
-						"c"."title"
+						"c"."title",
+						"c"."parent_id"
 					FROM "categories" "c"
-						LEFT JOIN "products_categories" "pc" ON "pc"."category_id" = "c"."id"
+						JOIN "products_categories" "pc" ON "pc"."category_id" = "c"."id"
 					WHERE "pc"."product_id" = "p"."id"
+					ORDER BY "c"."id" ASC
 				) AS "ct"
-			) AS "category",
+			) AS "categories",
 			"p"."created_at",
 			"p"."updated_at",
 			(
@@ -79,9 +128,39 @@ func (b *findProductBuilder) initQuery() {
 						"i"."filename",
 						"i"."url"
 					FROM "images" "i"
-					WHERE "i"."product_id" = "p"."id"
+					WHERE "i"."product_id" = "p"."id" AND "i"."variant_id" IS NULL
 				) AS "it"
-			) AS "images"
+			) AS "media",
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg("vt")), '[]'::json)
+				FROM (
+					SELECT
+						"v"."id",
+						"v"."product_id",
+						"v"."sku",
+						"v"."title",
+						"v"."price",
+						"v"."stock",
+						(
+							SELECT
+								COALESCE(array_to_json(array_agg("vit")), '[]'::json)
+							FROM (
+								SELECT
+									"vi"."id",
+									"vi"."filename",
+									"vi"."url"
+								FROM "images" "vi"
+								WHERE "vi"."variant_id" = "v"."id"
+							) AS "vit"
+						) AS "media",
+						"v"."created_at",
+						"v"."updated_at"
+					FROM "product_variants" "v"
+					WHERE "v"."product_id" = "p"."id"
+					ORDER BY "v"."created_at" ASC
+				) AS "vt"
+			) AS "variants"
 		FROM "products" "p"
 		WHERE 1 = 1`
 }
@@ -95,9 +174,61 @@ func (b *findProductBuilder) countQuery() {
 	`
 }
 
+// The facets are counted over the products matching the same where clause as the search
+func (b *findProductBuilder) openFacetQuery() {
+	b.query += `
+	WITH "fp" AS (
+		SELECT
+			"p"."id",
+			` + products.EffectivePrice + ` AS "price"
+		FROM "products" "p"
+		WHERE 1 = 1`
+}
+
+func (b *findProductBuilder) closeFacetQuery() {
+	buckets := make([]string, 0, len(products.PriceBuckets))
+	for _, bucket := range products.PriceBuckets {
+		buckets = append(buckets, fmt.Sprintf("(%g::FLOAT, %g::FLOAT)", bucket.Min, bucket.Max))
+	}
+
+	b.query += fmt.Sprintf(`
+	)
+	SELECT
+		jsonb_build_object(
+			'categories', (
+				SELECT
+					COALESCE(jsonb_agg("ct" ORDER BY "ct"."title"), '[]'::jsonb)
+				FROM (
+					SELECT
+						"c"."id",
+						"c"."title",
+						COUNT(*) AS "count"
+					FROM "fp"
+						JOIN "products_categories" "pc" ON "pc"."product_id" = "fp"."id"
+						JOIN "categories" "c" ON "c"."id" = "pc"."category_id"
+					GROUP BY "c"."id", "c"."title"
+				) AS "ct"
+			),
+			'price_ranges', (
+				SELECT
+					jsonb_agg("bt" ORDER BY "bt"."min")
+				FROM (
+					SELECT
+						"b"."min",
+						"b"."max",
+						COUNT("fp"."id") AS "count"
+					FROM (VALUES %s) AS "b" ("min", "max")
+						LEFT JOIN "fp" ON "fp"."price" >= "b"."min" AND ("b"."max" = 0 OR "fp"."price" < "b"."max")
+					GROUP BY "b"."min", "b"."max"
+				) AS "bt"
+			)
+		);`, strings.Join(buckets, ", "))
+}
+
 func (b *findProductBuilder) whereQuery() {
 	var queryWhere string
 	queryWhereStack := make([]string, 0)
+	placeholder := len(b.values)
 
 	// Id check
 	if b.req.Id != "" {
@@ -107,25 +238,102 @@ func (b *findProductBuilder) whereQuery() {
 		AND "p"."id" = ?`)
 	}
 
-	// Search check
+	// Lifecycle check, soft deleted products are only listed on request
+	if b.req.Deleted {
+		queryWhereStack = append(queryWhereStack, `
+		AND "p"."deleted_at" IS NOT NULL`)
+	} else {
+		queryWhereStack = append(queryWhereStack, `
+		AND "p"."deleted_at" IS NULL`)
+	}
+	if statuses := splitList(b.req.Status); len(statuses) > 0 {
+		b.values = append(b.values, statuses)
+
+		queryWhereStack = append(queryWhereStack, `
+		AND `+products.EffectiveStatus+` = ANY(?)`)
+	}
+
+	// Search check, uses the GIN index on search_vector
 	if b.req.Search != "" {
-		b.values = append(
-			b.values,
-			"%"+strings.ToLower(b.req.Search)+"%",
-			"%"+strings.ToLower(b.req.Search)+"%",
-		)
+		b.values = append(b.values, b.req.Search)
+
+		queryWhereStack = append(queryWhereStack, `
+		AND "p"."search_vector" @@ websearch_to_tsquery('english', ?)`)
+	}
+
+	// Attribute checks, each accepts a comma separated list
+	attributes := []struct {
+		column string
+		value  string
+	}{
+		{"grade", strings.ToUpper(b.req.Grade)},
+		{"scale", strings.ToLower(b.req.Scale)},
+		{"series", b.req.Series},
+		{"manufacturer", b.req.Manufacturer},
+	}
+	for _, attr := range attributes {
+		if attr.value == "" {
+			continue
+		}
+		b.values = append(b.values, splitList(attr.value))
+
+		queryWhereStack = append(queryWhereStack, fmt.Sprintf(`
+		AND "p"."%s" = ANY(?)`, attr.column))
+	}
+
+	// Category check, a product matches any of the given categories or the categories below them
+	if categories := splitList(b.req.Category); len(categories) > 0 {
+		categoryIds := make([]int, 0, len(categories))
+		for _, c := range categories {
+			if id, err := strconv.Atoi(c); err == nil {
+				categoryIds = append(categoryIds, id)
+			}
+		}
+		b.values = append(b.values, categoryIds)
+
+		queryWhereStack = append(queryWhereStack, `
+		AND EXISTS (
+			SELECT 1
+			FROM "products_categories" "pc"
+			WHERE "pc"."product_id" = "p"."id" AND "pc"."category_id" IN (SELECT category_descendants(?))
+		)`)
+	}
 
+	// Price range check
+	if b.req.MinPrice > 0 {
+		b.values = append(b.values, b.req.MinPrice)
+
+		queryWhereStack = append(queryWhereStack, `
+		AND `+products.EffectivePrice+` >= ?`)
+	}
+	if b.req.MaxPrice > 0 {
+		b.values = append(b.values, b.req.MaxPrice)
+
+		queryWhereStack = append(queryWhereStack, `
+		AND `+products.EffectivePrice+` <= ?`)
+	}
+
+	// In stock check
+	if b.req.InStock {
 		queryWhereStack = append(queryWhereStack, `
-		AND (LOWER("p"."title") LIKE ? OR LOWER("p"."description") LIKE ?)`)
+		AND NOT "p"."sold_out"
+		AND (
+			"p"."stock" > 0
+			OR EXISTS (
+				SELECT 1
+				FROM "product_variants" "v"
+				WHERE "v"."product_id" = "p"."id" AND "v"."stock" > 0
+			)
+		)`)
 	}
 
+	// Number the placeholders in the order the values were appended
 	for i := range queryWhereStack {
-		if i != len(queryWhereStack)-1 {
-			queryWhere += strings.Replace(queryWhereStack[i], "?", "$"+strconv.Itoa(i+1), 1)
-		} else {
-			queryWhere += strings.Replace(queryWhereStack[i], "?", "$"+strconv.Itoa(i+1), 1)
-			queryWhere = strings.Replace(queryWhere, "?", "$"+strconv.Itoa(i+2), 1)
+		for strings.Contains(queryWhereStack[i], "?") {
+			placeholder++
+			queryWhereStack[i] = strings.Replace(queryWhereStack[i], "?", "$"+strconv.Itoa(placeholder), 1)
 		}
+		queryWhere += queryWhereStack[i]
 	}
 	// Last stack record
 	b.lastStackIndex = len(b.values)
@@ -134,35 +342,56 @@ func (b *findProductBuilder) whereQuery() {
 	b.query += queryWhere
 }
 
-func (b *findProductBuilder) sort() {
-	orderByMap := map[string]string{
-		"id":    "\"p\".\"id\"",
-		"title": "\"p\".\"title\"",
-		"price": "\"p\".\"price\"",
-	}
-	if orderByMap[b.req.OrderBy] == "" {
-		b.req.OrderBy = orderByMap["title"]
-	} else {
-		b.req.OrderBy = orderByMap[b.req.OrderBy]
+func splitList(value string) []string {
+	list := make([]string, 0)
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.Trim(v, " "); v != "" {
+			list = append(list, v)
+		}
 	}
+	return list
+}
 
-	sortMap := map[string]string{
-		"DESC": "DESC",
-		"ASC":  "ASC",
-	}
-	if sortMap[b.req.Sort] == "" {
-		b.req.Sort = sortMap["ASC"]
-	} else {
-		b.req.Sort = sortMap[strings.ToUpper(b.req.Sort)]
+// cursorQuery keeps the rows after the cursor row in the sort order, or before it when paging backward
+func (b *findProductBuilder) cursorQuery() {
+	terms := b.sortTerms()
+	if b.cursor == nil || len(b.cursor.Values) != len(terms) {
+		return
 	}
 
-	b.values = append(b.values, b.req.OrderBy)
-	b.query += fmt.Sprintf(`
-		ORDER BY $%d %s`, b.lastStackIndex+1, b.req.Sort)
+	condition := entities.KeysetCondition(terms, products.SortColumns["id"], b.cursor.Backward, b.lastStackIndex)
+	b.values = append(b.values, b.cursor.Values...)
+	b.values = append(b.values, b.cursor.Id)
+	b.query += `
+		AND ` + condition
 	b.lastStackIndex = len(b.values)
 }
 
+func (b *findProductBuilder) sort() {
+	if b.req.OrderBy == "relevance" && b.req.Search != "" {
+		b.values = append(b.values, b.req.Search)
+		b.query += fmt.Sprintf(`
+		ORDER BY ts_rank("p"."search_vector", websearch_to_tsquery('english', $%d)) DESC, "p"."title" ASC`, len(b.values))
+		b.lastStackIndex = len(b.values)
+		return
+	}
+
+	// A backward page is read in reverse then flipped back in Result
+	backward := b.cursor != nil && b.cursor.Backward
+	b.query += `
+		` + entities.OrderByClause(b.sortTerms(), products.SortColumns["id"].Expr, backward)
+}
+
 func (b *findProductBuilder) paginate() {
+	// One extra row tells whether there is another page
+	if b.req.IsCursor() {
+		b.values = append(b.values, b.req.Limit+1)
+
+		b.query += fmt.Sprintf(`	LIMIT $%d`, b.lastStackIndex+1)
+		b.lastStackIndex = len(b.values)
+		return
+	}
+
 	// offset (page - 1)*limit
 	b.values = append(b.values, (b.req.Page-1)*b.req.Limit, b.req.Limit)
 
@@ -183,23 +412,36 @@ func (b *findProductBuilder) closeJsonQuery() {
 }
 
 func (b *findProductBuilder) Result() []*products.Products {
+	productsData, err := b.Rows()
+	if err != nil {
+		log.Printf("%v\n", err)
+		return make([]*products.Products, 0)
+	}
+	return productsData
+}
+
+// Rows is Result for the callers that must not mistake a failed query for an empty page
+func (b *findProductBuilder) Rows() ([]*products.Products, error) {
 	_, cancel := context.WithTimeout(context.Background(), time.Second*15)
 	defer cancel()
+	defer b.resetQuery()
 
 	bytes := make([]byte, 0)
 	productsData := make([]*products.Products, 0)
 
 	if err := b.db.Get(&bytes, b.query, b.values...); err != nil {
-		log.Printf("find producuts fail: %v\n", err)
-		return make([]*products.Products, 0)
+		return nil, fmt.Errorf("find producuts fail: %v", err)
 	}
 
 	if err := json.Unmarshal(bytes, &productsData); err != nil {
-		log.Printf("unmarshal producuts fail: %v\n", err)
-		return make([]*products.Products, 0)
+		return nil, fmt.Errorf("unmarshal producuts fail: %v", err)
 	}
-	b.resetQuery()
-	return productsData
+	if b.cursor != nil && b.cursor.Backward {
+		for i, j := 0, len(productsData)-1; i < j; i, j = i+1, j-1 {
+			productsData[i], productsData[j] = productsData[j], productsData[i]
+		}
+	}
+	return productsData, nil
 }
 
 func (b *findProductBuilder) Count() int {
@@ -214,6 +456,28 @@ func (b *findProductBuilder) Count() int {
 	b.resetQuery()
 	return count
 }
+func (b *findProductBuilder) Facets() *products.Facets {
+	_, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	bytes := make([]byte, 0)
+	facets := &products.Facets{
+		Categories:  make([]*products.CategoryFacet, 0),
+		PriceRanges: make([]*products.PriceFacet, 0),
+	}
+
+	if err := b.db.Get(&bytes, b.query, b.values...); err != nil {
+		log.Printf("find producuts facets fail: %v\n", err)
+		return facets
+	}
+
+	if err := json.Unmarshal(bytes, facets); err != nil {
+		log.Printf("unmarshal producuts facets fail: %v\n", err)
+	}
+	b.resetQuery()
+	return facets
+}
+
 func (b *findProductBuilder) PrintQuery() {
 	utils.Debug(b.values)
 	fmt.Println(b.query)
@@ -231,6 +495,7 @@ func (en *findProductEngineer) FindProduct() IFindProductBuilder {
 	en.builder.openJsonQuery()
 	en.builder.initQuery()
 	en.builder.whereQuery()
+	en.builder.cursorQuery()
 	en.builder.sort()
 	en.builder.paginate()
 	en.builder.closeJsonQuery()
@@ -242,3 +507,10 @@ func (en *findProductEngineer) CountProduct() IFindProductBuilder {
 	en.builder.whereQuery()
 	return en.builder
 }
+
+func (en *findProductEngineer) FacetProduct() IFindProductBuilder {
+	en.builder.openFacetQuery()
+	en.builder.whereQuery()
+	en.builder.closeFacetQuery()
+	return en.builder
+}
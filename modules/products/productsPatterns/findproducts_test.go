@@ -0,0 +1,102 @@
+package productspatterns
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/entities"
+	"github.com/Tanapoowapat/GunplaShop/modules/products"
+)
+
+func TestFindProductSort(t *testing.T) {
+	tests := []struct {
+		orderBy string
+		sort    string
+		search  string
+		want    string
+	}{
+		{"id", "ASC", "", `ORDER BY "p"."id" ASC`},
+		{"title", "ASC", "", `ORDER BY "p"."title" ASC, "p"."id" ASC`},
+		{"price", "ASC", "", `ORDER BY product_effective_price("p"."id", "p"."price") ASC, "p"."id" ASC`},
+		{"price", "DESC", "", `ORDER BY product_effective_price("p"."id", "p"."price") DESC, "p"."id" DESC`},
+		{"created_at", "DESC", "", `ORDER BY "p"."created_at" DESC, "p"."id" DESC`},
+		{"rating", "DESC", "", `ORDER BY "p"."rating_avg" DESC, "p"."id" DESC`},
+		{"-review_count,rating", "ASC", "", `ORDER BY "p"."review_count" DESC, "p"."rating_avg" ASC, "p"."id" DESC`},
+		{"price,-created_at", "ASC", "", `ORDER BY product_effective_price("p"."id", "p"."price") ASC, "p"."created_at" DESC, "p"."id" ASC`},
+		{"unknown", "ASC", "", `ORDER BY "p"."title" ASC, "p"."id" ASC`},
+		{"relevance", "ASC", "zaku", `ORDER BY ts_rank("p"."search_vector", websearch_to_tsquery('english', $1)) DESC, "p"."title" ASC`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.orderBy+" "+tt.sort, func(t *testing.T) {
+			b := NewFindProductBuilder(nil, &products.ProductFilter{
+				Search:        tt.search,
+				PaginationReq: &entities.PaginationReq{Page: 1, Limit: 5},
+				SortReq:       &entities.SortReq{OrderBy: tt.orderBy, Sort: tt.sort},
+			}).(*findProductBuilder)
+			b.sort()
+
+			if !strings.HasSuffix(b.query, tt.want) {
+				t.Errorf("sort() = %s, want %s", b.query, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindProductSortBackwardCursor(t *testing.T) {
+	req := &products.ProductFilter{
+		PaginationReq: &entities.PaginationReq{Page: 1, Limit: 5},
+		SortReq:       &entities.SortReq{OrderBy: "price", Sort: "ASC"},
+	}
+	req.Cursor = entities.EncodeCursor(&entities.Cursor{OrderBy: "price", Sort: "ASC", Values: []any{100.0}, Id: "P000010", Backward: true})
+
+	b := NewFindProductBuilder(nil, req).(*findProductBuilder)
+	b.cursorQuery()
+	b.sort()
+
+	for _, want := range []string{
+		`((product_effective_price("p"."id", "p"."price") < $1::FLOAT) OR (product_effective_price("p"."id", "p"."price") = $1::FLOAT AND "p"."id" < $2::VARCHAR))`,
+		`ORDER BY product_effective_price("p"."id", "p"."price") DESC, "p"."id" DESC`,
+	} {
+		if !strings.Contains(b.query, want) {
+			t.Errorf("query = %s, want %s", b.query, want)
+		}
+	}
+	if len(b.values) != 2 || b.values[1] != "P000010" {
+		t.Errorf("values = %v", b.values)
+	}
+}
+
+func TestFindProductBinding(t *testing.T) {
+	req := &products.ProductFilter{
+		Search:        "zaku",
+		Grade:         "hg,mg",
+		MinPrice:      500,
+		PaginationReq: &entities.PaginationReq{Page: 1, Limit: 5},
+		SortReq:       &entities.SortReq{OrderBy: "-review_count", Sort: "ASC"},
+	}
+	req.Cursor = entities.EncodeCursor(&entities.Cursor{OrderBy: "-review_count", Sort: "ASC", Values: []any{12.0}, Id: "P000010"})
+
+	b := NewFindProductEngineer(NewFindProductBuilder(nil, req)).FindProduct().(*findProductBuilder)
+
+	want := []any{"zaku", "zaku", []string{"HG", "MG"}, 500.0, 12.0, "P000010", 6}
+	if !reflect.DeepEqual(b.values, want) {
+		t.Errorf("values = %#v, want %#v", b.values, want)
+	}
+	for _, part := range []string{
+		`websearch_to_tsquery('english', $1)`,
+		`AND "p"."search_vector" @@ websearch_to_tsquery('english', $2)`,
+		`AND "p"."grade" = ANY($3)`,
+		`AND ` + products.EffectivePrice + ` >= $4`,
+		`(("p"."review_count" < $5::INT) OR ("p"."review_count" = $5::INT AND "p"."id" < $6::VARCHAR))`,
+		`ORDER BY "p"."review_count" DESC, "p"."id" DESC`,
+		`LIMIT $7`,
+	} {
+		if !strings.Contains(b.query, part) {
+			t.Errorf("query = %s, want %s", b.query, part)
+		}
+	}
+	if strings.Contains(b.query, "$8") {
+		t.Errorf("query = %s, binds more placeholders than values", b.query)
+	}
+}
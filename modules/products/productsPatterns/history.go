@@ -0,0 +1,100 @@
+package productspatterns
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// HistoryFields are the product columns whose changes are recorded,
+// the stock is left out as the inventory keeps its own movements
+var HistoryFields = []string{
+	"title",
+	"description",
+	"price",
+	"weight",
+	"category",
+	"grade",
+	"scale",
+	"series",
+	"manufacturer",
+	"status",
+	"sold_out",
+	"preorder",
+	"release_date",
+	"deposit",
+	"publish_at",
+	"unpublish_at",
+	"deleted_at",
+	"sale_price", // a scheduled price added or removed, recorded by the repository
+	"variant",    // a variant added, changed or removed, recorded by the repository
+}
+
+// Snapshot reads the HistoryFields of a product as text and locks its row
+// until tx ends. A missing product gives an empty snapshot.
+func Snapshot(ctx context.Context, tx *sqlx.Tx, productId string) (map[string]string, error) {
+	query := `
+	SELECT
+		to_jsonb("p") || jsonb_build_object(
+			'category', (
+				SELECT
+					string_agg("pc"."category_id"::TEXT, ',' ORDER BY "pc"."category_id")
+				FROM "products_categories" "pc"
+				WHERE "pc"."product_id" = "p"."id"
+			)
+		)
+	FROM "products" "p"
+	WHERE "p"."id" = $1
+	FOR UPDATE;`
+
+	snapshot := make(map[string]string)
+
+	raw := make([]byte, 0)
+	if err := tx.GetContext(ctx, &raw, query, productId); errors.Is(err, sql.ErrNoRows) {
+		return snapshot, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("get product snapshot failed: %v", err)
+	}
+	row := make(map[string]any)
+	if err := json.Unmarshal(raw, &row); err != nil {
+		return nil, fmt.Errorf("unmarshal product snapshot failed: %v", err)
+	}
+	for _, field := range HistoryFields {
+		switch value := row[field].(type) {
+		case nil:
+		case float64:
+			snapshot[field] = strconv.FormatFloat(value, 'f', -1, 64)
+		default:
+			snapshot[field] = fmt.Sprint(value)
+		}
+	}
+	return snapshot, nil
+}
+
+// RecordHistory stores a row for every field that differs between the snapshots
+func RecordHistory(ctx context.Context, tx *sqlx.Tx, productId, changedBy string, before, after map[string]string) error {
+	query := `
+	INSERT INTO "product_history" (
+		"product_id",
+		"field",
+		"old_value",
+		"new_value",
+		"changed_by"
+	)
+	VALUES ($1, $2, $3, $4, NULLIF($5, ''));`
+
+	for _, field := range HistoryFields {
+		if before[field] == after[field] {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, query, productId, field, before[field], after[field], changedBy); err != nil {
+			return fmt.Errorf("insert product history failed: %v", err)
+		}
+	}
+	return nil
+}
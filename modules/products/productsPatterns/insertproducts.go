@@ -14,6 +14,7 @@ type IInsertProductBuilder interface {
 	insertProduct() error
 	insertCategory() error
 	insertAttachment() error
+	insertHistory() error
 	commit() error
 	getProductId() string
 }
@@ -56,9 +57,16 @@ func (b *insertProductBuilder) insertProduct() error {
 	INSERT INTO "products" (
 		"title",
 		"description",
-		"price"
+		"price",
+		"stock",
+		"weight",
+		"grade",
+		"scale",
+		"series",
+		"manufacturer",
+		"status"
 	)
-	VALUES ($1, $2, $3)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING "id";`
 
 	if err := b.tx.QueryRowxContext(
@@ -67,6 +75,13 @@ func (b *insertProductBuilder) insertProduct() error {
 		b.req.Title,
 		b.req.Description,
 		b.req.Price,
+		b.req.Stock,
+		b.req.Weight,
+		b.req.Grade,
+		b.req.Scale,
+		b.req.Series,
+		b.req.Manufacturer,
+		b.req.Status,
 	).Scan(&b.req.Id); err != nil {
 		b.tx.Rollback()
 		return fmt.Errorf("insert product failed: %v", err)
@@ -78,26 +93,34 @@ func (b *insertProductBuilder) insertCategory() error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
 	defer cancel()
 
+	if err := insertCategories(ctx, b.tx, b.req.Id, b.req.CategoryIds()); err != nil {
+		b.tx.Rollback()
+		return err
+	}
+	return nil
+}
+
+// insertCategories puts the product in every category of categoryIds
+func insertCategories(ctx context.Context, tx *sqlx.Tx, productId string, categoryIds []int) error {
 	query := `
 	INSERT INTO "products_categories" (
 		"product_id",
 		"category_id"
 	)
-	VALUES ($1, $2);`
+	SELECT $1, unnest($2::INT[])
+	ON CONFLICT DO NOTHING;`
 
-	if _, err := b.tx.ExecContext(
-		ctx,
-		query,
-		b.req.Id,
-		b.req.Category.Id,
-	); err != nil {
-		b.tx.Rollback()
+	if _, err := tx.ExecContext(ctx, query, productId, categoryIds); err != nil {
 		return fmt.Errorf("insert products_categories failed: %v", err)
 	}
 	return nil
 }
 
 func (b *insertProductBuilder) insertAttachment() error {
+	if len(b.req.Images) == 0 {
+		return nil
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
 	defer cancel()
 
@@ -139,6 +162,22 @@ func (b *insertProductBuilder) insertAttachment() error {
 	return nil
 }
 
+// insertHistory records every field of the new product as changed from empty
+func (b *insertProductBuilder) insertHistory() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*15)
+	defer cancel()
+
+	after, err := Snapshot(ctx, b.tx, b.req.Id)
+	if err == nil {
+		err = RecordHistory(ctx, b.tx, b.req.Id, b.req.ChangedBy, map[string]string{}, after)
+	}
+	if err != nil {
+		b.tx.Rollback()
+		return err
+	}
+	return nil
+}
+
 func (b *insertProductBuilder) commit() error {
 	if err := b.tx.Commit(); err != nil {
 		return err
@@ -162,6 +201,9 @@ func (en *insertProductEngineer) InsertProduct() (string, error) {
 	if err := en.builder.insertAttachment(); err != nil {
 		return "", err
 	}
+	if err := en.builder.insertHistory(); err != nil {
+		return "", err
+	}
 	if err := en.builder.commit(); err != nil {
 		return "", err
 	}
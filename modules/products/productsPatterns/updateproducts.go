@@ -13,16 +13,20 @@ import (
 
 type IUpdateProductBuilder interface {
 	initTransaction() error
+	snapshot() error
 	initQuery()
 	updateTitleQuery()
 	updateDescriptionQuery()
 	updatePriceQuery()
+	updateWeightQuery()
+	updateAttributesQuery()
 	updateCategory() error
 	insertImages() error
 	getOldImages() []*entities.Images
 	deleteOldImages() error
 	closeQuery()
 	updateProduct() error
+	insertHistory() error
 	getQueryFields() []string
 	getValues() []any
 	getQuery() string
@@ -40,6 +44,7 @@ type updateProductBuilder struct {
 	queryFields    []string
 	lastStackIndex int
 	values         []any
+	before         map[string]string
 }
 
 func NewUpdateProductBuilder(db *sqlx.DB, req *products.Products, filesUsecases filesusecase.IFileUsecase) IUpdateProductBuilder {
@@ -68,6 +73,17 @@ func (b *updateProductBuilder) initTransaction() error {
 	b.tx = tx
 	return nil
 }
+
+// snapshot keeps the product as it was before the update and locks it
+func (b *updateProductBuilder) snapshot() error {
+	before, err := Snapshot(context.Background(), b.tx, b.req.Id)
+	if err != nil {
+		b.tx.Rollback()
+		return err
+	}
+	b.before = before
+	return nil
+}
 func (b *updateProductBuilder) initQuery() {
 	b.query += `
 	UPDATE "products" SET`
@@ -99,28 +115,58 @@ func (b *updateProductBuilder) updatePriceQuery() {
 		"price" = $%d`, b.lastStackIndex))
 	}
 }
-func (b *updateProductBuilder) updateCategory() error {
-	if b.req.Category == nil {
-		return nil
+func (b *updateProductBuilder) updateWeightQuery() {
+	if b.req.Weight != 0 {
+		b.values = append(b.values, b.req.Weight)
+		b.lastStackIndex = len(b.values)
+
+		b.queryFields = append(b.queryFields, fmt.Sprintf(`
+		"weight" = $%d`, b.lastStackIndex))
 	}
-	if b.req.Category.Id == 0 {
+}
+func (b *updateProductBuilder) updateAttributesQuery() {
+	attributes := []struct {
+		column string
+		value  string
+	}{
+		{"grade", b.req.Grade},
+		{"scale", b.req.Scale},
+		{"series", b.req.Series},
+		{"manufacturer", b.req.Manufacturer},
+		{"status", b.req.Status},
+	}
+	for _, attr := range attributes {
+		if attr.value == "" {
+			continue
+		}
+		b.values = append(b.values, attr.value)
+		b.lastStackIndex = len(b.values)
+
+		b.queryFields = append(b.queryFields, fmt.Sprintf(`
+		"%s" = $%d`, attr.column, b.lastStackIndex))
+	}
+}
+
+// updateCategory replaces the categories of the product when the request has any
+func (b *updateProductBuilder) updateCategory() error {
+	categoryIds := b.req.CategoryIds()
+	if len(categoryIds) == 0 {
 		return nil
 	}
 
 	query := `
-	UPDATE "products_categories" SET
-		"category_id" = $1
-	WHERE "product_id" = $2;`
+	DELETE FROM "products_categories"
+	WHERE "product_id" = $1 AND NOT ("category_id" = ANY($2::INT[]));`
 
-	if _, err := b.tx.ExecContext(
-		context.Background(),
-		query,
-		b.req.Category.Id,
-		b.req.Id,
-	); err != nil {
+	ctx := context.Background()
+	if _, err := b.tx.ExecContext(ctx, query, b.req.Id, categoryIds); err != nil {
 		b.tx.Rollback()
 		return fmt.Errorf("update products_categories failed: %v", err)
 	}
+	if err := insertCategories(ctx, b.tx, b.req.Id, categoryIds); err != nil {
+		b.tx.Rollback()
+		return err
+	}
 	return nil
 }
 func (b *updateProductBuilder) insertImages() error {
@@ -168,7 +214,7 @@ func (b *updateProductBuilder) getOldImages() []*entities.Images {
 		"filename",
 		"url"
 	FROM "images"
-	WHERE "product_id" = $1;`
+	WHERE "product_id" = $1 AND "variant_id" IS NULL;`
 
 	images := make([]*entities.Images, 0)
 	if err := b.db.Select(
@@ -183,7 +229,7 @@ func (b *updateProductBuilder) getOldImages() []*entities.Images {
 func (b *updateProductBuilder) deleteOldImages() error {
 	query := `
 	DELETE FROM "images"
-	WHERE "product_id" = $1;`
+	WHERE "product_id" = $1 AND "variant_id" IS NULL;`
 
 	images := b.getOldImages()
 	if len(images) > 0 {
@@ -220,6 +266,19 @@ func (b *updateProductBuilder) updateProduct() error {
 	}
 	return nil
 }
+func (b *updateProductBuilder) insertHistory() error {
+	ctx := context.Background()
+
+	after, err := Snapshot(ctx, b.tx, b.req.Id)
+	if err == nil {
+		err = RecordHistory(ctx, b.tx, b.req.Id, b.req.ChangedBy, b.before, after)
+	}
+	if err != nil {
+		b.tx.Rollback()
+		return err
+	}
+	return nil
+}
 func (b *updateProductBuilder) getQueryFields() []string { return b.queryFields }
 func (b *updateProductBuilder) getValues() []any         { return b.values }
 func (b *updateProductBuilder) getQuery() string         { return b.query }
@@ -236,6 +295,8 @@ func (en *updateProductEngineer) sumQueryFields() {
 	en.builder.updateTitleQuery()
 	en.builder.updateDescriptionQuery()
 	en.builder.updatePriceQuery()
+	en.builder.updateWeightQuery()
+	en.builder.updateAttributesQuery()
 
 	fields := en.builder.getQueryFields()
 
@@ -250,7 +311,12 @@ func (en *updateProductEngineer) sumQueryFields() {
 }
 
 func (en *updateProductEngineer) UpdateProduct() error {
-	en.builder.initTransaction()
+	if err := en.builder.initTransaction(); err != nil {
+		return err
+	}
+	if err := en.builder.snapshot(); err != nil {
+		return err
+	}
 
 	en.builder.initQuery()
 	en.sumQueryFields()
@@ -258,9 +324,11 @@ func (en *updateProductEngineer) UpdateProduct() error {
 
 	fmt.Println(en.builder.getQuery())
 
-	// Update product
-	if err := en.builder.updateProduct(); err != nil {
-		return err
+	// Update product, a request with only a category or images has no fields to set
+	if len(en.builder.getQueryFields()) > 0 {
+		if err := en.builder.updateProduct(); err != nil {
+			return err
+		}
 	}
 
 	// Update category
@@ -277,6 +345,10 @@ func (en *updateProductEngineer) UpdateProduct() error {
 		}
 	}
 
+	if err := en.builder.insertHistory(); err != nil {
+		return err
+	}
+
 	// Commit
 	if err := en.builder.commit(); err != nil {
 		return err
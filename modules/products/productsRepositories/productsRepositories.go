@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/Tanapoowapat/GunplaShop/config"
 	"github.com/Tanapoowapat/GunplaShop/modules/entities"
+	"github.com/Tanapoowapat/GunplaShop/modules/file"
 	filesusecase "github.com/Tanapoowapat/GunplaShop/modules/file/filesUsecase"
 	"github.com/Tanapoowapat/GunplaShop/modules/products"
 	productspatterns "github.com/Tanapoowapat/GunplaShop/modules/products/productsPatterns"
@@ -15,10 +17,26 @@ import (
 
 type IProductRepositorise interface {
 	FindOneProducts(productId string) (*products.Products, error)
-	FindProduct(req *products.ProductFilter) ([]*products.Products, int)
+	FindProduct(req *products.ProductFilter) ([]*products.Products, int, *products.Facets)
+	ExportProducts(req *products.ProductFilter, fn func(*products.Products) error) error
 	InsertProduct(req *products.Products) (*products.Products, error)
-	DeleteProduct(productId string) error
+	DeleteProduct(productId, changedBy string) error
+	RestoreProduct(productId, changedBy string) error
 	UpdateProduct(req *products.Products) (*products.Products, error)
+	FindOneVariant(variantId string) (*products.Variant, error)
+	InsertVariant(req *products.Variant) (*products.Variant, error)
+	UpdateVariant(req *products.Variant) (*products.Variant, error)
+	DeleteVariant(productId, variantId, changedBy string) error
+	UpdateSoldOut(productId string, soldOut bool, changedBy string) (bool, error)
+	UpdatePreorder(productId string, req *products.PreorderReq) error
+	UpdateSchedule(productId string, req *products.ScheduleReq) error
+	InsertPrice(req *products.Price) (*products.Price, error)
+	DeletePrice(productId, priceId, changedBy string) error
+	FindHistory(productId string) ([]*products.History, error)
+	FindUpcomingChanges(days int) ([]*products.ScheduledChange, error)
+	FindRelated(productId string, limit int) ([]*products.RecommendedProduct, error)
+	FindBoughtTogether(productId string, limit int) ([]*products.RecommendedProduct, error)
+	RefreshCoPurchases() error
 }
 
 type productsRepositories struct {
@@ -44,19 +62,39 @@ func (repo *productsRepositories) FindOneProducts(productId string) (*products.P
 			"p"."id",
 			"p"."title",
 			"p"."description",
-			"p"."price",
+			` + products.EffectivePrice + ` AS "price",
+			"p"."price" AS "base_price",
+			` + products.LowestPrice + ` AS "lowest_price_30d",
+			"p"."stock",
+			"p"."weight",
+			` + products.EffectiveStatus + ` AS "status",
+			COALESCE("p"."publish_at"::TEXT, '') AS "publish_at",
+			COALESCE("p"."unpublish_at"::TEXT, '') AS "unpublish_at",
+			COALESCE("p"."deleted_at"::TEXT, '') AS "deleted_at",
+			"p"."sold_out",
+			"p"."preorder",
+			COALESCE("p"."release_date"::TEXT, '') AS "release_date",
+			"p"."deposit",
+			"p"."grade",
+			"p"."scale",
+			"p"."series",
+			"p"."manufacturer",
+			"p"."rating_avg" AS "rating",
+			"p"."review_count",
 			(
 				SELECT
-					to_jsonb("ct")
+					COALESCE(array_to_json(array_agg("ct")), '[]'::json)
 				FROM (
 					SELECT
 						"c"."id",
-						"c"."title"
+						"c"."title",
+						"c"."parent_id"
 					FROM "categories" "c"
-						LEFT JOIN "products_categories" "pc" ON "pc"."category_id" = "c"."id"
+						JOIN "products_categories" "pc" ON "pc"."category_id" = "c"."id"
 					WHERE "pc"."product_id" = "p"."id"
+					ORDER BY "c"."id" ASC
 				) AS "ct"
-			) AS "category",
+			) AS "categories",
 			"p"."created_at",
 			"p"."updated_at",
 			(
@@ -68,9 +106,39 @@ func (repo *productsRepositories) FindOneProducts(productId string) (*products.P
 						"i"."filename",
 						"i"."url"
 					FROM "images" "i"
-					WHERE "i"."product_id" = "p"."id"
+					WHERE "i"."product_id" = "p"."id" AND "i"."variant_id" IS NULL
 				) AS "it"
-			) AS "media"
+			) AS "media",
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg("vt")), '[]'::json)
+				FROM (
+					SELECT
+						"v"."id",
+						"v"."product_id",
+						"v"."sku",
+						"v"."title",
+						"v"."price",
+						"v"."stock",
+						(
+							SELECT
+								COALESCE(array_to_json(array_agg("vit")), '[]'::json)
+							FROM (
+								SELECT
+									"vi"."id",
+									"vi"."filename",
+									"vi"."url"
+								FROM "images" "vi"
+								WHERE "vi"."variant_id" = "v"."id"
+							) AS "vit"
+						) AS "media",
+						"v"."created_at",
+						"v"."updated_at"
+					FROM "product_variants" "v"
+					WHERE "v"."product_id" = "p"."id"
+					ORDER BY "v"."created_at" ASC
+				) AS "vt"
+			) AS "variants"
 		FROM "products" "p"
 		WHERE "p"."id" = $1
 		LIMIT 1
@@ -91,21 +159,117 @@ func (repo *productsRepositories) FindOneProducts(productId string) (*products.P
 	return product, nil
 }
 
-func (repo *productsRepositories) FindProduct(req *products.ProductFilter) ([]*products.Products, int) {
+func (repo *productsRepositories) FindProduct(req *products.ProductFilter) ([]*products.Products, int, *products.Facets) {
 	builder := productspatterns.NewFindProductBuilder(repo.db, req)
 	engineer := productspatterns.NewFindProductEngineer(builder)
 
 	result := engineer.FindProduct().Result()
+
+	// Cursor pages are for scrolling through results, they skip the total count
+	// and the facets which would be recomputed over the whole search every page
+	if req.IsCursor() {
+		return result, 0, nil
+	}
 	count := engineer.CountProduct().Count()
-	return result, count
+	facets := engineer.FacetProduct().Facets()
+	return result, count, facets
 }
 
-func (repo *productsRepositories) DeleteProduct(productId string) error {
-	query := `DELETE FROM "producuts" WHERE "id" = $1;`
-	if _, err := repo.db.ExecContext(context.Background(), query, productId); err != nil {
-		return fmt.Errorf("delete products fail: %v", err)
+// ExportProducts walks every product matching the filter page by page in id
+// order, so a large catalogue is never held in memory at once. The pages are
+// read with the id keyset, rows added or removed meanwhile do not shift them.
+func (repo *productsRepositories) ExportProducts(req *products.ProductFilter, fn func(*products.Products) error) error {
+	req.PaginationReq = &entities.PaginationReq{Limit: 100, Paginate: "cursor"}
+	req.SortReq = &entities.SortReq{OrderBy: "id", Sort: "ASC"}
+
+	for {
+		builder := productspatterns.NewFindProductBuilder(repo.db, req)
+		result, err := productspatterns.NewFindProductEngineer(builder).FindProduct().Rows()
+		if err != nil {
+			return err
+		}
+
+		// A cursor page holds one extra row when there is another page
+		last := len(result) <= req.Limit
+		if !last {
+			result = result[:req.Limit]
+		}
+		for _, product := range result {
+			if err := fn(product); err != nil {
+				return err
+			}
+		}
+		if last {
+			return nil
+		}
+
+		lastId := result[len(result)-1].Id
+		req.Cursor = entities.EncodeCursor(&entities.Cursor{
+			OrderBy: req.OrderBy,
+			Sort:    req.Sort,
+			Values:  []any{lastId},
+			Id:      lastId,
+		})
 	}
-	return nil
+}
+
+// updateWithHistory runs update on the locked product and records the fields it changed
+func (repo *productsRepositories) updateWithHistory(productId, changedBy string, update func(ctx context.Context, tx *sqlx.Tx, before map[string]string) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := productspatterns.Snapshot(ctx, tx, productId)
+	if err != nil {
+		return err
+	}
+	if len(before) == 0 {
+		return fmt.Errorf("product %s not found", productId)
+	}
+
+	if err := update(ctx, tx, before); err != nil {
+		return err
+	}
+
+	after, err := productspatterns.Snapshot(ctx, tx, productId)
+	if err != nil {
+		return err
+	}
+	if err := productspatterns.RecordHistory(ctx, tx, productId, changedBy, before, after); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteProduct is a soft delete, the row stays so orders, reviews and
+// carts referencing it keep working and it can be restored
+func (repo *productsRepositories) DeleteProduct(productId, changedBy string) error {
+	return repo.updateWithHistory(productId, changedBy, func(ctx context.Context, tx *sqlx.Tx, before map[string]string) error {
+		if before["deleted_at"] != "" {
+			return fmt.Errorf("product %s not found", productId)
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE "products" SET "deleted_at" = now() WHERE "id" = $1;`, productId); err != nil {
+			return fmt.Errorf("delete products fail: %v", err)
+		}
+		return nil
+	})
+}
+
+func (repo *productsRepositories) RestoreProduct(productId, changedBy string) error {
+	return repo.updateWithHistory(productId, changedBy, func(ctx context.Context, tx *sqlx.Tx, before map[string]string) error {
+		if before["deleted_at"] == "" {
+			return fmt.Errorf("deleted product %s not found", productId)
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE "products" SET "deleted_at" = NULL WHERE "id" = $1;`, productId); err != nil {
+			return fmt.Errorf("restore products fail: %v", err)
+		}
+		return nil
+	})
 }
 
 func (repo *productsRepositories) InsertProduct(req *products.Products) (*products.Products, error) {
@@ -139,3 +303,467 @@ func (repo *productsRepositories) UpdateProduct(req *products.Products) (*produc
 
 	return product, nil
 }
+
+// UpdateSoldOut sets the sold-out flag and returns the previous one
+func (repo *productsRepositories) UpdateSoldOut(productId string, soldOut bool, changedBy string) (bool, error) {
+	var wasSoldOut bool
+	err := repo.updateWithHistory(productId, changedBy, func(ctx context.Context, tx *sqlx.Tx, before map[string]string) error {
+		wasSoldOut = before["sold_out"] == "true"
+		if _, err := tx.ExecContext(ctx, `UPDATE "products" SET "sold_out" = $2 WHERE "id" = $1;`, productId, soldOut); err != nil {
+			return fmt.Errorf("update product availability failed: %v", err)
+		}
+		return nil
+	})
+	return wasSoldOut, err
+}
+
+func (repo *productsRepositories) UpdatePreorder(productId string, req *products.PreorderReq) error {
+	query := `
+	UPDATE "products" SET
+		"preorder" = $2,
+		"release_date" = NULLIF($3, '')::DATE,
+		"deposit" = $4
+	WHERE "id" = $1;`
+
+	return repo.updateWithHistory(productId, req.ChangedBy, func(ctx context.Context, tx *sqlx.Tx, _ map[string]string) error {
+		if _, err := tx.ExecContext(ctx, query, productId, req.Preorder, req.ReleaseDate, req.Deposit); err != nil {
+			return fmt.Errorf("update product pre-order failed: %v", err)
+		}
+		return nil
+	})
+}
+
+func (repo *productsRepositories) FindOneVariant(variantId string) (*products.Variant, error) {
+	query := `
+	SELECT
+		to_jsonb("t")
+	FROM (
+		SELECT
+			"v"."id",
+			"v"."product_id",
+			"v"."sku",
+			"v"."title",
+			"v"."price",
+			"v"."stock",
+			(
+				SELECT
+					COALESCE(array_to_json(array_agg("it")), '[]'::json)
+				FROM (
+					SELECT
+						"i"."id",
+						"i"."filename",
+						"i"."url"
+					FROM "images" "i"
+					WHERE "i"."variant_id" = "v"."id"
+				) AS "it"
+			) AS "media",
+			"v"."created_at",
+			"v"."updated_at"
+		FROM "product_variants" "v"
+		WHERE "v"."id" = $1
+	) AS "t";`
+
+	variantBytes := make([]byte, 0)
+	variant := &products.Variant{
+		Images: make([]*entities.Images, 0),
+	}
+
+	if err := repo.db.Get(&variantBytes, query, variantId); err != nil {
+		return nil, fmt.Errorf("get variant fails: %v", err)
+	}
+	if err := json.Unmarshal(variantBytes, &variant); err != nil {
+		return nil, fmt.Errorf("fail to unmarshal json: %v", err)
+	}
+	return variant, nil
+}
+
+func (repo *productsRepositories) InsertVariant(req *products.Variant) (*products.Variant, error) {
+	query := `
+	INSERT INTO "product_variants" (
+		"product_id",
+		"sku",
+		"title",
+		"price",
+		"stock"
+	)
+	VALUES ($1, $2, $3, $4, $5)
+		RETURNING "id";`
+
+	err := repo.updateWithHistory(req.ProductId, req.ChangedBy, func(ctx context.Context, tx *sqlx.Tx, _ map[string]string) error {
+		if err := tx.QueryRowxContext(ctx, query,
+			req.ProductId,
+			req.Sku,
+			req.Title,
+			req.Price,
+			req.Stock,
+		).Scan(&req.Id); err != nil {
+			return fmt.Errorf("insert variant failed: %v", err)
+		}
+
+		if err := insertVariantImages(ctx, tx, req); err != nil {
+			return err
+		}
+		return productspatterns.RecordHistory(ctx, tx, req.ProductId, req.ChangedBy, map[string]string{}, map[string]string{"variant": req.String()})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return repo.FindOneVariant(req.Id)
+}
+
+// UpdateVariant overwrites the variant, its images are replaced only when new ones are given
+func (repo *productsRepositories) UpdateVariant(req *products.Variant) (*products.Variant, error) {
+	query := `
+	UPDATE "product_variants" SET
+		"sku" = $3,
+		"title" = $4,
+		"price" = $5,
+		"stock" = $6
+	WHERE "product_id" = $1 AND "id" = $2;`
+
+	old, err := repo.FindOneVariant(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = repo.updateWithHistory(req.ProductId, req.ChangedBy, func(ctx context.Context, tx *sqlx.Tx, _ map[string]string) error {
+		result, err := tx.ExecContext(ctx, query,
+			req.ProductId,
+			req.Id,
+			req.Sku,
+			req.Title,
+			req.Price,
+			req.Stock,
+		)
+		if err != nil {
+			return fmt.Errorf("update variant failed: %v", err)
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return fmt.Errorf("variant not found")
+		}
+
+		if len(req.Images) > 0 {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM "images" WHERE "variant_id" = $1;`, req.Id); err != nil {
+				return fmt.Errorf("delete variant images failed: %v", err)
+			}
+			if err := insertVariantImages(ctx, tx, req); err != nil {
+				return err
+			}
+		}
+		return productspatterns.RecordHistory(ctx, tx, req.ProductId, req.ChangedBy, map[string]string{"variant": old.String()}, map[string]string{"variant": req.String()})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Images) > 0 {
+		repo.deleteImageFiles(old.Images)
+	}
+	return repo.FindOneVariant(req.Id)
+}
+
+func (repo *productsRepositories) DeleteVariant(productId, variantId, changedBy string) error {
+	variant, err := repo.FindOneVariant(variantId)
+	if err != nil {
+		return err
+	}
+	if variant.ProductId != productId {
+		return fmt.Errorf("variant not found")
+	}
+
+	query := `DELETE FROM "product_variants" WHERE "product_id" = $1 AND "id" = $2;`
+	err = repo.updateWithHistory(productId, changedBy, func(ctx context.Context, tx *sqlx.Tx, _ map[string]string) error {
+		if _, err := tx.ExecContext(ctx, query, productId, variantId); err != nil {
+			return fmt.Errorf("delete variant fail: %v", err)
+		}
+		return productspatterns.RecordHistory(ctx, tx, productId, changedBy, map[string]string{"variant": variant.String()}, map[string]string{})
+	})
+	if err != nil {
+		return err
+	}
+	repo.deleteImageFiles(variant.Images)
+	return nil
+}
+
+func (repo *productsRepositories) deleteImageFiles(images []*entities.Images) {
+	if len(images) == 0 {
+		return
+	}
+	deleteFileReq := make([]*file.DeleteFileReq, 0)
+	for _, img := range images {
+		deleteFileReq = append(deleteFileReq, &file.DeleteFileReq{
+			Destination: fmt.Sprintf("images/products/%s", img.FileName),
+		})
+	}
+	repo.filesUsecase.DeleteImageLocal(deleteFileReq)
+}
+
+func insertVariantImages(ctx context.Context, tx *sqlx.Tx, req *products.Variant) error {
+	query := `
+	INSERT INTO "images" (
+		"filename",
+		"url",
+		"product_id",
+		"variant_id"
+	)
+	VALUES ($1, $2, $3, $4);`
+
+	for _, img := range req.Images {
+		if _, err := tx.ExecContext(ctx, query, img.FileName, img.Url, req.ProductId, req.Id); err != nil {
+			return fmt.Errorf("insert variant images failed: %v", err)
+		}
+	}
+	return nil
+}
+
+func (repo *productsRepositories) UpdateSchedule(productId string, req *products.ScheduleReq) error {
+	query := `
+	UPDATE "products" SET
+		"publish_at" = NULLIF($2, '')::TIMESTAMPTZ,
+		"unpublish_at" = NULLIF($3, '')::TIMESTAMPTZ
+	WHERE "id" = $1;`
+
+	return repo.updateWithHistory(productId, req.ChangedBy, func(ctx context.Context, tx *sqlx.Tx, _ map[string]string) error {
+		if _, err := tx.ExecContext(ctx, query, productId, req.PublishAt, req.UnpublishAt); err != nil {
+			return fmt.Errorf("update product schedule failed: %v", err)
+		}
+		return nil
+	})
+}
+
+func (repo *productsRepositories) InsertPrice(req *products.Price) (*products.Price, error) {
+	query := `
+	INSERT INTO "product_prices" (
+		"product_id",
+		"sale_price",
+		"start_at",
+		"end_at",
+		"created_by"
+	)
+	VALUES ($1, $2, $3::TIMESTAMPTZ, NULLIF($4, '')::TIMESTAMPTZ, NULLIF($5, ''))
+		RETURNING
+			"id",
+			"product_id",
+			"sale_price",
+			"start_at"::TEXT,
+			COALESCE("end_at"::TEXT, '') AS "end_at",
+			COALESCE("created_by", '') AS "created_by",
+			"created_at"::TEXT;`
+
+	price := new(products.Price)
+	err := repo.updateWithHistory(req.ProductId, req.CreatedBy, func(ctx context.Context, tx *sqlx.Tx, _ map[string]string) error {
+		if err := tx.QueryRowxContext(
+			ctx,
+			query,
+			req.ProductId,
+			req.SalePrice,
+			req.StartAt,
+			req.EndAt,
+			req.CreatedBy,
+		).StructScan(price); err != nil {
+			return fmt.Errorf("insert product price failed: %v", err)
+		}
+		return productspatterns.RecordHistory(ctx, tx, req.ProductId, req.CreatedBy, map[string]string{}, map[string]string{"sale_price": price.String()})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return price, nil
+}
+
+func (repo *productsRepositories) DeletePrice(productId, priceId, changedBy string) error {
+	query := `
+	DELETE FROM "product_prices"
+	WHERE "id" = $1 AND "product_id" = $2
+		RETURNING
+			"id",
+			"product_id",
+			"sale_price",
+			"start_at"::TEXT,
+			COALESCE("end_at"::TEXT, '') AS "end_at",
+			COALESCE("created_by", '') AS "created_by",
+			"created_at"::TEXT;`
+
+	return repo.updateWithHistory(productId, changedBy, func(ctx context.Context, tx *sqlx.Tx, _ map[string]string) error {
+		price := new(products.Price)
+		if err := tx.QueryRowxContext(ctx, query, priceId, productId).StructScan(price); err != nil {
+			return fmt.Errorf("price %s not found", priceId)
+		}
+		return productspatterns.RecordHistory(ctx, tx, productId, changedBy, map[string]string{"sale_price": price.String()}, map[string]string{})
+	})
+}
+
+// FindUpcomingChanges lists what the schedules will change in the next days, soonest first
+func (repo *productsRepositories) FindUpcomingChanges(days int) ([]*products.ScheduledChange, error) {
+	query := `
+	SELECT
+		"sc"."product_id",
+		"sc"."title",
+		"sc"."change",
+		"sc"."at"::TEXT,
+		"sc"."price_id",
+		"sc"."sale_price"
+	FROM (
+		SELECT
+			"p"."id" AS "product_id",
+			"p"."title",
+			'publish' AS "change",
+			"p"."publish_at" AS "at",
+			'' AS "price_id",
+			0::FLOAT AS "sale_price"
+		FROM "products" "p"
+		WHERE "p"."publish_at" > now() AND "p"."deleted_at" IS NULL
+		UNION ALL
+		SELECT
+			"p"."id",
+			"p"."title",
+			'unpublish',
+			"p"."unpublish_at",
+			'',
+			0::FLOAT
+		FROM "products" "p"
+		WHERE "p"."unpublish_at" > now() AND "p"."deleted_at" IS NULL
+		UNION ALL
+		SELECT
+			"p"."id",
+			"p"."title",
+			'price_start',
+			"pp"."start_at",
+			"pp"."id"::TEXT,
+			"pp"."sale_price"
+		FROM "product_prices" "pp"
+			JOIN "products" "p" ON "p"."id" = "pp"."product_id"
+		WHERE "pp"."start_at" > now() AND "p"."deleted_at" IS NULL
+		UNION ALL
+		SELECT
+			"p"."id",
+			"p"."title",
+			'price_end',
+			"pp"."end_at",
+			"pp"."id"::TEXT,
+			"pp"."sale_price"
+		FROM "product_prices" "pp"
+			JOIN "products" "p" ON "p"."id" = "pp"."product_id"
+		WHERE "pp"."end_at" > now() AND "p"."deleted_at" IS NULL
+	) AS "sc"
+	WHERE "sc"."at" <= now() + make_interval(days => $1)
+	ORDER BY "sc"."at" ASC, "sc"."product_id" ASC;`
+
+	changes := make([]*products.ScheduledChange, 0)
+	if err := repo.db.Select(&changes, query, days); err != nil {
+		return nil, fmt.Errorf("get scheduled changes failed: %v", err)
+	}
+	return changes, nil
+}
+
+// FindHistory lists the changes of a product, newest first
+func (repo *productsRepositories) FindHistory(productId string) ([]*products.History, error) {
+	query := `
+	SELECT
+		"h"."id",
+		"h"."product_id",
+		"h"."field",
+		"h"."old_value",
+		"h"."new_value",
+		COALESCE("h"."changed_by", '') AS "changed_by",
+		"h"."created_at"::TEXT
+	FROM "product_history" "h"
+	WHERE "h"."product_id" = $1
+	ORDER BY "h"."created_at" DESC, "h"."field" ASC;`
+
+	history := make([]*products.History, 0)
+	if err := repo.db.Select(&history, query, productId); err != nil {
+		return nil, fmt.Errorf("get product history failed: %v", err)
+	}
+	return history, nil
+}
+
+// recommendedColumns are the card columns of a recommended product "p"
+const recommendedColumns = `
+		"p"."id",
+		"p"."title",
+		` + products.EffectivePrice + ` AS "price",
+		"p"."grade",
+		"p"."scale",
+		"p"."series",
+		"p"."rating_avg" AS "rating",
+		COALESCE((
+			SELECT "i"."url"
+			FROM "images" "i"
+			WHERE "i"."product_id" = "p"."id" AND "i"."variant_id" IS NULL
+			ORDER BY "i"."created_at" ASC
+			LIMIT 1
+		), '') AS "image"`
+
+// recommendable keeps the products a customer can see and buy
+const recommendable = `
+		"p"."deleted_at" IS NULL
+		AND ` + products.EffectiveStatus + ` = 'published'
+		AND "p"."sold_out" = FALSE`
+
+// FindRelated lists the products sharing the series or a category of the
+// product, those sharing both first, then the series only, then the best rated
+func (repo *productsRepositories) FindRelated(productId string, limit int) ([]*products.RecommendedProduct, error) {
+	query := `
+	SELECT` + recommendedColumns + `
+	FROM "products" "p"
+		JOIN "products" "src" ON "src"."id" = $1
+		CROSS JOIN LATERAL (
+			SELECT
+				("src"."series" <> '' AND "p"."series" = "src"."series") AS "same_series",
+				EXISTS (
+					SELECT 1
+					FROM "products_categories" "pc"
+						JOIN "products_categories" "spc" ON "spc"."category_id" = "pc"."category_id"
+					WHERE "pc"."product_id" = "p"."id"
+						AND "spc"."product_id" = "src"."id"
+				) AS "same_category"
+		) "m"
+	WHERE "p"."id" <> "src"."id"
+		AND` + recommendable + `
+		AND ("m"."same_series" OR "m"."same_category")
+	ORDER BY
+		"m"."same_series" DESC,
+		"m"."same_category" DESC,
+		"p"."rating_avg" DESC,
+		"p"."review_count" DESC,
+		"p"."id" ASC
+	LIMIT $2;`
+
+	related := make([]*products.RecommendedProduct, 0)
+	if err := repo.db.Select(&related, query, productId, limit); err != nil {
+		return nil, fmt.Errorf("get related products failed: %v", err)
+	}
+	return related, nil
+}
+
+// FindBoughtTogether lists the products most often ordered with the product,
+// as of the last co-purchase refresh
+func (repo *productsRepositories) FindBoughtTogether(productId string, limit int) ([]*products.RecommendedProduct, error) {
+	query := `
+	SELECT` + recommendedColumns + `,
+		"cp"."orders"
+	FROM "product_co_purchases" "cp"
+		JOIN "products" "p" ON "p"."id" = "cp"."related_id"
+	WHERE "cp"."product_id" = $1
+		AND` + recommendable + `
+	ORDER BY "cp"."orders" DESC, "p"."id" ASC
+	LIMIT $2;`
+
+	together := make([]*products.RecommendedProduct, 0)
+	if err := repo.db.Select(&together, query, productId, limit); err != nil {
+		return nil, fmt.Errorf("get bought together products failed: %v", err)
+	}
+	return together, nil
+}
+
+// RefreshCoPurchases recomputes the co-purchase counts, CONCURRENTLY keeps
+// the old counts readable while it runs
+func (repo *productsRepositories) RefreshCoPurchases() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute*5)
+	defer cancel()
+
+	if _, err := repo.db.ExecContext(ctx, `REFRESH MATERIALIZED VIEW CONCURRENTLY "product_co_purchases";`); err != nil {
+		return fmt.Errorf("refresh co-purchases failed: %v", err)
+	}
+	return nil
+}
@@ -1,28 +1,55 @@
 package productsusecase
 
 import (
+	"context"
+	"fmt"
+	"log"
 	"math"
+	"time"
 
+	"github.com/Tanapoowapat/GunplaShop/modules/appinfo"
+	appinforepositories "github.com/Tanapoowapat/GunplaShop/modules/appinfo/appinfoRepositories"
 	"github.com/Tanapoowapat/GunplaShop/modules/entities"
 	"github.com/Tanapoowapat/GunplaShop/modules/products"
 	productsrepositories "github.com/Tanapoowapat/GunplaShop/modules/products/productsRepositories"
+	wishlistusecase "github.com/Tanapoowapat/GunplaShop/modules/wishlist/wishlistUsecase"
 )
 
 type IProductUseCase interface {
 	FindOneProducts(productId string) (*products.Products, error)
 	FindProducts(req *products.ProductFilter) *entities.PaginateRes
 	AddProduct(req *products.Products) (*products.Products, error)
-	DeleteProduct(productId string) error
+	DeleteProduct(productId, changedBy string) error
+	RestoreProduct(productId, changedBy string) (*products.Products, error)
 	UpdateProduct(req *products.Products) (*products.Products, error)
+	FindOneVariant(productId, variantId string) (*products.Variant, error)
+	AddVariant(req *products.Variant) (*products.Variant, error)
+	UpdateVariant(req *products.Variant) (*products.Variant, error)
+	RemoveVariant(productId, variantId, changedBy string) error
+	SetSoldOut(productId string, soldOut bool, changedBy string) (*products.Products, error)
+	SetPreorder(productId string, req *products.PreorderReq) (*products.Products, error)
+	SetSchedule(productId string, req *products.ScheduleReq) (*products.Products, error)
+	AddPrice(req *products.Price) (*products.Price, error)
+	RemovePrice(productId, priceId, changedBy string) error
+	FindUpcomingChanges(days int) ([]*products.ScheduledChange, error)
+	FindHistory(productId string) ([]*products.History, error)
+	ImportProducts(req *products.ImportReq, lines []*products.ImportLine, changedBy string) (*products.ImportRes, error)
+	ExportProducts(req *products.ProductFilter, fn func(*products.Products) error) error
+	FindRecommendations(productId string) (*products.Recommendations, error)
+	StartCoPurchaseRefresh(ctx context.Context, interval time.Duration)
 }
 
 type productsUsecase struct {
-	productsRepo productsrepositories.IProductRepositorise
+	productsRepo    productsrepositories.IProductRepositorise
+	wishlistUsecase wishlistusecase.IWishlistUsecase
+	appinfoRepo     appinforepositories.IAppinfoRepositories
 }
 
-func NewProductsUsecase(productsRepo productsrepositories.IProductRepositorise) IProductUseCase {
+func NewProductsUsecase(productsRepo productsrepositories.IProductRepositorise, wishlistUsecase wishlistusecase.IWishlistUsecase, appinfoRepo appinforepositories.IAppinfoRepositories) IProductUseCase {
 	return &productsUsecase{
-		productsRepo: productsRepo,
+		productsRepo:    productsRepo,
+		wishlistUsecase: wishlistUsecase,
+		appinfoRepo:     appinfoRepo,
 	}
 }
 
@@ -36,7 +63,22 @@ func (usecase *productsUsecase) FindOneProducts(productId string) (*products.Pro
 
 func (usecase *productsUsecase) FindProducts(req *products.ProductFilter) *entities.PaginateRes {
 
-	data, count := usecase.productsRepo.FindProduct(req)
+	data, count, facets := usecase.productsRepo.FindProduct(req)
+
+	if req.IsCursor() {
+		res := &entities.PaginateRes{
+			Limit: req.Limit,
+		}
+		terms, _ := entities.ParseSort(req.SortReq, products.SortColumns)
+		res.Data, res.NextCursor, res.PrevCursor = entities.CursorPage(req.PaginationReq, req.SortReq, data, func(p *products.Products) ([]any, string) {
+			values := make([]any, 0, len(terms))
+			for _, term := range terms {
+				values = append(values, p.SortValue(term.Key))
+			}
+			return values, p.Id
+		})
+		return res
+	}
 
 	return &entities.PaginateRes{
 		Data:       data,
@@ -44,6 +86,7 @@ func (usecase *productsUsecase) FindProducts(req *products.ProductFilter) *entit
 		Limit:      req.Limit,
 		TotalItems: count,
 		TotalPage:  int(math.Ceil(float64(count) / float64(req.Limit))),
+		Facets:     facets,
 	}
 }
 
@@ -55,10 +98,229 @@ func (usecase *productsUsecase) AddProduct(req *products.Products) (*products.Pr
 	return product, nil
 }
 
-func (usecase *productsUsecase) DeleteProduct(productId string) error {
-	return usecase.productsRepo.DeleteProduct(productId)
+func (usecase *productsUsecase) DeleteProduct(productId, changedBy string) error {
+	return usecase.productsRepo.DeleteProduct(productId, changedBy)
+}
+
+func (usecase *productsUsecase) RestoreProduct(productId, changedBy string) (*products.Products, error) {
+	if err := usecase.productsRepo.RestoreProduct(productId, changedBy); err != nil {
+		return nil, err
+	}
+	return usecase.productsRepo.FindOneProducts(productId)
 }
 
 func (usecase *productsUsecase) UpdateProduct(req *products.Products) (*products.Products, error) {
 	return usecase.productsRepo.UpdateProduct(req)
 }
+
+func (usecase *productsUsecase) FindOneVariant(productId, variantId string) (*products.Variant, error) {
+	variant, err := usecase.productsRepo.FindOneVariant(variantId)
+	if err != nil {
+		return nil, err
+	}
+	if variant.ProductId != productId {
+		return nil, fmt.Errorf("variant not found")
+	}
+	return variant, nil
+}
+
+func (usecase *productsUsecase) AddVariant(req *products.Variant) (*products.Variant, error) {
+	//Check product exists
+	if _, err := usecase.productsRepo.FindOneProducts(req.ProductId); err != nil {
+		return nil, err
+	}
+	return usecase.productsRepo.InsertVariant(req)
+}
+
+func (usecase *productsUsecase) UpdateVariant(req *products.Variant) (*products.Variant, error) {
+	if _, err := usecase.FindOneVariant(req.ProductId, req.Id); err != nil {
+		return nil, err
+	}
+	return usecase.productsRepo.UpdateVariant(req)
+}
+
+func (usecase *productsUsecase) RemoveVariant(productId, variantId, changedBy string) error {
+	return usecase.productsRepo.DeleteVariant(productId, variantId, changedBy)
+}
+
+// SetSoldOut tells the wishlist subscribers when the product comes back from sold-out
+func (usecase *productsUsecase) SetSoldOut(productId string, soldOut bool, changedBy string) (*products.Products, error) {
+	wasSoldOut, err := usecase.productsRepo.UpdateSoldOut(productId, soldOut, changedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	if wasSoldOut && !soldOut {
+		if _, err := usecase.wishlistUsecase.NotifyBackInStock(productId); err != nil {
+			log.Printf("notify back in stock of %s failed: %v\n", productId, err)
+		}
+	}
+	return usecase.productsRepo.FindOneProducts(productId)
+}
+
+func (usecase *productsUsecase) SetPreorder(productId string, req *products.PreorderReq) (*products.Products, error) {
+	if err := usecase.productsRepo.UpdatePreorder(productId, req); err != nil {
+		return nil, err
+	}
+	return usecase.productsRepo.FindOneProducts(productId)
+}
+
+func (usecase *productsUsecase) SetSchedule(productId string, req *products.ScheduleReq) (*products.Products, error) {
+	if err := usecase.productsRepo.UpdateSchedule(productId, req); err != nil {
+		return nil, err
+	}
+	return usecase.productsRepo.FindOneProducts(productId)
+}
+
+func (usecase *productsUsecase) AddPrice(req *products.Price) (*products.Price, error) {
+	//Check product exists
+	if _, err := usecase.productsRepo.FindOneProducts(req.ProductId); err != nil {
+		return nil, err
+	}
+	return usecase.productsRepo.InsertPrice(req)
+}
+
+func (usecase *productsUsecase) RemovePrice(productId, priceId, changedBy string) error {
+	return usecase.productsRepo.DeletePrice(productId, priceId, changedBy)
+}
+
+func (usecase *productsUsecase) FindUpcomingChanges(days int) ([]*products.ScheduledChange, error) {
+	return usecase.productsRepo.FindUpcomingChanges(days)
+}
+
+func (usecase *productsUsecase) FindHistory(productId string) ([]*products.History, error) {
+	//Check product exists
+	if _, err := usecase.productsRepo.FindOneProducts(productId); err != nil {
+		return nil, err
+	}
+	return usecase.productsRepo.FindHistory(productId)
+}
+
+// ImportProducts validates every row and, in commit mode, creates or updates
+// the valid ones. Rows are independent, a failed row does not stop the others.
+func (usecase *productsUsecase) ImportProducts(req *products.ImportReq, lines []*products.ImportLine, changedBy string) (*products.ImportRes, error) {
+	categories, err := usecase.appinfoRepo.FindCategory(&appinfo.CategoryFiter{})
+	if err != nil {
+		return nil, err
+	}
+	categoryIds := make(map[int]bool)
+	for _, c := range categories {
+		categoryIds[c.Id] = true
+	}
+
+	res := &products.ImportRes{
+		Mode:  req.Mode,
+		Total: len(lines),
+		Rows:  make([]*products.ImportResult, 0, len(lines)),
+	}
+	for _, line := range lines {
+		result := &products.ImportResult{Row: line.Row, Action: "create", Status: "failed"}
+		res.Rows = append(res.Rows, result)
+
+		if line.Err != nil {
+			result.Errors = []string{line.Err.Error()}
+			res.Failed++
+			continue
+		}
+		result.Id = line.Data.Id
+		result.Action = line.Data.Action()
+
+		product := line.Data.Product()
+		product.ChangedBy = changedBy
+		result.Errors = usecase.checkImportRow(line.Data, product, categoryIds)
+		if len(result.Errors) > 0 {
+			res.Failed++
+			continue
+		}
+		res.Valid++
+
+		if req.Mode != products.ImportCommit {
+			result.Status = "valid"
+			continue
+		}
+
+		var saved *products.Products
+		if result.Action == "update" {
+			saved, err = usecase.productsRepo.UpdateProduct(product)
+		} else {
+			saved, err = usecase.productsRepo.InsertProduct(product)
+		}
+		if err != nil {
+			result.Errors = []string{err.Error()}
+			res.Valid--
+			res.Failed++
+			continue
+		}
+		result.Id = saved.Id
+		result.Status = "imported"
+		res.Imported++
+	}
+	return res, nil
+}
+
+func (usecase *productsUsecase) checkImportRow(row *products.TransferRow, product *products.Products, categoryIds map[int]bool) []string {
+	errs := row.Validate()
+	if err := product.NormalizeAttributes(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	for _, id := range row.CategoryIds {
+		if id > 0 && !categoryIds[id] {
+			errs = append(errs, fmt.Sprintf("category %d not found", id))
+		}
+	}
+	if row.Id != "" {
+		existing, err := usecase.productsRepo.FindOneProducts(row.Id)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("product %s not found", row.Id))
+		} else if existing.DeletedAt != "" {
+			errs = append(errs, fmt.Sprintf("product %s is deleted", row.Id))
+		}
+	}
+	return errs
+}
+
+func (usecase *productsUsecase) ExportProducts(req *products.ProductFilter, fn func(*products.Products) error) error {
+	return usecase.productsRepo.ExportProducts(req, fn)
+}
+
+func (usecase *productsUsecase) FindRecommendations(productId string) (*products.Recommendations, error) {
+	related, err := usecase.productsRepo.FindRelated(productId, products.RecommendLimit)
+	if err != nil {
+		return nil, err
+	}
+	together, err := usecase.productsRepo.FindBoughtTogether(productId, products.RecommendLimit)
+	if err != nil {
+		return nil, err
+	}
+	return &products.Recommendations{
+		Related:        related,
+		BoughtTogether: together,
+	}, nil
+}
+
+// StartCoPurchaseRefresh refreshes the co-purchase counts now and then every
+// interval, in the background until ctx is done. An interval of 0 turns it off.
+func (usecase *productsUsecase) StartCoPurchaseRefresh(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	refresh := func() {
+		if err := usecase.productsRepo.RefreshCoPurchases(); err != nil {
+			log.Printf("refresh co-purchases failed: %v\n", err)
+		}
+	}
+
+	go func() {
+		refresh()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				refresh()
+			}
+		}
+	}()
+}
@@ -0,0 +1,353 @@
+package products
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/appinfo"
+	"github.com/Tanapoowapat/GunplaShop/modules/entities"
+)
+
+// Import and export file formats, one product per line
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+const (
+	ImportDryRun = "dry_run"
+	ImportCommit = "commit"
+)
+
+// MaxImportRows caps a single import file
+const MaxImportRows = 1000
+
+// TransferColumns are the CSV columns of an import or export, in order.
+// category_ids are separated by CategorySeparator, e.g. 1;4
+const CategorySeparator = ";"
+
+var TransferColumns = []string{"id", "title", "description", "price", "stock", "weight", "category_ids", "grade", "scale", "series", "manufacturer", "status"}
+
+// TransferRow is a product as it is imported and exported. A row with an id
+// updates that product, its empty fields are left alone and its stock is
+// ignored as stock moves through the inventory. A row without one creates a product.
+type TransferRow struct {
+	Id           string  `json:"id"`
+	Title        string  `json:"title"`
+	Description  string  `json:"description"`
+	Price        float64 `json:"price"`
+	Stock        int     `json:"stock"`
+	Weight       int     `json:"weight"`
+	CategoryIds  []int   `json:"category_ids"`
+	Grade        string  `json:"grade"`
+	Scale        string  `json:"scale"`
+	Series       string  `json:"series"`
+	Manufacturer string  `json:"manufacturer"`
+	Status       string  `json:"status"`
+}
+
+// ImportLine is a parsed row of an import file, Err is set when it could not be read
+type ImportLine struct {
+	Row  int
+	Data *TransferRow
+	Err  error
+}
+
+type ImportReq struct {
+	Format string `query:"format"` // csv | jsonl
+	Mode   string `query:"mode"`   // dry_run (default) | commit
+}
+
+type ImportRes struct {
+	Mode     string          `json:"mode"`
+	Total    int             `json:"total"`
+	Valid    int             `json:"valid"`
+	Imported int             `json:"imported"`
+	Failed   int             `json:"failed"`
+	Rows     []*ImportResult `json:"rows"`
+}
+
+type ImportResult struct {
+	Row    int      `json:"row"`
+	Id     string   `json:"id,omitempty"`
+	Action string   `json:"action"` // create | update
+	Status string   `json:"status"` // valid | imported | failed
+	Errors []string `json:"errors,omitempty"`
+}
+
+func (obj *TransferRow) Action() string {
+	if obj.Id != "" {
+		return "update"
+	}
+	return "create"
+}
+
+// Validate checks what can be checked without the database, the category
+// and the product of an update are checked by the usecase
+func (obj *TransferRow) Validate() []string {
+	errs := make([]string, 0)
+	create := obj.Id == ""
+
+	if create && strings.Trim(obj.Title, " ") == "" {
+		errs = append(errs, "title is required")
+	}
+	if obj.Price < 0 || (create && obj.Price == 0) {
+		errs = append(errs, "price must be greater than 0")
+	}
+	if obj.Stock < 0 {
+		errs = append(errs, "stock must not be negative")
+	}
+	if obj.Weight < 0 {
+		errs = append(errs, "weight must not be negative")
+	}
+	if create && len(obj.CategoryIds) == 0 {
+		errs = append(errs, "category_ids is required")
+	}
+	for _, id := range obj.CategoryIds {
+		if id <= 0 {
+			errs = append(errs, "category_ids must be positive")
+			break
+		}
+	}
+	return errs
+}
+
+// Product turns the row into the request of the insert or update builder
+func (obj *TransferRow) Product() *Products {
+	product := &Products{
+		Id:           obj.Id,
+		Title:        strings.Trim(obj.Title, " "),
+		Description:  obj.Description,
+		Price:        obj.Price,
+		Weight:       obj.Weight,
+		Categories:   make([]*appinfo.Category, 0, len(obj.CategoryIds)),
+		Grade:        obj.Grade,
+		Scale:        obj.Scale,
+		Series:       obj.Series,
+		Manufacturer: obj.Manufacturer,
+		Status:       obj.Status,
+		Images:       make([]*entities.Images, 0),
+	}
+	for _, id := range obj.CategoryIds {
+		product.Categories = append(product.Categories, &appinfo.Category{Id: id})
+	}
+	if obj.Id == "" {
+		product.Stock = obj.Stock
+		if product.Status == "" {
+			product.Status = StatusDraft
+		}
+	}
+	return product
+}
+
+// NewTransferRow is the export row of a product, its base price is exported
+// so the file can be imported back
+func NewTransferRow(product *Products) *TransferRow {
+	return &TransferRow{
+		Id:           product.Id,
+		Title:        product.Title,
+		Description:  product.Description,
+		Price:        product.BasePrice,
+		Stock:        product.Stock,
+		Weight:       product.Weight,
+		CategoryIds:  product.CategoryIds(),
+		Grade:        product.Grade,
+		Scale:        product.Scale,
+		Series:       product.Series,
+		Manufacturer: product.Manufacturer,
+		Status:       product.Status,
+	}
+}
+
+// ReadImport parses a CSV file with a header row or a JSON lines file.
+// A row that cannot be read is returned with its error, a broken file fails as a whole.
+func ReadImport(format string, r io.Reader) ([]*ImportLine, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(r)
+	case FormatJSONL:
+		return readJSONLines(r)
+	default:
+		return nil, fmt.Errorf("format must be %s or %s", FormatCSV, FormatJSONL)
+	}
+}
+
+func readCSV(r io.Reader) ([]*ImportLine, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("file is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read csv header failed: %v", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.Trim(name, " \ufeff"))
+		if !contains(TransferColumns, name) {
+			return nil, fmt.Errorf("unknown column %s", name)
+		}
+		columns[name] = i
+	}
+
+	lines := make([]*ImportLine, 0)
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if len(lines) == MaxImportRows {
+			return nil, fmt.Errorf("a file may hold at most %d rows", MaxImportRows)
+		}
+		if err != nil {
+			lines = append(lines, &ImportLine{Row: row, Err: err})
+			continue
+		}
+		data, err := csvRow(columns, record)
+		lines = append(lines, &ImportLine{Row: row, Data: data, Err: err})
+	}
+	return lines, nil
+}
+
+func csvRow(columns map[string]int, record []string) (*TransferRow, error) {
+	value := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.Trim(record[i], " ")
+		}
+		return ""
+	}
+
+	row := &TransferRow{
+		Id:           value("id"),
+		Title:        value("title"),
+		Description:  value("description"),
+		Grade:        value("grade"),
+		Scale:        value("scale"),
+		Series:       value("series"),
+		Manufacturer: value("manufacturer"),
+		Status:       value("status"),
+	}
+
+	var err error
+	if v := value("price"); v != "" {
+		if row.Price, err = strconv.ParseFloat(v, 64); err != nil {
+			return row, fmt.Errorf("price must be a number")
+		}
+	}
+	integers := []struct {
+		name  string
+		value *int
+	}{
+		{"stock", &row.Stock},
+		{"weight", &row.Weight},
+	}
+	for _, field := range integers {
+		if v := value(field.name); v != "" {
+			if *field.value, err = strconv.Atoi(v); err != nil {
+				return row, fmt.Errorf("%s must be a whole number", field.name)
+			}
+		}
+	}
+	for _, v := range strings.Split(value("category_ids"), CategorySeparator) {
+		if v = strings.Trim(v, " "); v == "" {
+			continue
+		}
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return row, fmt.Errorf("category_ids must be whole numbers separated by %s", CategorySeparator)
+		}
+		row.CategoryIds = append(row.CategoryIds, id)
+	}
+	return row, nil
+}
+
+func readJSONLines(r io.Reader) ([]*ImportLine, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	lines := make([]*ImportLine, 0)
+	for row := 1; scanner.Scan(); row++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		if len(lines) == MaxImportRows {
+			return nil, fmt.Errorf("a file may hold at most %d rows", MaxImportRows)
+		}
+
+		data := new(TransferRow)
+		decoder := json.NewDecoder(bytes.NewReader(text))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(data); err != nil {
+			lines = append(lines, &ImportLine{Row: row, Err: fmt.Errorf("invalid json: %v", err)})
+			continue
+		}
+		lines = append(lines, &ImportLine{Row: row, Data: data})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read json lines failed: %v", err)
+	}
+	return lines, nil
+}
+
+// TransferWriter writes export rows in one of the import formats
+type TransferWriter struct {
+	format string
+	csv    *csv.Writer
+	json   *json.Encoder
+}
+
+func NewTransferWriter(format string, w io.Writer) (*TransferWriter, error) {
+	switch format {
+	case FormatCSV:
+		writer := &TransferWriter{format: format, csv: csv.NewWriter(w)}
+		return writer, writer.csv.Write(TransferColumns)
+	case FormatJSONL:
+		return &TransferWriter{format: format, json: json.NewEncoder(w)}, nil
+	default:
+		return nil, fmt.Errorf("format must be %s or %s", FormatCSV, FormatJSONL)
+	}
+}
+
+func (w *TransferWriter) Write(row *TransferRow) error {
+	if w.format == FormatJSONL {
+		return w.json.Encode(row)
+	}
+
+	categoryIds := make([]string, 0, len(row.CategoryIds))
+	for _, id := range row.CategoryIds {
+		categoryIds = append(categoryIds, strconv.Itoa(id))
+	}
+	return w.csv.Write([]string{
+		row.Id,
+		row.Title,
+		row.Description,
+		strconv.FormatFloat(row.Price, 'f', -1, 64),
+		strconv.Itoa(row.Stock),
+		strconv.Itoa(row.Weight),
+		strings.Join(categoryIds, CategorySeparator),
+		row.Grade,
+		row.Scale,
+		row.Series,
+		row.Manufacturer,
+		row.Status,
+	})
+}
+
+// Flush pushes the buffered CSV rows, JSON lines are written straight away
+func (w *TransferWriter) Flush() error {
+	if w.csv == nil {
+		return nil
+	}
+	w.csv.Flush()
+	return w.csv.Error()
+}
@@ -0,0 +1,91 @@
+package products
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadImportCSV(t *testing.T) {
+	file := "title,price,stock,category_ids,grade\n" +
+		"RX-78-2,850,10,1;4,hg\n" +
+		"Strike Freedom,abc,5,1,MG\n" +
+		"\"Zaku II\",1200\n"
+
+	lines, err := ReadImport(FormatCSV, strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("ReadImport unexpected error: %v", err)
+	}
+	if len(lines) != 3 {
+		t.Fatalf("ReadImport returned %d rows, want 3", len(lines))
+	}
+
+	first := lines[0]
+	if first.Err != nil || first.Data.Title != "RX-78-2" || first.Data.Price != 850 || first.Data.Stock != 10 || !reflect.DeepEqual(first.Data.CategoryIds, []int{1, 4}) {
+		t.Errorf("row 1 = %+v, %v", first.Data, first.Err)
+	}
+	if lines[1].Err == nil || lines[1].Row != 2 {
+		t.Errorf("row 2 expected a price error, got %v", lines[1].Err)
+	}
+	if lines[2].Err != nil || lines[2].Data.Price != 1200 || len(lines[2].Data.CategoryIds) != 0 {
+		t.Errorf("row 3 = %+v, %v", lines[2].Data, lines[2].Err)
+	}
+	if errs := lines[2].Data.Validate(); len(errs) != 1 {
+		t.Errorf("row 3 Validate() = %v, want the category error only", errs)
+	}
+}
+
+func TestReadImportRejectsUnknownColumns(t *testing.T) {
+	if _, err := ReadImport(FormatCSV, strings.NewReader("title,colour\nRX-78-2,white\n")); err == nil {
+		t.Error("ReadImport expected an error for an unknown column")
+	}
+	if _, err := ReadImport("xml", strings.NewReader("")); err == nil {
+		t.Error("ReadImport expected an error for an unknown format")
+	}
+}
+
+func TestReadImportJSONLines(t *testing.T) {
+	file := `{"id":"P000001","price":900}` + "\n\n" +
+		`{"title":"Zaku II","colour":"green"}` + "\n"
+
+	lines, err := ReadImport(FormatJSONL, strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("ReadImport unexpected error: %v", err)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("ReadImport returned %d rows, want 2", len(lines))
+	}
+	if lines[0].Err != nil || lines[0].Data.Action() != "update" || lines[0].Data.Price != 900 {
+		t.Errorf("row 1 = %+v, %v", lines[0].Data, lines[0].Err)
+	}
+	if lines[1].Err == nil || lines[1].Row != 3 {
+		t.Errorf("row 3 expected an unknown field error, got %v", lines[1].Err)
+	}
+}
+
+func TestTransferWriterRoundTrip(t *testing.T) {
+	row := &TransferRow{Id: "P000001", Title: "RX-78-2, Ver. 2.0", Price: 1250.5, Stock: 3, CategoryIds: []int{2, 5}, Grade: "MG", Status: StatusPublished}
+
+	for _, format := range []string{FormatCSV, FormatJSONL} {
+		var buf bytes.Buffer
+		writer, err := NewTransferWriter(format, &buf)
+		if err != nil {
+			t.Fatalf("NewTransferWriter(%s) unexpected error: %v", format, err)
+		}
+		if err := writer.Write(row); err != nil {
+			t.Fatalf("Write(%s) unexpected error: %v", format, err)
+		}
+		if err := writer.Flush(); err != nil {
+			t.Fatalf("Flush(%s) unexpected error: %v", format, err)
+		}
+
+		lines, err := ReadImport(format, &buf)
+		if err != nil || len(lines) != 1 || lines[0].Err != nil {
+			t.Fatalf("ReadImport(%s) could not read the export back: %v", format, err)
+		}
+		if !reflect.DeepEqual(lines[0].Data, row) {
+			t.Errorf("%s round trip = %+v, want %+v", format, lines[0].Data, row)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package promotions
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	TypePercentage = "percentage"
+	TypeFixed      = "fixed"
+)
+
+// Promotion is a discount code. Zero limits mean unlimited, and a promotion
+// without products or categories applies to the whole order.
+type Promotion struct {
+	Id           string     `db:"id" json:"id"`
+	Code         string     `db:"code" json:"code"`
+	Title        string     `db:"title" json:"title"`
+	Type         string     `db:"type" json:"type"`
+	Value        float64    `db:"value" json:"value"`
+	MaxDiscount  float64    `db:"max_discount" json:"max_discount"`
+	MinSpend     float64    `db:"min_spend" json:"min_spend"`
+	UsageLimit   int        `db:"usage_limit" json:"usage_limit"`
+	PerUserLimit int        `db:"per_user_limit" json:"per_user_limit"`
+	StartsAt     *time.Time `db:"starts_at" json:"starts_at"`
+	EndsAt       *time.Time `db:"ends_at" json:"ends_at"`
+	IsActive     bool       `db:"is_active" json:"is_active"`
+	ProductIds   []string   `db:"-" json:"product_ids"`
+	CategoryIds  []int      `db:"-" json:"category_ids"`
+	Used         int        `db:"used" json:"used"`
+	CreatedAt    string     `db:"created_at" json:"created_at,omitempty"`
+	UpdatedAt    string     `db:"updated_at" json:"updated_at,omitempty"`
+}
+
+// Item is one order line offered to a promotion
+type Item struct {
+	ProductId   string
+	CategoryIds []int
+	Subtotal    float64
+	Discount    float64
+}
+
+// Discount is the breakdown stored on the order
+type Discount struct {
+	PromotionId string          `json:"promotion_id"`
+	Code        string          `json:"code"`
+	Title       string          `json:"title"`
+	Type        string          `json:"type"`
+	Value       float64         `json:"value"`
+	Amount      float64         `json:"amount"`
+	Lines       []*DiscountLine `json:"lines"`
+}
+
+type DiscountLine struct {
+	ProductId string  `json:"product_id"`
+	Amount    float64 `json:"amount"`
+}
+
+type Usage struct {
+	PromotionId string  `db:"promotion_id"`
+	OrderId     string  `db:"order_id"`
+	UserId      string  `db:"user_id"`
+	Amount      float64 `db:"amount"`
+}
+
+// PromotionError is returned when a code cannot be applied to an order
+type PromotionError struct {
+	Code   string
+	Reason string
+}
+
+func (e *PromotionError) Error() string {
+	return fmt.Sprintf("promotion %s: %s", e.Code, e.Reason)
+}
+
+func (obj *Promotion) Validate() error {
+	obj.Code = strings.ToUpper(strings.Trim(obj.Code, " "))
+	if obj.Code == "" {
+		return fmt.Errorf("code is required")
+	}
+	if obj.Type != TypePercentage && obj.Type != TypeFixed {
+		return fmt.Errorf("type must be %s or %s", TypePercentage, TypeFixed)
+	}
+	if obj.Value <= 0 {
+		return fmt.Errorf("value must be greater than 0")
+	}
+	if obj.Type == TypePercentage && obj.Value > 100 {
+		return fmt.Errorf("percentage must not be greater than 100")
+	}
+	if obj.MaxDiscount < 0 || obj.MinSpend < 0 || obj.UsageLimit < 0 || obj.PerUserLimit < 0 {
+		return fmt.Errorf("limits must not be negative")
+	}
+	if obj.StartsAt != nil && obj.EndsAt != nil && !obj.EndsAt.After(*obj.StartsAt) {
+		return fmt.Errorf("ends_at must be after starts_at")
+	}
+	return nil
+}
+
+func (obj *Promotion) eligible(item *Item) bool {
+	if len(obj.ProductIds) == 0 && len(obj.CategoryIds) == 0 {
+		return true
+	}
+	for _, id := range obj.ProductIds {
+		if id == item.ProductId {
+			return true
+		}
+	}
+	for _, id := range obj.CategoryIds {
+		for _, categoryId := range item.CategoryIds {
+			if id == categoryId {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Apply calculates the discount for items at now and writes each line's share into Item.Discount.
+// Usage limits are checked when the promotion is redeemed.
+func (obj *Promotion) Apply(items []*Item, now time.Time) (*Discount, error) {
+	if !obj.IsActive {
+		return nil, &PromotionError{Code: obj.Code, Reason: "is not active"}
+	}
+	if obj.StartsAt != nil && now.Before(*obj.StartsAt) {
+		return nil, &PromotionError{Code: obj.Code, Reason: "has not started yet"}
+	}
+	if obj.EndsAt != nil && !now.Before(*obj.EndsAt) {
+		return nil, &PromotionError{Code: obj.Code, Reason: "has expired"}
+	}
+
+	subtotal := 0.0
+	eligibleSubtotal := 0.0
+	eligibleItems := make([]*Item, 0)
+	for _, item := range items {
+		subtotal += item.Subtotal
+		if obj.eligible(item) {
+			eligibleSubtotal += item.Subtotal
+			eligibleItems = append(eligibleItems, item)
+		}
+	}
+	if subtotal < obj.MinSpend {
+		return nil, &PromotionError{Code: obj.Code, Reason: fmt.Sprintf("requires a minimum spend of %.2f", obj.MinSpend)}
+	}
+	if eligibleSubtotal <= 0 {
+		return nil, &PromotionError{Code: obj.Code, Reason: "does not apply to any product in this order"}
+	}
+
+	amount := obj.Value
+	if obj.Type == TypePercentage {
+		amount = eligibleSubtotal * obj.Value / 100
+		if obj.MaxDiscount > 0 {
+			amount = math.Min(amount, obj.MaxDiscount)
+		}
+	}
+	amount = roundPrice(math.Min(amount, eligibleSubtotal))
+
+	// Spread the discount over the eligible lines by their subtotal,
+	// the last line takes the rounding difference
+	discount := &Discount{
+		PromotionId: obj.Id,
+		Code:        obj.Code,
+		Title:       obj.Title,
+		Type:        obj.Type,
+		Value:       obj.Value,
+		Amount:      amount,
+		Lines:       make([]*DiscountLine, 0, len(eligibleItems)),
+	}
+	remaining := amount
+	for i, item := range eligibleItems {
+		share := roundPrice(amount * item.Subtotal / eligibleSubtotal)
+		if i == len(eligibleItems)-1 {
+			share = roundPrice(remaining)
+		}
+		remaining -= share
+		item.Discount += share
+		discount.Lines = append(discount.Lines, &DiscountLine{
+			ProductId: item.ProductId,
+			Amount:    share,
+		})
+	}
+	return discount, nil
+}
+
+func roundPrice(price float64) float64 {
+	return math.Round(price*100) / 100
+}
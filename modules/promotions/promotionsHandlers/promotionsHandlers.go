@@ -0,0 +1,159 @@
+package promotionshandlers
+
+import (
+	"strings"
+
+	"github.com/Tanapoowapat/GunplaShop/config"
+	"github.com/Tanapoowapat/GunplaShop/modules/entities"
+	"github.com/Tanapoowapat/GunplaShop/modules/promotions"
+	promotionsusecase "github.com/Tanapoowapat/GunplaShop/modules/promotions/promotionsUsecase"
+	"github.com/gofiber/fiber/v2"
+)
+
+type promotionsHandlersErr string
+
+const (
+	FindPromotionsErr   promotionsHandlersErr = "Promotions-001"
+	FindOnePromotionErr promotionsHandlersErr = "Promotions-002"
+	AddPromotionErr     promotionsHandlersErr = "Promotions-003"
+	UpdatePromotionErr  promotionsHandlersErr = "Promotions-004"
+	RemovePromotionErr  promotionsHandlersErr = "Promotions-005"
+)
+
+type IPromotionsHandlers interface {
+	FindPromotions(c *fiber.Ctx) error
+	FindOnePromotion(c *fiber.Ctx) error
+	AddPromotion(c *fiber.Ctx) error
+	UpdatePromotion(c *fiber.Ctx) error
+	RemovePromotion(c *fiber.Ctx) error
+}
+
+type promotionsHandlers struct {
+	cfg               config.IConfig
+	promotionsUsecase promotionsusecase.IPromotionsUsecase
+}
+
+func NewPromotionsHandlers(cfg config.IConfig, promotionsUsecase promotionsusecase.IPromotionsUsecase) IPromotionsHandlers {
+	return &promotionsHandlers{
+		cfg:               cfg,
+		promotionsUsecase: promotionsUsecase,
+	}
+}
+
+func (h *promotionsHandlers) FindPromotions(c *fiber.Ctx) error {
+	result, err := h.promotionsUsecase.FindPromotions()
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(FindPromotionsErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, result).Res()
+}
+
+func (h *promotionsHandlers) FindOnePromotion(c *fiber.Ctx) error {
+	promotionId := strings.Trim(c.Params("promotion_id"), " ")
+
+	result, err := h.promotionsUsecase.FindOnePromotion(promotionId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrNotFound.Code,
+			string(FindOnePromotionErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, result).Res()
+}
+
+func (h *promotionsHandlers) AddPromotion(c *fiber.Ctx) error {
+	req := &promotions.Promotion{
+		IsActive:    true,
+		ProductIds:  make([]string, 0),
+		CategoryIds: make([]int, 0),
+	}
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(AddPromotionErr),
+			err.Error(),
+		).Res()
+	}
+
+	if err := req.Validate(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(AddPromotionErr),
+			err.Error(),
+		).Res()
+	}
+
+	result, err := h.promotionsUsecase.AddPromotion(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(AddPromotionErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusCreated, result).Res()
+}
+
+func (h *promotionsHandlers) UpdatePromotion(c *fiber.Ctx) error {
+	promotionId := strings.Trim(c.Params("promotion_id"), " ")
+
+	// Fields missing from the body keep their current value
+	req, err := h.promotionsUsecase.FindOnePromotion(promotionId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrNotFound.Code,
+			string(UpdatePromotionErr),
+			err.Error(),
+		).Res()
+	}
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(UpdatePromotionErr),
+			err.Error(),
+		).Res()
+	}
+	req.Id = promotionId
+
+	if err := req.Validate(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(UpdatePromotionErr),
+			err.Error(),
+		).Res()
+	}
+
+	result, err := h.promotionsUsecase.UpdatePromotion(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(UpdatePromotionErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, result).Res()
+}
+
+func (h *promotionsHandlers) RemovePromotion(c *fiber.Ctx) error {
+	promotionId := strings.Trim(c.Params("promotion_id"), " ")
+
+	if err := h.promotionsUsecase.RemovePromotion(promotionId); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(RemovePromotionErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(
+		fiber.StatusOK,
+		&struct {
+			PromotionId string `json:"promotion_id"`
+		}{
+			PromotionId: promotionId,
+		}).Res()
+}
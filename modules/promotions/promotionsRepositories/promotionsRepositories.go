@@ -0,0 +1,312 @@
+package promotionsrepositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/promotions"
+	"github.com/jmoiron/sqlx"
+)
+
+type IPromotionsRepositories interface {
+	FindPromotions() ([]*promotions.Promotion, error)
+	FindOnePromotion(promotionId string) (*promotions.Promotion, error)
+	FindPromotionByCode(code string) (*promotions.Promotion, error)
+	InsertPromotion(req *promotions.Promotion) (string, error)
+	UpdatePromotion(req *promotions.Promotion) error
+	DeletePromotion(promotionId string) error
+	RedeemPromotion(tx *sqlx.Tx, req *promotions.Usage) error
+	ReleasePromotion(tx *sqlx.Tx, orderId string) error
+}
+
+type promotionsRepositories struct {
+	db *sqlx.DB
+}
+
+func NewPromotionsRepositories(db *sqlx.DB) IPromotionsRepositories {
+	return &promotionsRepositories{
+		db: db,
+	}
+}
+
+const selectPromotion = `
+	SELECT
+		"p"."id",
+		"p"."code",
+		"p"."title",
+		"p"."type",
+		"p"."value",
+		"p"."max_discount",
+		"p"."min_spend",
+		"p"."usage_limit",
+		"p"."per_user_limit",
+		"p"."starts_at",
+		"p"."ends_at",
+		"p"."is_active",
+		(
+			SELECT
+				COUNT(*)
+			FROM "promotion_usages" "u"
+			WHERE "u"."promotion_id" = "p"."id"
+		) AS "used",
+		"p"."created_at",
+		"p"."updated_at"
+	FROM "promotions" "p"`
+
+func (repo *promotionsRepositories) FindPromotions() ([]*promotions.Promotion, error) {
+	query := selectPromotion + `
+	ORDER BY "p"."created_at" DESC;`
+
+	result := make([]*promotions.Promotion, 0)
+	if err := repo.db.Select(&result, query); err != nil {
+		return nil, fmt.Errorf("get promotions failed: %v", err)
+	}
+	for _, p := range result {
+		if err := repo.findScope(p); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (repo *promotionsRepositories) FindOnePromotion(promotionId string) (*promotions.Promotion, error) {
+	query := selectPromotion + `
+	WHERE "p"."id" = $1;`
+
+	promotion := new(promotions.Promotion)
+	if err := repo.db.Get(promotion, query, promotionId); err != nil {
+		return nil, fmt.Errorf("promotion not found: %v", err)
+	}
+	if err := repo.findScope(promotion); err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+func (repo *promotionsRepositories) FindPromotionByCode(code string) (*promotions.Promotion, error) {
+	query := selectPromotion + `
+	WHERE "p"."code" = UPPER($1);`
+
+	promotion := new(promotions.Promotion)
+	if err := repo.db.Get(promotion, query, code); err != nil {
+		return nil, &promotions.PromotionError{Code: code, Reason: "does not exist"}
+	}
+	if err := repo.findScope(promotion); err != nil {
+		return nil, err
+	}
+
+	// A promotion on a category also covers the categories below it
+	if len(promotion.CategoryIds) > 0 {
+		categoryIds := make([]int, 0)
+		if err := repo.db.Select(&categoryIds, `SELECT category_descendants($1);`, promotion.CategoryIds); err != nil {
+			return nil, fmt.Errorf("get promotion categories failed: %v", err)
+		}
+		promotion.CategoryIds = categoryIds
+	}
+	return promotion, nil
+}
+
+func (repo *promotionsRepositories) findScope(promotion *promotions.Promotion) error {
+	promotion.ProductIds = make([]string, 0)
+	if err := repo.db.Select(&promotion.ProductIds, `SELECT "product_id" FROM "promotions_products" WHERE "promotion_id" = $1 ORDER BY "product_id";`, promotion.Id); err != nil {
+		return fmt.Errorf("get promotion products failed: %v", err)
+	}
+
+	promotion.CategoryIds = make([]int, 0)
+	if err := repo.db.Select(&promotion.CategoryIds, `SELECT "category_id" FROM "promotions_categories" WHERE "promotion_id" = $1 ORDER BY "category_id";`, promotion.Id); err != nil {
+		return fmt.Errorf("get promotion categories failed: %v", err)
+	}
+	return nil
+}
+
+func (repo *promotionsRepositories) InsertPromotion(req *promotions.Promotion) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO "promotions" (
+		"code",
+		"title",
+		"type",
+		"value",
+		"max_discount",
+		"min_spend",
+		"usage_limit",
+		"per_user_limit",
+		"starts_at",
+		"ends_at",
+		"is_active"
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	RETURNING "id";`
+
+	if err := tx.QueryRowxContext(ctx, query,
+		req.Code,
+		req.Title,
+		req.Type,
+		req.Value,
+		req.MaxDiscount,
+		req.MinSpend,
+		req.UsageLimit,
+		req.PerUserLimit,
+		req.StartsAt,
+		req.EndsAt,
+		req.IsActive,
+	).Scan(&req.Id); err != nil {
+		return "", fmt.Errorf("insert promotion failed: %v", err)
+	}
+
+	if err := insertScope(ctx, tx, req); err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return req.Id, nil
+}
+
+func (repo *promotionsRepositories) UpdatePromotion(req *promotions.Promotion) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+	UPDATE "promotions" SET
+		"code" = $2,
+		"title" = $3,
+		"type" = $4,
+		"value" = $5,
+		"max_discount" = $6,
+		"min_spend" = $7,
+		"usage_limit" = $8,
+		"per_user_limit" = $9,
+		"starts_at" = $10,
+		"ends_at" = $11,
+		"is_active" = $12
+	WHERE "id" = $1;`
+
+	if _, err := tx.ExecContext(ctx, query,
+		req.Id,
+		req.Code,
+		req.Title,
+		req.Type,
+		req.Value,
+		req.MaxDiscount,
+		req.MinSpend,
+		req.UsageLimit,
+		req.PerUserLimit,
+		req.StartsAt,
+		req.EndsAt,
+		req.IsActive,
+	); err != nil {
+		return fmt.Errorf("update promotion failed: %v", err)
+	}
+
+	for _, table := range []string{"promotions_products", "promotions_categories"} {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM "%s" WHERE "promotion_id" = $1;`, table), req.Id); err != nil {
+			return fmt.Errorf("clear %s failed: %v", table, err)
+		}
+	}
+	if err := insertScope(ctx, tx, req); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertScope(ctx context.Context, tx *sqlx.Tx, req *promotions.Promotion) error {
+	for _, productId := range req.ProductIds {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO "promotions_products" ("promotion_id", "product_id") VALUES ($1, $2) ON CONFLICT DO NOTHING;`, req.Id, productId); err != nil {
+			return fmt.Errorf("insert promotion product %s failed: %v", productId, err)
+		}
+	}
+	for _, categoryId := range req.CategoryIds {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO "promotions_categories" ("promotion_id", "category_id") VALUES ($1, $2) ON CONFLICT DO NOTHING;`, req.Id, categoryId); err != nil {
+			return fmt.Errorf("insert promotion category %d failed: %v", categoryId, err)
+		}
+	}
+	return nil
+}
+
+func (repo *promotionsRepositories) DeletePromotion(promotionId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	if _, err := repo.db.ExecContext(ctx, `DELETE FROM "promotions" WHERE "id" = $1;`, promotionId); err != nil {
+		return fmt.Errorf("delete promotion failed: %v", err)
+	}
+	return nil
+}
+
+// RedeemPromotion records a usage inside tx. The promotion row is locked so
+// concurrent orders cannot go over the usage limits.
+func (repo *promotionsRepositories) RedeemPromotion(tx *sqlx.Tx, req *promotions.Usage) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	var code string
+	var usageLimit, perUserLimit int
+	if err := tx.QueryRowxContext(ctx, `
+	SELECT
+		"code",
+		"usage_limit",
+		"per_user_limit"
+	FROM "promotions"
+	WHERE "id" = $1
+	FOR UPDATE;`, req.PromotionId).Scan(&code, &usageLimit, &perUserLimit); err != nil {
+		return fmt.Errorf("lock promotion failed: %v", err)
+	}
+
+	var used, usedByUser int
+	if err := tx.QueryRowxContext(ctx, `
+	SELECT
+		COUNT(*),
+		COUNT(*) FILTER (WHERE "user_id" = $2)
+	FROM "promotion_usages"
+	WHERE "promotion_id" = $1;`, req.PromotionId, req.UserId).Scan(&used, &usedByUser); err != nil {
+		return fmt.Errorf("count promotion usages failed: %v", err)
+	}
+	if usageLimit > 0 && used >= usageLimit {
+		return &promotions.PromotionError{Code: code, Reason: "has been fully redeemed"}
+	}
+	if perUserLimit > 0 && usedByUser >= perUserLimit {
+		return &promotions.PromotionError{Code: code, Reason: "has already been used"}
+	}
+
+	query := `
+	INSERT INTO "promotion_usages" (
+		"promotion_id",
+		"order_id",
+		"user_id",
+		"amount"
+	)
+	VALUES ($1, $2, $3, $4);`
+
+	if _, err := tx.ExecContext(ctx, query, req.PromotionId, req.OrderId, req.UserId, req.Amount); err != nil {
+		return fmt.Errorf("insert promotion usage failed: %v", err)
+	}
+	return nil
+}
+
+// ReleasePromotion gives the usages of a canceled order back
+func (repo *promotionsRepositories) ReleasePromotion(tx *sqlx.Tx, orderId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM "promotion_usages" WHERE "order_id" = $1;`, orderId); err != nil {
+		return fmt.Errorf("release promotion usage failed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package promotionsusecase
+
+import (
+	"github.com/Tanapoowapat/GunplaShop/modules/promotions"
+	promotionsrepositories "github.com/Tanapoowapat/GunplaShop/modules/promotions/promotionsRepositories"
+)
+
+type IPromotionsUsecase interface {
+	FindPromotions() ([]*promotions.Promotion, error)
+	FindOnePromotion(promotionId string) (*promotions.Promotion, error)
+	AddPromotion(req *promotions.Promotion) (*promotions.Promotion, error)
+	UpdatePromotion(req *promotions.Promotion) (*promotions.Promotion, error)
+	RemovePromotion(promotionId string) error
+}
+
+type promotionsUsecase struct {
+	promotionsRepo promotionsrepositories.IPromotionsRepositories
+}
+
+func NewPromotionsUsecase(promotionsRepo promotionsrepositories.IPromotionsRepositories) IPromotionsUsecase {
+	return &promotionsUsecase{
+		promotionsRepo: promotionsRepo,
+	}
+}
+
+func (u *promotionsUsecase) FindPromotions() ([]*promotions.Promotion, error) {
+	return u.promotionsRepo.FindPromotions()
+}
+
+func (u *promotionsUsecase) FindOnePromotion(promotionId string) (*promotions.Promotion, error) {
+	return u.promotionsRepo.FindOnePromotion(promotionId)
+}
+
+func (u *promotionsUsecase) AddPromotion(req *promotions.Promotion) (*promotions.Promotion, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	promotionId, err := u.promotionsRepo.InsertPromotion(req)
+	if err != nil {
+		return nil, err
+	}
+	return u.promotionsRepo.FindOnePromotion(promotionId)
+}
+
+func (u *promotionsUsecase) UpdatePromotion(req *promotions.Promotion) (*promotions.Promotion, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := u.promotionsRepo.UpdatePromotion(req); err != nil {
+		return nil, err
+	}
+	return u.promotionsRepo.FindOnePromotion(req.Id)
+}
+
+func (u *promotionsUsecase) RemovePromotion(promotionId string) error {
+	return u.promotionsRepo.DeletePromotion(promotionId)
+}
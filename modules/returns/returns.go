@@ -0,0 +1,22 @@
+package returns
+
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+type ReturnReq struct {
+	OrderId        string `json:"-"`
+	UserId         string `json:"-"`
+	ProductOrderId string `json:"product_order_id" form:"product_order_id"`
+	Qty            int    `json:"qty" form:"qty"`
+	Reason         string `json:"reason" form:"reason"`
+}
+
+type ReviewReq struct {
+	ReturnId   string `json:"-"`
+	ReviewedBy string `json:"-"`
+	Status     string `json:"-"`
+	Note       string `json:"note" form:"note"`
+}
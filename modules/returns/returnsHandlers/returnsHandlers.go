@@ -0,0 +1,169 @@
+package returnshandlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Tanapoowapat/GunplaShop/config"
+	"github.com/Tanapoowapat/GunplaShop/modules/entities"
+	"github.com/Tanapoowapat/GunplaShop/modules/file"
+	"github.com/Tanapoowapat/GunplaShop/modules/returns"
+	returnsusecase "github.com/Tanapoowapat/GunplaShop/modules/returns/returnsUsecase"
+	"github.com/gofiber/fiber/v2"
+)
+
+type returnsHandlersErr string
+
+const (
+	RequestReturnErr      returnsHandlersErr = "Returns-001"
+	FindPendingReturnsErr returnsHandlersErr = "Returns-002"
+	ApproveReturnErr      returnsHandlersErr = "Returns-003"
+	RejectReturnErr       returnsHandlersErr = "Returns-004"
+)
+
+type IReturnsHandlers interface {
+	RequestReturn(c *fiber.Ctx) error
+	FindPendingReturns(c *fiber.Ctx) error
+	ApproveReturn(c *fiber.Ctx) error
+	RejectReturn(c *fiber.Ctx) error
+}
+
+type returnsHandlers struct {
+	cfg            config.IConfig
+	returnsUsecase returnsusecase.IReturnsUsecase
+}
+
+func NewReturnsHandlers(cfg config.IConfig, returnsUsecase returnsusecase.IReturnsUsecase) IReturnsHandlers {
+	return &returnsHandlers{
+		cfg:            cfg,
+		returnsUsecase: returnsUsecase,
+	}
+}
+
+func (h *returnsHandlers) RequestReturn(c *fiber.Ctx) error {
+	req := new(returns.ReturnReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(RequestReturnErr),
+			err.Error(),
+		).Res()
+	}
+	req.UserId = strings.Trim(c.Params("userId"), " ")
+	req.OrderId = strings.Trim(c.Params("order_id"), " ")
+
+	if req.ProductOrderId == "" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(RequestReturnErr),
+			"product_order_id is required",
+		).Res()
+	}
+	if req.Qty <= 0 {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(RequestReturnErr),
+			"qty must be greater than 0",
+		).Res()
+	}
+	if strings.Trim(req.Reason, " ") == "" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(RequestReturnErr),
+			"reason is required",
+		).Res()
+	}
+
+	// Photos are optional
+	photos := make([]*file.FileReq, 0)
+	if form, err := c.MultipartForm(); err == nil {
+		// File Validation
+		for _, f := range form.File["file"] {
+			photo, err := file.NewImageReq(f, "returns/"+req.OrderId, h.cfg.App().FileLimit())
+			if err != nil {
+				return entities.NewResponse(c).Error(
+					fiber.ErrBadRequest.Code,
+					string(RequestReturnErr),
+					err.Error(),
+				).Res()
+			}
+			photos = append(photos, photo)
+		}
+	}
+
+	result, err := h.returnsUsecase.RequestReturn(req, photos)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(RequestReturnErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusCreated, result).Res()
+}
+
+func (h *returnsHandlers) FindPendingReturns(c *fiber.Ctx) error {
+	result, err := h.returnsUsecase.FindPendingReturns()
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(FindPendingReturnsErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, result).Res()
+}
+
+func (h *returnsHandlers) ApproveReturn(c *fiber.Ctx) error {
+	req := new(returns.ReviewReq)
+	if err := c.BodyParser(req); err != nil && !errors.Is(err, fiber.ErrUnprocessableEntity) {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(ApproveReturnErr),
+			err.Error(),
+		).Res()
+	}
+	req.ReturnId = strings.Trim(c.Params("return_id"), " ")
+	req.ReviewedBy = c.Locals("userId").(string)
+
+	order, err := h.returnsUsecase.ApproveReturn(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(ApproveReturnErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, order).Res()
+}
+
+func (h *returnsHandlers) RejectReturn(c *fiber.Ctx) error {
+	req := new(returns.ReviewReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(RejectReturnErr),
+			err.Error(),
+		).Res()
+	}
+	req.ReturnId = strings.Trim(c.Params("return_id"), " ")
+	req.ReviewedBy = c.Locals("userId").(string)
+
+	if strings.Trim(req.Note, " ") == "" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(RejectReturnErr),
+			"note is required",
+		).Res()
+	}
+
+	result, err := h.returnsUsecase.RejectReturn(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(RejectReturnErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, result).Res()
+}
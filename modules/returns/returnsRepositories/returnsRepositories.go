@@ -0,0 +1,231 @@
+package returnsrepositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/inventory"
+	inventoryrepositories "github.com/Tanapoowapat/GunplaShop/modules/inventory/inventoryRepositories"
+	"github.com/Tanapoowapat/GunplaShop/modules/orders"
+	"github.com/Tanapoowapat/GunplaShop/modules/returns"
+	"github.com/jmoiron/sqlx"
+)
+
+type IReturnsRepositories interface {
+	FindOneReturn(returnId string) (*orders.ReturnRequest, error)
+	FindPendingReturns() ([]*orders.ReturnRequest, error)
+	InsertReturn(req *orders.ReturnRequest) (string, error)
+	ApproveReturn(req *returns.ReviewReq, refund *orders.Refund, stock *inventory.StockReq) error
+	RejectReturn(req *returns.ReviewReq) error
+}
+
+type returnsRepositories struct {
+	db            *sqlx.DB
+	inventoryRepo inventoryrepositories.IInventoryRepositories
+}
+
+func NewReturnsRepositories(db *sqlx.DB, inventoryRepo inventoryrepositories.IInventoryRepositories) IReturnsRepositories {
+	return &returnsRepositories{
+		db:            db,
+		inventoryRepo: inventoryRepo,
+	}
+}
+
+func (repo *returnsRepositories) findReturns(where string, args ...any) ([]*orders.ReturnRequest, error) {
+	query := fmt.Sprintf(`
+	SELECT
+		COALESCE(array_to_json(array_agg("t")), '[]'::json)
+	FROM (
+		SELECT
+			"r"."id",
+			"r"."order_id",
+			"r"."product_order_id",
+			"r"."user_id",
+			"r"."qty",
+			"r"."reason",
+			"r"."images",
+			"r"."status",
+			"r"."refund_amount",
+			"r"."admin_note",
+			"r"."reviewed_by",
+			"r"."reviewed_at",
+			"r"."created_at"
+		FROM "return_requests" "r"
+		WHERE %s
+		ORDER BY "r"."created_at" ASC
+	) AS "t";`, where)
+
+	raw := make([]byte, 0)
+	if err := repo.db.Get(&raw, query, args...); err != nil {
+		return nil, fmt.Errorf("get return requests failed: %v", err)
+	}
+
+	data := make([]*orders.ReturnRequest, 0)
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("unmarshal return requests failed: %v", err)
+	}
+	return data, nil
+}
+
+func (repo *returnsRepositories) FindOneReturn(returnId string) (*orders.ReturnRequest, error) {
+	data, err := repo.findReturns(`"r"."id" = $1`, returnId)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("return request not found")
+	}
+	return data[0], nil
+}
+
+func (repo *returnsRepositories) FindPendingReturns() ([]*orders.ReturnRequest, error) {
+	return repo.findReturns(`"r"."status" = $1`, returns.StatusPending)
+}
+
+// InsertReturn locks the order line so the returned qty can never exceed the bought qty
+func (repo *returnsRepositories) InsertReturn(req *orders.ReturnRequest) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var boughtQty int
+	if err := tx.QueryRowxContext(ctx, `
+	SELECT
+		"qty"
+	FROM "products_orders"
+	WHERE "id" = $1 AND "order_id" = $2
+	FOR UPDATE;`, req.ProductOrderId, req.OrderId).Scan(&boughtQty); err != nil {
+		return "", fmt.Errorf("product order not found: %v", err)
+	}
+
+	var requestedQty int
+	if err := tx.QueryRowxContext(ctx, `
+	SELECT
+		COALESCE(SUM("qty"), 0)
+	FROM "return_requests"
+	WHERE "product_order_id" = $1 AND "status" IN ('pending', 'approved');`, req.ProductOrderId).Scan(&requestedQty); err != nil {
+		return "", fmt.Errorf("get returned qty failed: %v", err)
+	}
+	if requestedQty+req.Qty > boughtQty {
+		return "", fmt.Errorf("only %d item(s) left to return", boughtQty-requestedQty)
+	}
+
+	images, err := json.Marshal(req.Images)
+	if err != nil {
+		return "", err
+	}
+
+	query := `
+	INSERT INTO "return_requests" (
+		"order_id",
+		"product_order_id",
+		"user_id",
+		"qty",
+		"reason",
+		"images"
+	)
+	VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING "id";`
+
+	var returnId string
+	if err := tx.QueryRowxContext(
+		ctx,
+		query,
+		req.OrderId,
+		req.ProductOrderId,
+		req.UserId,
+		req.Qty,
+		req.Reason,
+		images,
+	).Scan(&returnId); err != nil {
+		return "", fmt.Errorf("insert return request failed: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return returnId, nil
+}
+
+// ApproveReturn records the refund and puts the returned items back in stock
+func (repo *returnsRepositories) ApproveReturn(req *returns.ReviewReq, refund *orders.Refund, stock *inventory.StockReq) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := repo.reviewReturn(ctx, tx, req, refund.Amount); err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO "refunds" (
+		"order_id",
+		"return_id",
+		"amount",
+		"created_by"
+	)
+	VALUES ($1, $2, $3, $4);`
+
+	if _, err := tx.ExecContext(ctx, query, refund.OrderId, refund.ReturnId, refund.Amount, refund.CreatedBy); err != nil {
+		return fmt.Errorf("insert refund failed: %v", err)
+	}
+
+	returnQty := 0
+	if err := tx.QueryRowxContext(ctx, `SELECT "qty" FROM "return_requests" WHERE "id" = $1;`, req.ReturnId).Scan(&returnQty); err != nil {
+		return fmt.Errorf("get return qty failed: %v", err)
+	}
+	stock.Qty = returnQty
+	if err := repo.inventoryRepo.ReleaseStock(tx, []*inventory.StockReq{stock}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repo *returnsRepositories) RejectReturn(req *returns.ReviewReq) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := repo.reviewReturn(ctx, tx, req, 0); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (repo *returnsRepositories) reviewReturn(ctx context.Context, tx *sqlx.Tx, req *returns.ReviewReq, refundAmount float64) error {
+	query := `
+	UPDATE "return_requests" SET
+		"status" = $1,
+		"admin_note" = $2,
+		"refund_amount" = $3,
+		"reviewed_by" = $4,
+		"reviewed_at" = now()
+	WHERE "id" = $5 AND "status" = 'pending';`
+
+	result, err := tx.ExecContext(ctx, query, req.Status, req.Note, refundAmount, req.ReviewedBy, req.ReturnId)
+	if err != nil {
+		return fmt.Errorf("review return request failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("return request has already been reviewed")
+	}
+	return nil
+}
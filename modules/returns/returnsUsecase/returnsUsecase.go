@@ -0,0 +1,162 @@
+package returnsusecase
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/file"
+	filesusecase "github.com/Tanapoowapat/GunplaShop/modules/file/filesUsecase"
+	"github.com/Tanapoowapat/GunplaShop/modules/inventory"
+	"github.com/Tanapoowapat/GunplaShop/modules/orders"
+	ordersusecase "github.com/Tanapoowapat/GunplaShop/modules/orders/ordersUsecase"
+	paymentsusecase "github.com/Tanapoowapat/GunplaShop/modules/payments/paymentsUsecase"
+	"github.com/Tanapoowapat/GunplaShop/modules/returns"
+	returnsrepositories "github.com/Tanapoowapat/GunplaShop/modules/returns/returnsRepositories"
+)
+
+type IReturnsUsecase interface {
+	RequestReturn(req *returns.ReturnReq, photos []*file.FileReq) (*orders.ReturnRequest, error)
+	FindPendingReturns() ([]*orders.ReturnRequest, error)
+	ApproveReturn(req *returns.ReviewReq) (*orders.Order, error)
+	RejectReturn(req *returns.ReviewReq) (*orders.ReturnRequest, error)
+}
+
+type returnsUsecase struct {
+	returnsRepo     returnsrepositories.IReturnsRepositories
+	ordersUsecase   ordersusecase.IOrdersUsecase
+	paymentsUsecase paymentsusecase.IPaymentsUsecase
+	filesUsecase    filesusecase.IFileUsecase
+}
+
+func NewReturnsUsecase(returnsRepo returnsrepositories.IReturnsRepositories, ordersUsecase ordersusecase.IOrdersUsecase, paymentsUsecase paymentsusecase.IPaymentsUsecase, filesUsecase filesusecase.IFileUsecase) IReturnsUsecase {
+	return &returnsUsecase{
+		returnsRepo:     returnsRepo,
+		ordersUsecase:   ordersUsecase,
+		paymentsUsecase: paymentsUsecase,
+		filesUsecase:    filesUsecase,
+	}
+}
+
+func findProductOrder(order *orders.Order, productOrderId string) (*orders.ProductOrder, error) {
+	for _, p := range order.Product {
+		if p.Id == productOrderId {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("product order not found")
+}
+
+func (u *returnsUsecase) RequestReturn(req *returns.ReturnReq, photos []*file.FileReq) (*orders.ReturnRequest, error) {
+	order, err := u.ordersUsecase.FindOnceOrders(req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserId != req.UserId {
+		return nil, fmt.Errorf("order not found")
+	}
+	if order.Status != orders.StatusCompleted {
+		return nil, fmt.Errorf("only completed orders can be returned")
+	}
+	if _, err := findProductOrder(order, req.ProductOrderId); err != nil {
+		return nil, err
+	}
+	if req.Qty <= 0 {
+		return nil, fmt.Errorf("qty must be greater than 0")
+	}
+
+	images := make([]*file.FileRes, 0)
+	if len(photos) > 0 {
+		images, err = u.filesUsecase.UploadImageLocal(photos)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	returnId, err := u.returnsRepo.InsertReturn(&orders.ReturnRequest{
+		OrderId:        req.OrderId,
+		ProductOrderId: req.ProductOrderId,
+		UserId:         req.UserId,
+		Qty:            req.Qty,
+		Reason:         req.Reason,
+		Images:         images,
+	})
+	if err != nil {
+		// Nothing refers to the uploaded photos anymore
+		if deleteErr := u.deleteImages(req.OrderId, images); deleteErr != nil {
+			log.Printf("delete return photos failed: %v", deleteErr)
+		}
+		return nil, err
+	}
+	return u.returnsRepo.FindOneReturn(returnId)
+}
+
+func (u *returnsUsecase) deleteImages(orderId string, images []*file.FileRes) error {
+	if len(images) == 0 {
+		return nil
+	}
+
+	deleteFileReq := make([]*file.DeleteFileReq, 0)
+	for _, img := range images {
+		deleteFileReq = append(deleteFileReq, &file.DeleteFileReq{
+			Destination: "returns/" + orderId + "/" + img.FileName,
+		})
+	}
+	return u.filesUsecase.DeleteImageLocal(deleteFileReq)
+}
+
+func (u *returnsUsecase) FindPendingReturns() ([]*orders.ReturnRequest, error) {
+	return u.returnsRepo.FindPendingReturns()
+}
+
+// ApproveReturn refunds what the customer paid for the returned qty, after discounts.
+// The refund goes back through the order's payment intents, orders paid by
+// transfer slip have no refund API and are refunded by hand
+func (u *returnsUsecase) ApproveReturn(req *returns.ReviewReq) (*orders.Order, error) {
+	ret, err := u.returnsRepo.FindOneReturn(req.ReturnId)
+	if err != nil {
+		return nil, err
+	}
+	order, err := u.ordersUsecase.FindOnceOrders(ret.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	line, err := findProductOrder(order, ret.ProductOrderId)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Status = returns.StatusApproved
+	refund := &orders.Refund{
+		OrderId:   ret.OrderId,
+		ReturnId:  ret.Id,
+		Amount:    math.Round((line.Subtotal-line.Discount)/float64(line.Qty)*float64(ret.Qty)*100) / 100,
+		CreatedBy: req.ReviewedBy,
+	}
+	stock := &inventory.StockReq{ProductId: line.Product.Id}
+	if line.Variant != nil {
+		stock.VariantId = line.Variant.Id
+	}
+	if err := u.returnsRepo.ApproveReturn(req, refund, stock); err != nil {
+		return nil, err
+	}
+
+	// The return is recorded first so a failed provider refund is never repeated
+	// by approving again, it can be retried from PATCH /payments/:intent_id/refund
+	if _, err := u.paymentsUsecase.RefundOrder(ret.OrderId, refund.Amount); err != nil {
+		return nil, fmt.Errorf("return approved but the payment refund failed: %v", err)
+	}
+	return u.ordersUsecase.FindOnceOrders(ret.OrderId)
+}
+
+func (u *returnsUsecase) RejectReturn(req *returns.ReviewReq) (*orders.ReturnRequest, error) {
+	if req.Note == "" {
+		return nil, fmt.Errorf("note is required")
+	}
+
+	req.Status = returns.StatusRejected
+	if err := u.returnsRepo.RejectReturn(req); err != nil {
+		return nil, err
+	}
+	return u.returnsRepo.FindOneReturn(req.ReturnId)
+}
@@ -0,0 +1,53 @@
+package reviews
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/file"
+)
+
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+type Review struct {
+	Id          string          `json:"id"`
+	ProductId   string          `json:"product_id"`
+	UserId      string          `json:"user_id"`
+	Username    string          `json:"username"`
+	OrderId     string          `json:"order_id"`
+	Rating      int             `json:"rating"`
+	Comment     string          `json:"comment"`
+	Images      []*file.FileRes `json:"images"`
+	Status      string          `json:"status"`
+	AdminNote   string          `json:"admin_note"`
+	ModeratedBy *string         `json:"moderated_by"`
+	ModeratedAt *string         `json:"moderated_at"`
+	CreatedAt   string          `json:"created_at"`
+	UpdatedAt   string          `json:"updated_at"`
+}
+
+type ReviewReq struct {
+	ProductId string `json:"-"`
+	UserId    string `json:"-"`
+	Rating    int    `json:"rating" form:"rating"`
+	Comment   string `json:"comment" form:"comment"`
+}
+
+func (obj *ReviewReq) Validate() error {
+	if obj.Rating < 1 || obj.Rating > 5 {
+		return fmt.Errorf("rating must be between 1 and 5")
+	}
+	obj.Comment = strings.Trim(obj.Comment, " ")
+	return nil
+}
+
+type ModerateReq struct {
+	ReviewId    string `json:"-"`
+	ModeratedBy string `json:"-"`
+	Status      string `json:"-"`
+	Note        string `json:"note" form:"note"`
+}
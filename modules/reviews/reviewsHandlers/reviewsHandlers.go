@@ -0,0 +1,194 @@
+package reviewshandlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Tanapoowapat/GunplaShop/config"
+	"github.com/Tanapoowapat/GunplaShop/modules/entities"
+	"github.com/Tanapoowapat/GunplaShop/modules/file"
+	"github.com/Tanapoowapat/GunplaShop/modules/reviews"
+	reviewsusecase "github.com/Tanapoowapat/GunplaShop/modules/reviews/reviewsUsecase"
+	"github.com/gofiber/fiber/v2"
+)
+
+type reviewsHandlersErr string
+
+const (
+	FindReviewsErr        reviewsHandlersErr = "Reviews-001"
+	FindPendingReviewsErr reviewsHandlersErr = "Reviews-002"
+	AddReviewErr          reviewsHandlersErr = "Reviews-003"
+	ApproveReviewErr      reviewsHandlersErr = "Reviews-004"
+	RejectReviewErr       reviewsHandlersErr = "Reviews-005"
+	DeleteReviewErr       reviewsHandlersErr = "Reviews-006"
+)
+
+type IReviewsHandlers interface {
+	FindReviews(c *fiber.Ctx) error
+	FindPendingReviews(c *fiber.Ctx) error
+	AddReview(c *fiber.Ctx) error
+	ApproveReview(c *fiber.Ctx) error
+	RejectReview(c *fiber.Ctx) error
+	DeleteReview(c *fiber.Ctx) error
+}
+
+type reviewsHandlers struct {
+	cfg            config.IConfig
+	reviewsUsecase reviewsusecase.IReviewsUsecase
+}
+
+func NewReviewsHandlers(cfg config.IConfig, reviewsUsecase reviewsusecase.IReviewsUsecase) IReviewsHandlers {
+	return &reviewsHandlers{
+		cfg:            cfg,
+		reviewsUsecase: reviewsUsecase,
+	}
+}
+
+func (h *reviewsHandlers) FindReviews(c *fiber.Ctx) error {
+	productId := strings.Trim(c.Params("product_id"), " ")
+
+	result, err := h.reviewsUsecase.FindReviews(productId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(FindReviewsErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, result).Res()
+}
+
+func (h *reviewsHandlers) FindPendingReviews(c *fiber.Ctx) error {
+	result, err := h.reviewsUsecase.FindPendingReviews()
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(FindPendingReviewsErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, result).Res()
+}
+
+func (h *reviewsHandlers) AddReview(c *fiber.Ctx) error {
+	req := new(reviews.ReviewReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(AddReviewErr),
+			err.Error(),
+		).Res()
+	}
+	req.UserId = strings.Trim(c.Params("userId"), " ")
+	req.ProductId = strings.Trim(c.Params("product_id"), " ")
+
+	if err := req.Validate(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(AddReviewErr),
+			err.Error(),
+		).Res()
+	}
+
+	// Build photos are optional
+	photos := make([]*file.FileReq, 0)
+	if form, err := c.MultipartForm(); err == nil {
+		// File Validation
+		for _, f := range form.File["file"] {
+			photo, err := file.NewImageReq(f, "reviews/"+req.ProductId, h.cfg.App().FileLimit())
+			if err != nil {
+				return entities.NewResponse(c).Error(
+					fiber.ErrBadRequest.Code,
+					string(AddReviewErr),
+					err.Error(),
+				).Res()
+			}
+			photos = append(photos, photo)
+		}
+	}
+
+	result, err := h.reviewsUsecase.AddReview(req, photos)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(AddReviewErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusCreated, result).Res()
+}
+
+func (h *reviewsHandlers) ApproveReview(c *fiber.Ctx) error {
+	req := new(reviews.ModerateReq)
+	if err := c.BodyParser(req); err != nil && !errors.Is(err, fiber.ErrUnprocessableEntity) {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(ApproveReviewErr),
+			err.Error(),
+		).Res()
+	}
+	req.ReviewId = strings.Trim(c.Params("review_id"), " ")
+	req.ModeratedBy = c.Locals("userId").(string)
+	req.Status = reviews.StatusApproved
+
+	result, err := h.reviewsUsecase.ModerateReview(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(ApproveReviewErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, result).Res()
+}
+
+func (h *reviewsHandlers) RejectReview(c *fiber.Ctx) error {
+	req := new(reviews.ModerateReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(RejectReviewErr),
+			err.Error(),
+		).Res()
+	}
+	req.ReviewId = strings.Trim(c.Params("review_id"), " ")
+	req.ModeratedBy = c.Locals("userId").(string)
+	req.Status = reviews.StatusRejected
+
+	if strings.Trim(req.Note, " ") == "" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(RejectReviewErr),
+			"note is required",
+		).Res()
+	}
+
+	result, err := h.reviewsUsecase.ModerateReview(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(RejectReviewErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, result).Res()
+}
+
+func (h *reviewsHandlers) DeleteReview(c *fiber.Ctx) error {
+	reviewId := strings.Trim(c.Params("review_id"), " ")
+
+	if err := h.reviewsUsecase.DeleteReview(reviewId); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(DeleteReviewErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(
+		fiber.StatusOK,
+		&struct {
+			ReviewId string `json:"review_id"`
+		}{
+			ReviewId: reviewId,
+		}).Res()
+}
@@ -0,0 +1,224 @@
+package reviewsrepositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/reviews"
+	"github.com/jmoiron/sqlx"
+)
+
+type IReviewsRepositories interface {
+	FindReviews(productId string) ([]*reviews.Review, error)
+	FindPendingReviews() ([]*reviews.Review, error)
+	FindOneReview(reviewId string) (*reviews.Review, error)
+	FindCompletedOrderId(userId, productId string) (string, error)
+	InsertReview(req *reviews.Review) (string, error)
+	ModerateReview(req *reviews.ModerateReq) error
+	DeleteReview(reviewId string) error
+}
+
+type reviewsRepositories struct {
+	db *sqlx.DB
+}
+
+func NewReviewsRepositories(db *sqlx.DB) IReviewsRepositories {
+	return &reviewsRepositories{
+		db: db,
+	}
+}
+
+func (repo *reviewsRepositories) findReviews(where string, args ...any) ([]*reviews.Review, error) {
+	query := fmt.Sprintf(`
+	SELECT
+		COALESCE(array_to_json(array_agg("t")), '[]'::json)
+	FROM (
+		SELECT
+			"r"."id",
+			"r"."product_id",
+			"r"."user_id",
+			"u"."username",
+			"r"."order_id",
+			"r"."rating",
+			"r"."comment",
+			"r"."images",
+			"r"."status",
+			"r"."admin_note",
+			"r"."moderated_by",
+			"r"."moderated_at",
+			"r"."created_at",
+			"r"."updated_at"
+		FROM "reviews" "r"
+			JOIN "users" "u" ON "u"."id" = "r"."user_id"
+		WHERE %s
+		ORDER BY "r"."created_at" DESC
+	) AS "t";`, where)
+
+	raw := make([]byte, 0)
+	if err := repo.db.Get(&raw, query, args...); err != nil {
+		return nil, fmt.Errorf("get reviews failed: %v", err)
+	}
+
+	data := make([]*reviews.Review, 0)
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("unmarshal reviews failed: %v", err)
+	}
+	return data, nil
+}
+
+func (repo *reviewsRepositories) FindReviews(productId string) ([]*reviews.Review, error) {
+	return repo.findReviews(`"r"."product_id" = $1 AND "r"."status" = $2`, productId, reviews.StatusApproved)
+}
+
+func (repo *reviewsRepositories) FindPendingReviews() ([]*reviews.Review, error) {
+	return repo.findReviews(`"r"."status" = $1`, reviews.StatusPending)
+}
+
+func (repo *reviewsRepositories) FindOneReview(reviewId string) (*reviews.Review, error) {
+	data, err := repo.findReviews(`"r"."id" = $1`, reviewId)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("review not found")
+	}
+	return data[0], nil
+}
+
+// FindCompletedOrderId returns the latest completed order of the user that contains the product
+func (repo *reviewsRepositories) FindCompletedOrderId(userId, productId string) (string, error) {
+	query := `
+	SELECT
+		"o"."id"
+	FROM "orders" "o"
+		JOIN "products_orders" "po" ON "po"."order_id" = "o"."id"
+	WHERE "o"."user_id" = $1
+	AND "o"."status" = 'completed'
+	AND "po"."product"->>'id' = $2
+	ORDER BY "o"."created_at" DESC
+	LIMIT 1;`
+
+	var orderId string
+	if err := repo.db.Get(&orderId, query, userId, productId); err != nil {
+		return "", fmt.Errorf("only customers who received this product can review it")
+	}
+	return orderId, nil
+}
+
+func (repo *reviewsRepositories) InsertReview(req *reviews.Review) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	images, err := json.Marshal(req.Images)
+	if err != nil {
+		return "", err
+	}
+
+	query := `
+	INSERT INTO "reviews" (
+		"product_id",
+		"user_id",
+		"order_id",
+		"rating",
+		"comment",
+		"images"
+	)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT ("product_id", "user_id") DO NOTHING
+		RETURNING "id";`
+
+	var reviewId string
+	if err := repo.db.QueryRowxContext(
+		ctx,
+		query,
+		req.ProductId,
+		req.UserId,
+		req.OrderId,
+		req.Rating,
+		req.Comment,
+		images,
+	).Scan(&reviewId); err != nil {
+		// ON CONFLICT DO NOTHING returns no row for a second review
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("product has already been reviewed")
+		}
+		return "", fmt.Errorf("insert review failed: %v", err)
+	}
+	return reviewId, nil
+}
+
+// ModerateReview changes the status of the review and refreshes the rating of its product
+func (repo *reviewsRepositories) ModerateReview(req *reviews.ModerateReq) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+	UPDATE "reviews" SET
+		"status" = $1,
+		"admin_note" = $2,
+		"moderated_by" = $3,
+		"moderated_at" = now()
+	WHERE "id" = $4
+		RETURNING "product_id";`
+
+	var productId string
+	if err := tx.QueryRowxContext(ctx, query, req.Status, req.Note, req.ModeratedBy, req.ReviewId).Scan(&productId); err != nil {
+		return fmt.Errorf("moderate review failed: %v", err)
+	}
+
+	if err := refreshRating(ctx, tx, productId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (repo *reviewsRepositories) DeleteReview(reviewId string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tx, err := repo.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var productId string
+	if err := tx.QueryRowxContext(ctx, `DELETE FROM "reviews" WHERE "id" = $1 RETURNING "product_id";`, reviewId).Scan(&productId); err != nil {
+		return fmt.Errorf("delete review failed: %v", err)
+	}
+
+	if err := refreshRating(ctx, tx, productId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func refreshRating(ctx context.Context, tx *sqlx.Tx, productId string) error {
+	query := `
+	UPDATE "products" SET
+		"rating_avg" = "r"."rating_avg",
+		"review_count" = "r"."review_count"
+	FROM (
+		SELECT
+			COALESCE(ROUND(AVG("rating")::NUMERIC, 2), 0)::FLOAT AS "rating_avg",
+			COUNT(*) AS "review_count"
+		FROM "reviews"
+		WHERE "product_id" = $1 AND "status" = 'approved'
+	) AS "r"
+	WHERE "products"."id" = $1;`
+
+	if _, err := tx.ExecContext(ctx, query, productId); err != nil {
+		return fmt.Errorf("update product rating failed: %v", err)
+	}
+	return nil
+}
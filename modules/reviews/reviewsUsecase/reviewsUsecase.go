@@ -0,0 +1,108 @@
+package reviewsusecase
+
+import (
+	"log"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/file"
+	filesusecase "github.com/Tanapoowapat/GunplaShop/modules/file/filesUsecase"
+	"github.com/Tanapoowapat/GunplaShop/modules/reviews"
+	reviewsrepositories "github.com/Tanapoowapat/GunplaShop/modules/reviews/reviewsRepositories"
+)
+
+type IReviewsUsecase interface {
+	FindReviews(productId string) ([]*reviews.Review, error)
+	FindPendingReviews() ([]*reviews.Review, error)
+	AddReview(req *reviews.ReviewReq, photos []*file.FileReq) (*reviews.Review, error)
+	ModerateReview(req *reviews.ModerateReq) (*reviews.Review, error)
+	DeleteReview(reviewId string) error
+}
+
+type reviewsUsecase struct {
+	reviewsRepo  reviewsrepositories.IReviewsRepositories
+	filesUsecase filesusecase.IFileUsecase
+}
+
+func NewReviewsUsecase(reviewsRepo reviewsrepositories.IReviewsRepositories, filesUsecase filesusecase.IFileUsecase) IReviewsUsecase {
+	return &reviewsUsecase{
+		reviewsRepo:  reviewsRepo,
+		filesUsecase: filesUsecase,
+	}
+}
+
+func (u *reviewsUsecase) FindReviews(productId string) ([]*reviews.Review, error) {
+	return u.reviewsRepo.FindReviews(productId)
+}
+
+func (u *reviewsUsecase) FindPendingReviews() ([]*reviews.Review, error) {
+	return u.reviewsRepo.FindPendingReviews()
+}
+
+// AddReview only accepts reviews from users with a completed order of the product,
+// the review stays hidden until an admin approves it
+func (u *reviewsUsecase) AddReview(req *reviews.ReviewReq, photos []*file.FileReq) (*reviews.Review, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	orderId, err := u.reviewsRepo.FindCompletedOrderId(req.UserId, req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]*file.FileRes, 0)
+	if len(photos) > 0 {
+		images, err = u.filesUsecase.UploadImageLocal(photos)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	reviewId, err := u.reviewsRepo.InsertReview(&reviews.Review{
+		ProductId: req.ProductId,
+		UserId:    req.UserId,
+		OrderId:   orderId,
+		Rating:    req.Rating,
+		Comment:   req.Comment,
+		Images:    images,
+	})
+	if err != nil {
+		// Nothing refers to the uploaded photos anymore
+		if deleteErr := u.deleteImages(req.ProductId, images); deleteErr != nil {
+			log.Printf("delete review photos failed: %v", deleteErr)
+		}
+		return nil, err
+	}
+	return u.reviewsRepo.FindOneReview(reviewId)
+}
+
+func (u *reviewsUsecase) ModerateReview(req *reviews.ModerateReq) (*reviews.Review, error) {
+	if err := u.reviewsRepo.ModerateReview(req); err != nil {
+		return nil, err
+	}
+	return u.reviewsRepo.FindOneReview(req.ReviewId)
+}
+
+func (u *reviewsUsecase) DeleteReview(reviewId string) error {
+	review, err := u.reviewsRepo.FindOneReview(reviewId)
+	if err != nil {
+		return err
+	}
+	if err := u.reviewsRepo.DeleteReview(reviewId); err != nil {
+		return err
+	}
+	return u.deleteImages(review.ProductId, review.Images)
+}
+
+func (u *reviewsUsecase) deleteImages(productId string, images []*file.FileRes) error {
+	if len(images) == 0 {
+		return nil
+	}
+
+	deleteFileReq := make([]*file.DeleteFileReq, 0)
+	for _, img := range images {
+		deleteFileReq = append(deleteFileReq, &file.DeleteFileReq{
+			Destination: "reviews/" + productId + "/" + img.FileName,
+		})
+	}
+	return u.filesUsecase.DeleteImageLocal(deleteFileReq)
+}
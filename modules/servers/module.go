@@ -1,11 +1,20 @@
 package servers
 
 import (
+	addresseshandlers "github.com/Tanapoowapat/GunplaShop/modules/addresses/addressesHandlers"
+	addressesrepositories "github.com/Tanapoowapat/GunplaShop/modules/addresses/addressesRepositories"
+	addressesusecase "github.com/Tanapoowapat/GunplaShop/modules/addresses/addressesUsecase"
 	appinfohandlers "github.com/Tanapoowapat/GunplaShop/modules/appinfo/appinfoHandlers"
 	appinforepositories "github.com/Tanapoowapat/GunplaShop/modules/appinfo/appinfoRepositories"
 	appinfousecase "github.com/Tanapoowapat/GunplaShop/modules/appinfo/appinfoUsecase"
+	carthandlers "github.com/Tanapoowapat/GunplaShop/modules/cart/cartHandlers"
+	cartrepositories "github.com/Tanapoowapat/GunplaShop/modules/cart/cartRepositories"
+	cartusecase "github.com/Tanapoowapat/GunplaShop/modules/cart/cartUsecase"
 	filehandler "github.com/Tanapoowapat/GunplaShop/modules/file/fileHandler"
 	filesusecase "github.com/Tanapoowapat/GunplaShop/modules/file/filesUsecase"
+	inventoryhandlers "github.com/Tanapoowapat/GunplaShop/modules/inventory/inventoryHandlers"
+	inventoryrepositories "github.com/Tanapoowapat/GunplaShop/modules/inventory/inventoryRepositories"
+	inventoryusecase "github.com/Tanapoowapat/GunplaShop/modules/inventory/inventoryUsecase"
 	"github.com/Tanapoowapat/GunplaShop/modules/middlewares/middlewaresHandlers"
 	"github.com/Tanapoowapat/GunplaShop/modules/middlewares/middlewaresRepositories"
 	"github.com/Tanapoowapat/GunplaShop/modules/middlewares/middlewaresUsecase"
@@ -13,12 +22,35 @@ import (
 	ordershandlers "github.com/Tanapoowapat/GunplaShop/modules/orders/ordersHandlers"
 	ordersrepositories "github.com/Tanapoowapat/GunplaShop/modules/orders/ordersRepositories"
 	ordersusecase "github.com/Tanapoowapat/GunplaShop/modules/orders/ordersUsecase"
+	paymentshandlers "github.com/Tanapoowapat/GunplaShop/modules/payments/paymentsHandlers"
+	paymentsproviders "github.com/Tanapoowapat/GunplaShop/modules/payments/paymentsProviders"
+	paymentsrepositories "github.com/Tanapoowapat/GunplaShop/modules/payments/paymentsRepositories"
+	paymentsusecase "github.com/Tanapoowapat/GunplaShop/modules/payments/paymentsUsecase"
 	productshandlers "github.com/Tanapoowapat/GunplaShop/modules/products/productsHandlers"
 	productsrepositories "github.com/Tanapoowapat/GunplaShop/modules/products/productsRepositories"
 	productsusecase "github.com/Tanapoowapat/GunplaShop/modules/products/productsUsercase"
+	promotionshandlers "github.com/Tanapoowapat/GunplaShop/modules/promotions/promotionsHandlers"
+	promotionsrepositories "github.com/Tanapoowapat/GunplaShop/modules/promotions/promotionsRepositories"
+	promotionsusecase "github.com/Tanapoowapat/GunplaShop/modules/promotions/promotionsUsecase"
+	returnshandlers "github.com/Tanapoowapat/GunplaShop/modules/returns/returnsHandlers"
+	returnsrepositories "github.com/Tanapoowapat/GunplaShop/modules/returns/returnsRepositories"
+	returnsusecase "github.com/Tanapoowapat/GunplaShop/modules/returns/returnsUsecase"
+	reviewshandlers "github.com/Tanapoowapat/GunplaShop/modules/reviews/reviewsHandlers"
+	reviewsrepositories "github.com/Tanapoowapat/GunplaShop/modules/reviews/reviewsRepositories"
+	reviewsusecase "github.com/Tanapoowapat/GunplaShop/modules/reviews/reviewsUsecase"
+	shippinghandlers "github.com/Tanapoowapat/GunplaShop/modules/shipping/shippingHandlers"
+	shippingrepositories "github.com/Tanapoowapat/GunplaShop/modules/shipping/shippingRepositories"
+	shippingusecase "github.com/Tanapoowapat/GunplaShop/modules/shipping/shippingUsecase"
+	slipshandlers "github.com/Tanapoowapat/GunplaShop/modules/slips/slipsHandlers"
+	slipsrepositories "github.com/Tanapoowapat/GunplaShop/modules/slips/slipsRepositories"
+	slipsusecase "github.com/Tanapoowapat/GunplaShop/modules/slips/slipsUsecase"
 	"github.com/Tanapoowapat/GunplaShop/modules/users/usersHandlers"
 	"github.com/Tanapoowapat/GunplaShop/modules/users/usersRepositories"
 	"github.com/Tanapoowapat/GunplaShop/modules/users/usersUsecase"
+	wishlisthandlers "github.com/Tanapoowapat/GunplaShop/modules/wishlist/wishlistHandlers"
+	wishlistnotifiers "github.com/Tanapoowapat/GunplaShop/modules/wishlist/wishlistNotifiers"
+	wishlistrepositories "github.com/Tanapoowapat/GunplaShop/modules/wishlist/wishlistRepositories"
+	wishlistusecase "github.com/Tanapoowapat/GunplaShop/modules/wishlist/wishlistUsecase"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -27,8 +59,18 @@ type IModuleFactory interface {
 	UserMoudle()
 	AppinfoModule()
 	FileModule()
-	ProductsModule()
+	ProductsModule() productsusecase.IProductUseCase
 	OrdersModule()
+	CartModule()
+	InventoryModule()
+	SlipsModule()
+	PaymentsModule()
+	ReturnsModule()
+	AddressesModule()
+	ShippingModule()
+	PromotionsModule()
+	ReviewsModule()
+	WishlistModule()
 }
 
 type moduleFactory struct {
@@ -100,37 +142,278 @@ func (m *moduleFactory) FileModule() {
 	router.Patch("/delete", m.mid.JwtAuth(), m.mid.Authorization(2), handler.DeleteImagesLocal)
 }
 
-func (m *moduleFactory) ProductsModule() {
+// ProductsModule returns the usecase so the server can run its background jobs
+func (m *moduleFactory) ProductsModule() productsusecase.IProductUseCase {
 	fileUsecase := filesusecase.NewFileUsecase(m.server.cfg)
 	repo := productsrepositories.NewProductRepositories(m.server.db, m.server.cfg, fileUsecase)
-	usecase := productsusecase.NewProductsUsecase(repo)
+	wishlistRepo := wishlistrepositories.NewWishlistRepositories(m.server.db)
+	wishlistUsecase := wishlistusecase.NewWishlistUsecase(wishlistRepo, repo, wishlistnotifiers.NewLogNotifier(m.server.cfg.App().NotifyFile()))
+	appinfoRepo := appinforepositories.AppinfoRepositories(m.server.db)
+	usecase := productsusecase.NewProductsUsecase(repo, wishlistUsecase, appinfoRepo)
 	handler := productshandlers.NewProductsHandler(m.server.cfg, usecase, fileUsecase)
 
 	router := m.router.Group("/products")
 
 	router.Post("/", m.mid.JwtAuth(), m.mid.Authorization(2), handler.AddProducts)
-	router.Patch("/", m.mid.JwtAuth(), m.mid.Authorization(2), handler.UpdateProducts)
+	router.Post("/import", m.mid.JwtAuth(), m.mid.Authorization(2), handler.ImportProducts)
+	router.Patch("/:product_id", m.mid.JwtAuth(), m.mid.Authorization(2), handler.UpdateProducts)
+	router.Patch("/:product_id/availability", m.mid.JwtAuth(), m.mid.Authorization(2), handler.SetAvailability)
+	router.Patch("/:product_id/preorder", m.mid.JwtAuth(), m.mid.Authorization(2), handler.SetPreorder)
+	router.Patch("/:product_id/schedule", m.mid.JwtAuth(), m.mid.Authorization(2), handler.SetSchedule)
+	router.Post("/:product_id/prices", m.mid.JwtAuth(), m.mid.Authorization(2), handler.AddPrice)
+	router.Delete("/:product_id/prices/:price_id", m.mid.JwtAuth(), m.mid.Authorization(2), handler.DeletePrice)
+
+	router.Post("/:product_id/variants", m.mid.JwtAuth(), m.mid.Authorization(2), handler.AddVariant)
+	router.Patch("/:product_id/variants/:variant_id", m.mid.JwtAuth(), m.mid.Authorization(2), handler.UpdateVariant)
+	router.Delete("/:product_id/variants/:variant_id", m.mid.JwtAuth(), m.mid.Authorization(2), handler.DeleteVariant)
 
 	router.Get("/", m.mid.CheckApiKey(), handler.FindProducts)
+	router.Get("/admin", m.mid.JwtAuth(), m.mid.Authorization(2), handler.FindProducts)
+	router.Get("/admin/schedule", m.mid.JwtAuth(), m.mid.Authorization(2), handler.FindUpcomingChanges)
+	router.Get("/admin/export", m.mid.JwtAuth(), m.mid.Authorization(2), handler.ExportProducts)
+	router.Get("/admin/:product_id", m.mid.JwtAuth(), m.mid.Authorization(2), handler.FindOneProduct)
 	router.Get("/:product_id", m.mid.CheckApiKey(), handler.FindOneProduct)
+	router.Get("/:product_id/history", m.mid.JwtAuth(), m.mid.Authorization(2), handler.FindHistory)
+	router.Get("/:product_id/recommendations", m.mid.CheckApiKey(), handler.FindRecommendations)
 
 	router.Delete("/:product_id", m.mid.JwtAuth(), m.mid.Authorization(2), handler.DeleteProducts)
+	router.Patch("/:product_id/restore", m.mid.JwtAuth(), m.mid.Authorization(2), handler.RestoreProducts)
 
+	return usecase
 }
 
 func (m *moduleFactory) OrdersModule() {
 	fileUsecase := filesusecase.NewFileUsecase(m.server.cfg)
 	productsRepo := productsrepositories.NewProductRepositories(m.server.db, m.server.cfg, fileUsecase)
 
-	repo := ordersrepositories.NewOrdersRepositories(m.server.db)
-	usecase := ordersusecase.NewOrdersUsecase(repo, productsRepo)
+	inventoryRepo := inventoryrepositories.NewInventoryRepositories(m.server.db)
+	promotionsRepo := promotionsrepositories.NewPromotionsRepositories(m.server.db)
+
+	repo := ordersrepositories.NewOrdersRepositories(m.server.db, inventoryRepo, promotionsRepo)
+	addressesRepo := addressesrepositories.NewAddressesRepositories(m.server.db)
+	shippingRepo := shippingrepositories.NewShippingRepositories(m.server.db)
+	usecase := ordersusecase.NewOrdersUsecase(repo, productsRepo, addressesRepo, shippingRepo, promotionsRepo)
 	handler := ordershandlers.NewOrdersHandlers(usecase, m.server.cfg)
 
 	router := m.router.Group("/orders")
 
 	router.Get("/", m.mid.JwtAuth(), m.mid.Authorization(2), handler.FindOrder)
-	router.Get("/:user_id/:order_id", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.FindOnceOrders)
+	router.Get("/:order_id", m.mid.JwtAuth(), m.mid.Authorization(2), handler.FindOnceOrders)
+	router.Get("/:userId/:order_id", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.FindOnceOrders)
 
 	router.Post("/", m.mid.JwtAuth(), handler.InsertOrder)
-	router.Patch("/:user_id/:order_id", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.UpdateOrder)
+	router.Post("/preorders/:product_id", m.mid.JwtAuth(), m.mid.Authorization(2), handler.TransitionPreorders)
+	router.Patch("/:order_id", m.mid.JwtAuth(), m.mid.Authorization(2), handler.UpdateOrder)
+	router.Patch("/:userId/:order_id", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.UpdateOrder)
+}
+
+func (m *moduleFactory) CartModule() {
+	fileUsecase := filesusecase.NewFileUsecase(m.server.cfg)
+	productsRepo := productsrepositories.NewProductRepositories(m.server.db, m.server.cfg, fileUsecase)
+	inventoryRepo := inventoryrepositories.NewInventoryRepositories(m.server.db)
+	promotionsRepo := promotionsrepositories.NewPromotionsRepositories(m.server.db)
+	ordersRepo := ordersrepositories.NewOrdersRepositories(m.server.db, inventoryRepo, promotionsRepo)
+	addressesRepo := addressesrepositories.NewAddressesRepositories(m.server.db)
+	shippingRepo := shippingrepositories.NewShippingRepositories(m.server.db)
+	ordersUsecase := ordersusecase.NewOrdersUsecase(ordersRepo, productsRepo, addressesRepo, shippingRepo, promotionsRepo)
+
+	repo := cartrepositories.NewCartRepositories(m.server.db)
+	usecase := cartusecase.NewCartUsecase(repo, productsRepo, ordersUsecase)
+	handler := carthandlers.NewCartHandlers(m.server.cfg, usecase)
+
+	router := m.router.Group("/carts")
+
+	router.Get("/:userId", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.FindCart)
+
+	router.Post("/:userId", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.AddItem)
+	router.Post("/:userId/checkout", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.Checkout)
+
+	router.Patch("/:userId/:item_id", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.UpdateItem)
+
+	router.Delete("/:userId/:item_id", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.RemoveItem)
+}
+
+func (m *moduleFactory) InventoryModule() {
+	repo := inventoryrepositories.NewInventoryRepositories(m.server.db)
+	usecase := inventoryusecase.NewInventoryUsecase(repo)
+	handler := inventoryhandlers.NewInventoryHandlers(m.server.cfg, usecase)
+
+	router := m.router.Group("/inventory")
+
+	router.Get("/:product_id", m.mid.JwtAuth(), m.mid.Authorization(2), handler.FindStock)
+	router.Patch("/:product_id", m.mid.JwtAuth(), m.mid.Authorization(2), handler.UpdateStock)
+}
+
+func (m *moduleFactory) SlipsModule() {
+	fileUsecase := filesusecase.NewFileUsecase(m.server.cfg)
+	productsRepo := productsrepositories.NewProductRepositories(m.server.db, m.server.cfg, fileUsecase)
+	inventoryRepo := inventoryrepositories.NewInventoryRepositories(m.server.db)
+	promotionsRepo := promotionsrepositories.NewPromotionsRepositories(m.server.db)
+	ordersRepo := ordersrepositories.NewOrdersRepositories(m.server.db, inventoryRepo, promotionsRepo)
+	addressesRepo := addressesrepositories.NewAddressesRepositories(m.server.db)
+	shippingRepo := shippingrepositories.NewShippingRepositories(m.server.db)
+	ordersUsecase := ordersusecase.NewOrdersUsecase(ordersRepo, productsRepo, addressesRepo, shippingRepo, promotionsRepo)
+
+	repo := slipsrepositories.NewSlipsRepositories(m.server.db)
+	usecase := slipsusecase.NewSlipsUsecase(repo, ordersUsecase, fileUsecase)
+	handler := slipshandlers.NewSlipsHandlers(m.server.cfg, usecase)
+
+	router := m.router.Group("/slips")
+
+	router.Get("/pending", m.mid.JwtAuth(), m.mid.Authorization(2), handler.FindPendingSlips)
+	router.Get("/:userId/:order_id", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.FindOrderSlips)
+
+	router.Post("/:userId/:order_id", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.UploadSlip)
+
+	router.Patch("/:slip_id/approve", m.mid.JwtAuth(), m.mid.Authorization(2), handler.ApproveSlip)
+	router.Patch("/:slip_id/reject", m.mid.JwtAuth(), m.mid.Authorization(2), handler.RejectSlip)
+}
+
+func (m *moduleFactory) PaymentsModule() {
+	fileUsecase := filesusecase.NewFileUsecase(m.server.cfg)
+	productsRepo := productsrepositories.NewProductRepositories(m.server.db, m.server.cfg, fileUsecase)
+	inventoryRepo := inventoryrepositories.NewInventoryRepositories(m.server.db)
+	promotionsRepo := promotionsrepositories.NewPromotionsRepositories(m.server.db)
+	ordersRepo := ordersrepositories.NewOrdersRepositories(m.server.db, inventoryRepo, promotionsRepo)
+	addressesRepo := addressesrepositories.NewAddressesRepositories(m.server.db)
+	shippingRepo := shippingrepositories.NewShippingRepositories(m.server.db)
+	ordersUsecase := ordersusecase.NewOrdersUsecase(ordersRepo, productsRepo, addressesRepo, shippingRepo, promotionsRepo)
+
+	repo := paymentsrepositories.NewPaymentsRepositories(m.server.db)
+	usecase := paymentsusecase.NewPaymentsUsecase(
+		repo,
+		ordersUsecase,
+		paymentsproviders.NewPromptPayProvider(m.server.cfg.Payment()),
+	)
+	handler := paymentshandlers.NewPaymentsHandlers(m.server.cfg, usecase)
+
+	router := m.router.Group("/payments")
+
+	router.Post("/webhooks/:provider", handler.Webhook)
+
+	router.Get("/:userId/:order_id", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.FindOrderIntents)
+	router.Post("/:userId/:order_id", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.CreateIntent)
+
+	router.Patch("/:intent_id/capture", m.mid.JwtAuth(), m.mid.Authorization(2), handler.Capture)
+	router.Patch("/:intent_id/refund", m.mid.JwtAuth(), m.mid.Authorization(2), handler.Refund)
+}
+
+func (m *moduleFactory) ReturnsModule() {
+	fileUsecase := filesusecase.NewFileUsecase(m.server.cfg)
+	productsRepo := productsrepositories.NewProductRepositories(m.server.db, m.server.cfg, fileUsecase)
+	inventoryRepo := inventoryrepositories.NewInventoryRepositories(m.server.db)
+	promotionsRepo := promotionsrepositories.NewPromotionsRepositories(m.server.db)
+	ordersRepo := ordersrepositories.NewOrdersRepositories(m.server.db, inventoryRepo, promotionsRepo)
+	addressesRepo := addressesrepositories.NewAddressesRepositories(m.server.db)
+	shippingRepo := shippingrepositories.NewShippingRepositories(m.server.db)
+	ordersUsecase := ordersusecase.NewOrdersUsecase(ordersRepo, productsRepo, addressesRepo, shippingRepo, promotionsRepo)
+
+	paymentsUsecase := paymentsusecase.NewPaymentsUsecase(
+		paymentsrepositories.NewPaymentsRepositories(m.server.db),
+		ordersUsecase,
+		paymentsproviders.NewPromptPayProvider(m.server.cfg.Payment()),
+	)
+
+	repo := returnsrepositories.NewReturnsRepositories(m.server.db, inventoryRepo)
+	usecase := returnsusecase.NewReturnsUsecase(repo, ordersUsecase, paymentsUsecase, fileUsecase)
+	handler := returnshandlers.NewReturnsHandlers(m.server.cfg, usecase)
+
+	router := m.router.Group("/returns")
+
+	router.Get("/pending", m.mid.JwtAuth(), m.mid.Authorization(2), handler.FindPendingReturns)
+
+	router.Post("/:userId/:order_id", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.RequestReturn)
+
+	router.Patch("/:return_id/approve", m.mid.JwtAuth(), m.mid.Authorization(2), handler.ApproveReturn)
+	router.Patch("/:return_id/reject", m.mid.JwtAuth(), m.mid.Authorization(2), handler.RejectReturn)
+}
+
+func (m *moduleFactory) AddressesModule() {
+	repo := addressesrepositories.NewAddressesRepositories(m.server.db)
+	usecase := addressesusecase.NewAddressesUsecase(repo)
+	handler := addresseshandlers.NewAddressesHandlers(m.server.cfg, usecase)
+
+	router := m.router.Group("/users/:userId/addresses")
+
+	router.Get("/", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.FindAddresses)
+	router.Get("/:address_id", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.FindOneAddress)
+
+	router.Post("/", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.AddAddress)
+
+	router.Patch("/:address_id", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.UpdateAddress)
+	router.Patch("/:address_id/default", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.SetDefaultAddress)
+
+	router.Delete("/:address_id", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.RemoveAddress)
+}
+
+func (m *moduleFactory) ShippingModule() {
+	repo := shippingrepositories.NewShippingRepositories(m.server.db)
+	usecase := shippingusecase.NewShippingUsecase(repo)
+	handler := shippinghandlers.NewShippingHandlers(m.server.cfg, usecase)
+
+	router := m.router.Group("/shipping")
+
+	router.Get("/rates", m.mid.CheckApiKey(), handler.FindRates)
+	router.Get("/quote", m.mid.CheckApiKey(), handler.Quote)
+
+	router.Post("/rates", m.mid.JwtAuth(), m.mid.Authorization(2), handler.AddRate)
+
+	router.Patch("/rates/:rate_id", m.mid.JwtAuth(), m.mid.Authorization(2), handler.UpdateRate)
+
+	router.Delete("/rates/:rate_id", m.mid.JwtAuth(), m.mid.Authorization(2), handler.RemoveRate)
+}
+
+func (m *moduleFactory) PromotionsModule() {
+	repo := promotionsrepositories.NewPromotionsRepositories(m.server.db)
+	usecase := promotionsusecase.NewPromotionsUsecase(repo)
+	handler := promotionshandlers.NewPromotionsHandlers(m.server.cfg, usecase)
+
+	router := m.router.Group("/promotions")
+
+	router.Get("/", m.mid.JwtAuth(), m.mid.Authorization(2), handler.FindPromotions)
+	router.Get("/:promotion_id", m.mid.JwtAuth(), m.mid.Authorization(2), handler.FindOnePromotion)
+
+	router.Post("/", m.mid.JwtAuth(), m.mid.Authorization(2), handler.AddPromotion)
+
+	router.Patch("/:promotion_id", m.mid.JwtAuth(), m.mid.Authorization(2), handler.UpdatePromotion)
+
+	router.Delete("/:promotion_id", m.mid.JwtAuth(), m.mid.Authorization(2), handler.RemovePromotion)
+}
+
+func (m *moduleFactory) ReviewsModule() {
+	fileUsecase := filesusecase.NewFileUsecase(m.server.cfg)
+	repo := reviewsrepositories.NewReviewsRepositories(m.server.db)
+	usecase := reviewsusecase.NewReviewsUsecase(repo, fileUsecase)
+	handler := reviewshandlers.NewReviewsHandlers(m.server.cfg, usecase)
+
+	router := m.router.Group("/reviews")
+
+	router.Get("/pending", m.mid.JwtAuth(), m.mid.Authorization(2), handler.FindPendingReviews)
+	router.Get("/products/:product_id", m.mid.CheckApiKey(), handler.FindReviews)
+
+	router.Post("/:userId/:product_id", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.AddReview)
+
+	router.Patch("/:review_id/approve", m.mid.JwtAuth(), m.mid.Authorization(2), handler.ApproveReview)
+	router.Patch("/:review_id/reject", m.mid.JwtAuth(), m.mid.Authorization(2), handler.RejectReview)
+
+	router.Delete("/:review_id", m.mid.JwtAuth(), m.mid.Authorization(2), handler.DeleteReview)
+}
+
+func (m *moduleFactory) WishlistModule() {
+	fileUsecase := filesusecase.NewFileUsecase(m.server.cfg)
+	productsRepo := productsrepositories.NewProductRepositories(m.server.db, m.server.cfg, fileUsecase)
+
+	repo := wishlistrepositories.NewWishlistRepositories(m.server.db)
+	usecase := wishlistusecase.NewWishlistUsecase(repo, productsRepo, wishlistnotifiers.NewLogNotifier(m.server.cfg.App().NotifyFile()))
+	handler := wishlisthandlers.NewWishlistHandlers(m.server.cfg, usecase)
+
+	router := m.router.Group("/users/:userId/wishlist")
+
+	router.Get("/", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.FindWishlist)
+
+	router.Post("/", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.AddItem)
+
+	router.Patch("/:product_id", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.UpdateNotify)
+
+	router.Delete("/:product_id", m.mid.JwtAuth(), m.mid.ParamsCheck(), handler.RemoveItem)
 }
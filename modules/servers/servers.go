@@ -1,6 +1,7 @@
 package servers
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"os"
@@ -37,6 +38,10 @@ func NewServer(cfg config.IConfig, db *sqlx.DB) IServer {
 }
 
 func (s *server) Start() {
+	//Background jobs run until the server shuts down
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	//Middlewares
 	middlewares := NewMiddlewares(s)
 	s.app.Use(middlewares.Logger())
@@ -48,16 +53,30 @@ func (s *server) Start() {
 	modules.UserMoudle()
 	modules.AppinfoModule()
 	modules.FileModule()
-	modules.ProductsModule()
+	productsUsecase := modules.ProductsModule()
 	modules.OrdersModule()
+	modules.CartModule()
+	modules.InventoryModule()
+	modules.SlipsModule()
+	modules.PaymentsModule()
+	modules.ReturnsModule()
+	modules.AddressesModule()
+	modules.ShippingModule()
+	modules.PromotionsModule()
+	modules.ReviewsModule()
+	modules.WishlistModule()
 	s.app.Use(middlewares.RouterCheck())
 
+	//Jobs
+	productsUsecase.StartCoPurchaseRefresh(ctx, s.cfg.App().CoPurchaseRefresh())
+
 	//Graceful shutdown
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt)
 	go func() {
 		_ = <-c
 		log.Println("Server is shutting down...")
+		cancel()
 		_ = s.app.Shutdown()
 	}()
 
@@ -0,0 +1,102 @@
+package shipping
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	RateFlat   = "flat"
+	RateWeight = "weight"
+)
+
+// Rate is one row of the shipping rate table.
+// Region is a province name, empty for nationwide. MaxWeight 0 means no upper limit
+// and FreeOver 0 means the rate is never waived.
+type Rate struct {
+	Id        int     `db:"id" json:"id"`
+	Title     string  `db:"title" json:"title"`
+	Type      string  `db:"type" json:"type"`
+	Region    string  `db:"region" json:"region"`
+	MinWeight int     `db:"min_weight" json:"min_weight"`
+	MaxWeight int     `db:"max_weight" json:"max_weight"`
+	Price     float64 `db:"price" json:"price"`
+	FreeOver  float64 `db:"free_over" json:"free_over"`
+	IsActive  bool    `db:"is_active" json:"is_active"`
+	CreatedAt string  `db:"created_at" json:"created_at,omitempty"`
+	UpdatedAt string  `db:"updated_at" json:"updated_at,omitempty"`
+}
+
+type QuoteReq struct {
+	Province string  `query:"province"`
+	Weight   int     `query:"weight"`
+	Subtotal float64 `query:"subtotal"`
+}
+
+type Quote struct {
+	Rate *Rate   `json:"rate"`
+	Fee  float64 `json:"fee"`
+}
+
+func (obj *Rate) Validate() error {
+	if strings.Trim(obj.Title, " ") == "" {
+		return fmt.Errorf("title is required")
+	}
+	if obj.Type != RateFlat && obj.Type != RateWeight {
+		return fmt.Errorf("type must be %s or %s", RateFlat, RateWeight)
+	}
+	if obj.Price < 0 || obj.FreeOver < 0 || obj.MinWeight < 0 || obj.MaxWeight < 0 {
+		return fmt.Errorf("price, free_over and weights must not be negative")
+	}
+	if obj.MaxWeight != 0 && obj.MaxWeight <= obj.MinWeight {
+		return fmt.Errorf("max_weight must be greater than min_weight")
+	}
+	return nil
+}
+
+func (obj *Rate) Matches(province string, weight int) bool {
+	if obj.Region != "" && !strings.EqualFold(obj.Region, province) {
+		return false
+	}
+	if obj.Type == RateWeight {
+		if weight < obj.MinWeight {
+			return false
+		}
+		if obj.MaxWeight != 0 && weight >= obj.MaxWeight {
+			return false
+		}
+	}
+	return true
+}
+
+func (obj *Rate) Fee(subtotal float64) float64 {
+	if obj.FreeOver > 0 && subtotal >= obj.FreeOver {
+		return 0
+	}
+	return obj.Price
+}
+
+// SelectRate picks the rate for a parcel. Rates for the province win over
+// nationwide ones, then the cheapest wins. It returns nil when nothing applies.
+func SelectRate(rates []*Rate, province string, weight int) *Rate {
+	var selected *Rate
+	for _, r := range rates {
+		if !r.IsActive || !r.Matches(province, weight) {
+			continue
+		}
+		if selected == nil {
+			selected = r
+			continue
+		}
+		if (r.Region != "") != (selected.Region != "") {
+			if r.Region != "" {
+				selected = r
+			}
+			continue
+		}
+		if r.Price < selected.Price {
+			selected = r
+		}
+	}
+	return selected
+}
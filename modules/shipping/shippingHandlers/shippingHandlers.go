@@ -0,0 +1,180 @@
+package shippinghandlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Tanapoowapat/GunplaShop/config"
+	"github.com/Tanapoowapat/GunplaShop/modules/entities"
+	"github.com/Tanapoowapat/GunplaShop/modules/shipping"
+	shippingusecase "github.com/Tanapoowapat/GunplaShop/modules/shipping/shippingUsecase"
+	"github.com/gofiber/fiber/v2"
+)
+
+type shippingHandlersErr string
+
+const (
+	FindRatesErr  shippingHandlersErr = "Shipping-001"
+	AddRateErr    shippingHandlersErr = "Shipping-002"
+	UpdateRateErr shippingHandlersErr = "Shipping-003"
+	RemoveRateErr shippingHandlersErr = "Shipping-004"
+	QuoteErr      shippingHandlersErr = "Shipping-005"
+)
+
+type IShippingHandlers interface {
+	FindRates(c *fiber.Ctx) error
+	AddRate(c *fiber.Ctx) error
+	UpdateRate(c *fiber.Ctx) error
+	RemoveRate(c *fiber.Ctx) error
+	Quote(c *fiber.Ctx) error
+}
+
+type shippingHandlers struct {
+	cfg             config.IConfig
+	shippingUsecase shippingusecase.IShippingUsecase
+}
+
+func NewShippingHandlers(cfg config.IConfig, shippingUsecase shippingusecase.IShippingUsecase) IShippingHandlers {
+	return &shippingHandlers{
+		cfg:             cfg,
+		shippingUsecase: shippingUsecase,
+	}
+}
+
+func (h *shippingHandlers) FindRates(c *fiber.Ctx) error {
+	result, err := h.shippingUsecase.FindRates()
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(FindRatesErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, result).Res()
+}
+
+func (h *shippingHandlers) AddRate(c *fiber.Ctx) error {
+	req := &shipping.Rate{
+		Type:     shipping.RateFlat,
+		IsActive: true,
+	}
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(AddRateErr),
+			err.Error(),
+		).Res()
+	}
+
+	if err := req.Validate(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(AddRateErr),
+			err.Error(),
+		).Res()
+	}
+
+	result, err := h.shippingUsecase.AddRate(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(AddRateErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusCreated, result).Res()
+}
+
+func (h *shippingHandlers) UpdateRate(c *fiber.Ctx) error {
+	rateId, err := strconv.Atoi(strings.Trim(c.Params("rate_id"), " "))
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(UpdateRateErr),
+			err.Error(),
+		).Res()
+	}
+
+	// Fields missing from the body keep their current value
+	req, err := h.shippingUsecase.FindOneRate(rateId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrNotFound.Code,
+			string(UpdateRateErr),
+			err.Error(),
+		).Res()
+	}
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(UpdateRateErr),
+			err.Error(),
+		).Res()
+	}
+	req.Id = rateId
+
+	if err := req.Validate(); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(UpdateRateErr),
+			err.Error(),
+		).Res()
+	}
+
+	result, err := h.shippingUsecase.UpdateRate(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(UpdateRateErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, result).Res()
+}
+
+func (h *shippingHandlers) RemoveRate(c *fiber.Ctx) error {
+	rateId, err := strconv.Atoi(strings.Trim(c.Params("rate_id"), " "))
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(RemoveRateErr),
+			err.Error(),
+		).Res()
+	}
+
+	if err := h.shippingUsecase.RemoveRate(rateId); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(RemoveRateErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(
+		fiber.StatusOK,
+		&struct {
+			RateId int `json:"rate_id"`
+		}{
+			RateId: rateId,
+		}).Res()
+}
+
+func (h *shippingHandlers) Quote(c *fiber.Ctx) error {
+	req := new(shipping.QuoteReq)
+	if err := c.QueryParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(QuoteErr),
+			err.Error(),
+		).Res()
+	}
+
+	result, err := h.shippingUsecase.Quote(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(QuoteErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, result).Res()
+}
@@ -0,0 +1,146 @@
+package shippingrepositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/shipping"
+	"github.com/jmoiron/sqlx"
+)
+
+type IShippingRepositories interface {
+	FindRates(activeOnly bool) ([]*shipping.Rate, error)
+	FindOneRate(rateId int) (*shipping.Rate, error)
+	InsertRate(req *shipping.Rate) (int, error)
+	UpdateRate(req *shipping.Rate) error
+	DeleteRate(rateId int) error
+}
+
+type shippingRepositories struct {
+	db *sqlx.DB
+}
+
+func NewShippingRepositories(db *sqlx.DB) IShippingRepositories {
+	return &shippingRepositories{
+		db: db,
+	}
+}
+
+const selectRate = `
+	SELECT
+		"r"."id",
+		"r"."title",
+		"r"."type",
+		"r"."region",
+		"r"."min_weight",
+		"r"."max_weight",
+		"r"."price",
+		"r"."free_over",
+		"r"."is_active",
+		"r"."created_at",
+		"r"."updated_at"
+	FROM "shipping_rates" "r"`
+
+func (repo *shippingRepositories) FindRates(activeOnly bool) ([]*shipping.Rate, error) {
+	query := selectRate
+	if activeOnly {
+		query += `
+	WHERE "r"."is_active"`
+	}
+	query += `
+	ORDER BY "r"."id" ASC;`
+
+	rates := make([]*shipping.Rate, 0)
+	if err := repo.db.Select(&rates, query); err != nil {
+		return nil, fmt.Errorf("get shipping rates failed: %v", err)
+	}
+	return rates, nil
+}
+
+func (repo *shippingRepositories) FindOneRate(rateId int) (*shipping.Rate, error) {
+	query := selectRate + `
+	WHERE "r"."id" = $1;`
+
+	rate := new(shipping.Rate)
+	if err := repo.db.Get(rate, query, rateId); err != nil {
+		return nil, fmt.Errorf("shipping rate not found: %v", err)
+	}
+	return rate, nil
+}
+
+func (repo *shippingRepositories) InsertRate(req *shipping.Rate) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	query := `
+	INSERT INTO "shipping_rates" (
+		"title",
+		"type",
+		"region",
+		"min_weight",
+		"max_weight",
+		"price",
+		"free_over",
+		"is_active"
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING "id";`
+
+	var rateId int
+	if err := repo.db.QueryRowxContext(ctx, query,
+		req.Title,
+		req.Type,
+		req.Region,
+		req.MinWeight,
+		req.MaxWeight,
+		req.Price,
+		req.FreeOver,
+		req.IsActive,
+	).Scan(&rateId); err != nil {
+		return 0, fmt.Errorf("insert shipping rate failed: %v", err)
+	}
+	return rateId, nil
+}
+
+func (repo *shippingRepositories) UpdateRate(req *shipping.Rate) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	query := `
+	UPDATE "shipping_rates" SET
+		"title" = $2,
+		"type" = $3,
+		"region" = $4,
+		"min_weight" = $5,
+		"max_weight" = $6,
+		"price" = $7,
+		"free_over" = $8,
+		"is_active" = $9
+	WHERE "id" = $1;`
+
+	if _, err := repo.db.ExecContext(ctx, query,
+		req.Id,
+		req.Title,
+		req.Type,
+		req.Region,
+		req.MinWeight,
+		req.MaxWeight,
+		req.Price,
+		req.FreeOver,
+		req.IsActive,
+	); err != nil {
+		return fmt.Errorf("update shipping rate failed: %v", err)
+	}
+	return nil
+}
+
+func (repo *shippingRepositories) DeleteRate(rateId int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	if _, err := repo.db.ExecContext(ctx, `DELETE FROM "shipping_rates" WHERE "id" = $1;`, rateId); err != nil {
+		return fmt.Errorf("delete shipping rate failed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package shippingusecase
+
+import (
+	"fmt"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/shipping"
+	shippingrepositories "github.com/Tanapoowapat/GunplaShop/modules/shipping/shippingRepositories"
+)
+
+type IShippingUsecase interface {
+	FindRates() ([]*shipping.Rate, error)
+	FindOneRate(rateId int) (*shipping.Rate, error)
+	AddRate(req *shipping.Rate) (*shipping.Rate, error)
+	UpdateRate(req *shipping.Rate) (*shipping.Rate, error)
+	RemoveRate(rateId int) error
+	Quote(req *shipping.QuoteReq) (*shipping.Quote, error)
+}
+
+type shippingUsecase struct {
+	shippingRepo shippingrepositories.IShippingRepositories
+}
+
+func NewShippingUsecase(shippingRepo shippingrepositories.IShippingRepositories) IShippingUsecase {
+	return &shippingUsecase{
+		shippingRepo: shippingRepo,
+	}
+}
+
+func (u *shippingUsecase) FindRates() ([]*shipping.Rate, error) {
+	return u.shippingRepo.FindRates(false)
+}
+
+func (u *shippingUsecase) FindOneRate(rateId int) (*shipping.Rate, error) {
+	return u.shippingRepo.FindOneRate(rateId)
+}
+
+func (u *shippingUsecase) AddRate(req *shipping.Rate) (*shipping.Rate, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	rateId, err := u.shippingRepo.InsertRate(req)
+	if err != nil {
+		return nil, err
+	}
+	return u.shippingRepo.FindOneRate(rateId)
+}
+
+func (u *shippingUsecase) UpdateRate(req *shipping.Rate) (*shipping.Rate, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := u.shippingRepo.UpdateRate(req); err != nil {
+		return nil, err
+	}
+	return u.shippingRepo.FindOneRate(req.Id)
+}
+
+func (u *shippingUsecase) RemoveRate(rateId int) error {
+	return u.shippingRepo.DeleteRate(rateId)
+}
+
+func (u *shippingUsecase) Quote(req *shipping.QuoteReq) (*shipping.Quote, error) {
+	rates, err := u.shippingRepo.FindRates(true)
+	if err != nil {
+		return nil, err
+	}
+
+	rate := shipping.SelectRate(rates, req.Province, req.Weight)
+	if rate == nil {
+		return nil, fmt.Errorf("no shipping rate available for %s", req.Province)
+	}
+	return &shipping.Quote{
+		Rate: rate,
+		Fee:  rate.Fee(req.Subtotal),
+	}, nil
+}
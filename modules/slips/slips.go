@@ -0,0 +1,14 @@
+package slips
+
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+type ReviewReq struct {
+	SlipId     string `json:"-"`
+	ReviewedBy string `json:"-"`
+	Status     string `json:"-"`
+	Reason     string `json:"reason" form:"reason"`
+}
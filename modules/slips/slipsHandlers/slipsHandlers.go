@@ -0,0 +1,168 @@
+package slipshandlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Tanapoowapat/GunplaShop/config"
+	"github.com/Tanapoowapat/GunplaShop/modules/entities"
+	"github.com/Tanapoowapat/GunplaShop/modules/file"
+	"github.com/Tanapoowapat/GunplaShop/modules/orders"
+	"github.com/Tanapoowapat/GunplaShop/modules/slips"
+	slipsusecase "github.com/Tanapoowapat/GunplaShop/modules/slips/slipsUsecase"
+	"github.com/gofiber/fiber/v2"
+)
+
+type slipsHandlersErr string
+
+const (
+	UploadSlipErr       slipsHandlersErr = "Slips-001"
+	FindOrderSlipsErr   slipsHandlersErr = "Slips-002"
+	FindPendingSlipsErr slipsHandlersErr = "Slips-003"
+	ApproveSlipErr      slipsHandlersErr = "Slips-004"
+	RejectSlipErr       slipsHandlersErr = "Slips-005"
+)
+
+type ISlipsHandlers interface {
+	UploadSlip(c *fiber.Ctx) error
+	FindOrderSlips(c *fiber.Ctx) error
+	FindPendingSlips(c *fiber.Ctx) error
+	ApproveSlip(c *fiber.Ctx) error
+	RejectSlip(c *fiber.Ctx) error
+}
+
+type slipsHandlers struct {
+	cfg          config.IConfig
+	slipsUsecase slipsusecase.ISlipsUsecase
+}
+
+func NewSlipsHandlers(cfg config.IConfig, slipsUsecase slipsusecase.ISlipsUsecase) ISlipsHandlers {
+	return &slipsHandlers{
+		cfg:          cfg,
+		slipsUsecase: slipsUsecase,
+	}
+}
+
+func (h *slipsHandlers) UploadSlip(c *fiber.Ctx) error {
+	userId := strings.Trim(c.Params("userId"), " ")
+	orderId := strings.Trim(c.Params("order_id"), " ")
+
+	f, err := c.FormFile("file")
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(UploadSlipErr),
+			err.Error(),
+		).Res()
+	}
+
+	// File Validation
+	fileReq, err := file.NewImageReq(f, "slips/"+orderId, h.cfg.App().FileLimit())
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(UploadSlipErr),
+			err.Error(),
+		).Res()
+	}
+
+	slip, err := h.slipsUsecase.UploadSlip(userId, orderId, fileReq)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(UploadSlipErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusCreated, slip).Res()
+}
+
+func (h *slipsHandlers) FindOrderSlips(c *fiber.Ctx) error {
+	userId := strings.Trim(c.Params("userId"), " ")
+	orderId := strings.Trim(c.Params("order_id"), " ")
+	isAdmin := c.Locals("userRoleID").(int) == 2
+
+	result, err := h.slipsUsecase.FindOrderSlips(userId, orderId, isAdmin)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(FindOrderSlipsErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, result).Res()
+}
+
+func (h *slipsHandlers) FindPendingSlips(c *fiber.Ctx) error {
+	result, err := h.slipsUsecase.FindPendingSlips()
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(FindPendingSlipsErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, result).Res()
+}
+
+func (h *slipsHandlers) ApproveSlip(c *fiber.Ctx) error {
+	req := new(slips.ReviewReq)
+	if err := c.BodyParser(req); err != nil && !errors.Is(err, fiber.ErrUnprocessableEntity) {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(ApproveSlipErr),
+			err.Error(),
+		).Res()
+	}
+	req.SlipId = strings.Trim(c.Params("slip_id"), " ")
+	req.ReviewedBy = c.Locals("userId").(string)
+
+	order, err := h.slipsUsecase.ApproveSlip(req)
+	if err != nil {
+		var transitionErr *orders.StatusTransitionError
+		if errors.As(err, &transitionErr) {
+			return entities.NewResponse(c).Error(
+				fiber.ErrBadRequest.Code,
+				string(ApproveSlipErr),
+				transitionErr.Error(),
+			).Res()
+		}
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(ApproveSlipErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, order).Res()
+}
+
+func (h *slipsHandlers) RejectSlip(c *fiber.Ctx) error {
+	req := new(slips.ReviewReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(RejectSlipErr),
+			err.Error(),
+		).Res()
+	}
+	req.SlipId = strings.Trim(c.Params("slip_id"), " ")
+	req.ReviewedBy = c.Locals("userId").(string)
+
+	if strings.Trim(req.Reason, " ") == "" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(RejectSlipErr),
+			"reason is required",
+		).Res()
+	}
+
+	slip, err := h.slipsUsecase.RejectSlip(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(RejectSlipErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, slip).Res()
+}
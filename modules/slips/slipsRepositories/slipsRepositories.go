@@ -0,0 +1,131 @@
+package slipsrepositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/orders"
+	"github.com/Tanapoowapat/GunplaShop/modules/slips"
+	"github.com/jmoiron/sqlx"
+)
+
+type ISlipsRepositories interface {
+	FindOneSlip(slipId string) (*orders.TransferSlip, error)
+	FindOrderSlips(orderId string) ([]*orders.TransferSlip, error)
+	FindPendingSlips() ([]*orders.TransferSlip, error)
+	InsertSlip(req *orders.TransferSlip) (string, error)
+	ReviewSlip(req *slips.ReviewReq) error
+}
+
+type slipsRepositories struct {
+	db *sqlx.DB
+}
+
+func NewSlipsRepositories(db *sqlx.DB) ISlipsRepositories {
+	return &slipsRepositories{
+		db: db,
+	}
+}
+
+const selectSlipQuery = `
+	SELECT
+		"s"."id",
+		"s"."order_id",
+		"s"."filename",
+		"s"."url",
+		"s"."status",
+		"s"."reason",
+		COALESCE("s"."reviewed_by", '') AS "reviewed_by",
+		COALESCE("s"."reviewed_at"::TEXT, '') AS "reviewed_at",
+		"s"."created_at"
+	FROM "transfer_slips" "s"`
+
+func (repo *slipsRepositories) FindOneSlip(slipId string) (*orders.TransferSlip, error) {
+	query := selectSlipQuery + `
+	WHERE "s"."id" = $1;`
+
+	slip := new(orders.TransferSlip)
+	if err := repo.db.Get(slip, query, slipId); err != nil {
+		return nil, fmt.Errorf("slip not found: %v", err)
+	}
+	return slip, nil
+}
+
+func (repo *slipsRepositories) FindOrderSlips(orderId string) ([]*orders.TransferSlip, error) {
+	query := selectSlipQuery + `
+	WHERE "s"."order_id" = $1
+	ORDER BY "s"."created_at" ASC;`
+
+	slipsData := make([]*orders.TransferSlip, 0)
+	if err := repo.db.Select(&slipsData, query, orderId); err != nil {
+		return nil, fmt.Errorf("get slips failed: %v", err)
+	}
+	return slipsData, nil
+}
+
+// FindPendingSlips returns the review queue, oldest first
+func (repo *slipsRepositories) FindPendingSlips() ([]*orders.TransferSlip, error) {
+	query := selectSlipQuery + `
+	WHERE "s"."status" = $1
+	ORDER BY "s"."created_at" ASC;`
+
+	slipsData := make([]*orders.TransferSlip, 0)
+	if err := repo.db.Select(&slipsData, query, slips.StatusPending); err != nil {
+		return nil, fmt.Errorf("get pending slips failed: %v", err)
+	}
+	return slipsData, nil
+}
+
+func (repo *slipsRepositories) InsertSlip(req *orders.TransferSlip) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	query := `
+	INSERT INTO "transfer_slips" (
+		"order_id",
+		"filename",
+		"url"
+	)
+	VALUES ($1, $2, $3)
+		RETURNING "id";`
+
+	var slipId string
+	if err := repo.db.QueryRowxContext(
+		ctx,
+		query,
+		req.OrderId,
+		req.FileName,
+		req.Url,
+	).Scan(&slipId); err != nil {
+		return "", fmt.Errorf("insert slip failed: %v", err)
+	}
+	return slipId, nil
+}
+
+// ReviewSlip only changes slips which are still waiting for review
+func (repo *slipsRepositories) ReviewSlip(req *slips.ReviewReq) error {
+	query := `
+	UPDATE "transfer_slips" SET
+		"status" = $1,
+		"reason" = $2,
+		"reviewed_by" = $3,
+		"reviewed_at" = now()
+	WHERE "id" = $4 AND "status" = 'pending';`
+
+	result, err := repo.db.ExecContext(
+		context.Background(),
+		query,
+		req.Status,
+		req.Reason,
+		req.ReviewedBy,
+		req.SlipId,
+	)
+	if err != nil {
+		return fmt.Errorf("review slip failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("slip has already been reviewed")
+	}
+	return nil
+}
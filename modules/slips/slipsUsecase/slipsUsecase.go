@@ -0,0 +1,126 @@
+package slipsusecase
+
+import (
+	"fmt"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/file"
+	filesusecase "github.com/Tanapoowapat/GunplaShop/modules/file/filesUsecase"
+	"github.com/Tanapoowapat/GunplaShop/modules/orders"
+	ordersusecase "github.com/Tanapoowapat/GunplaShop/modules/orders/ordersUsecase"
+	"github.com/Tanapoowapat/GunplaShop/modules/slips"
+	slipsrepositories "github.com/Tanapoowapat/GunplaShop/modules/slips/slipsRepositories"
+)
+
+type ISlipsUsecase interface {
+	UploadSlip(userId, orderId string, req *file.FileReq) (*orders.TransferSlip, error)
+	FindOrderSlips(userId, orderId string, isAdmin bool) ([]*orders.TransferSlip, error)
+	FindPendingSlips() ([]*orders.TransferSlip, error)
+	ApproveSlip(req *slips.ReviewReq) (*orders.Order, error)
+	RejectSlip(req *slips.ReviewReq) (*orders.TransferSlip, error)
+}
+
+type slipsUsecase struct {
+	slipsRepo     slipsrepositories.ISlipsRepositories
+	ordersUsecase ordersusecase.IOrdersUsecase
+	filesUsecase  filesusecase.IFileUsecase
+}
+
+func NewSlipsUsecase(slipsRepo slipsrepositories.ISlipsRepositories, ordersUsecase ordersusecase.IOrdersUsecase, filesUsecase filesusecase.IFileUsecase) ISlipsUsecase {
+	return &slipsUsecase{
+		slipsRepo:     slipsRepo,
+		ordersUsecase: ordersUsecase,
+		filesUsecase:  filesUsecase,
+	}
+}
+
+func (u *slipsUsecase) findOwnOrder(userId, orderId string, isAdmin bool) (*orders.Order, error) {
+	order, err := u.ordersUsecase.FindOnceOrders(orderId)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin && order.UserId != userId {
+		return nil, fmt.Errorf("order not found")
+	}
+	return order, nil
+}
+
+// UploadSlip stores a new slip attempt and puts it in the review queue
+func (u *slipsUsecase) UploadSlip(userId, orderId string, req *file.FileReq) (*orders.TransferSlip, error) {
+	order, err := u.findOwnOrder(userId, orderId, false)
+	if err != nil {
+		return nil, err
+	}
+	if order.AmountDue() <= 0 {
+		return nil, fmt.Errorf("order status is %s, slip is not required", order.Status)
+	}
+
+	files, err := u.filesUsecase.UploadImageLocal([]*file.FileReq{req})
+	if err != nil {
+		return nil, err
+	}
+
+	slipId, err := u.slipsRepo.InsertSlip(&orders.TransferSlip{
+		OrderId:  orderId,
+		FileName: files[0].FileName,
+		Url:      files[0].Url,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u.slipsRepo.FindOneSlip(slipId)
+}
+
+func (u *slipsUsecase) FindOrderSlips(userId, orderId string, isAdmin bool) ([]*orders.TransferSlip, error) {
+	if _, err := u.findOwnOrder(userId, orderId, isAdmin); err != nil {
+		return nil, err
+	}
+	return u.slipsRepo.FindOrderSlips(orderId)
+}
+
+func (u *slipsUsecase) FindPendingSlips() ([]*orders.TransferSlip, error) {
+	return u.slipsRepo.FindPendingSlips()
+}
+
+// ApproveSlip accepts the slip and moves its order to paid, or to deposit_paid
+// for a new pre-order, in one transaction
+func (u *slipsUsecase) ApproveSlip(req *slips.ReviewReq) (*orders.Order, error) {
+	slip, err := u.slipsRepo.FindOneSlip(req.SlipId)
+	if err != nil {
+		return nil, err
+	}
+	if slip.Status != slips.StatusPending {
+		return nil, fmt.Errorf("slip has already been reviewed")
+	}
+	order, err := u.ordersUsecase.FindOnceOrders(slip.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	status := order.PaidStatus()
+	if !orders.CanTransition(order.Status, status, true, order.IsPreorder) {
+		return nil, &orders.StatusTransitionError{
+			From: order.Status,
+			To:   status,
+		}
+	}
+
+	slip.Reason = req.Reason
+	slip.ReviewedBy = req.ReviewedBy
+	return u.ordersUsecase.UpdateOrder(&orders.Order{
+		Id:           slip.OrderId,
+		Status:       status,
+		TransferSlip: slip,
+		ApproveSlip:  true,
+	}, req.ReviewedBy, true)
+}
+
+func (u *slipsUsecase) RejectSlip(req *slips.ReviewReq) (*orders.TransferSlip, error) {
+	if req.Reason == "" {
+		return nil, fmt.Errorf("reason is required")
+	}
+
+	req.Status = slips.StatusRejected
+	if err := u.slipsRepo.ReviewSlip(req); err != nil {
+		return nil, err
+	}
+	return u.slipsRepo.FindOneSlip(req.SlipId)
+}
@@ -0,0 +1,32 @@
+package wishlist
+
+import (
+	"github.com/Tanapoowapat/GunplaShop/modules/products"
+)
+
+const EventBackInStock = "back_in_stock"
+
+type Item struct {
+	Id         string             `db:"id" json:"id"`
+	UserId     string             `db:"user_id" json:"user_id"`
+	ProductId  string             `db:"product_id" json:"-"`
+	Notify     bool               `db:"notify" json:"notify"` // subscribed to the back-in-stock notification
+	NotifiedAt *string            `db:"notified_at" json:"notified_at"`
+	Product    *products.Products `db:"-" json:"product"`
+	CreatedAt  string             `db:"created_at" json:"created_at"`
+}
+
+type ItemReq struct {
+	UserId    string `json:"-"`
+	ProductId string `json:"product_id" form:"product_id"`
+	Notify    bool   `json:"notify" form:"notify"`
+}
+
+type Notification struct {
+	Event        string `db:"-" json:"event"`
+	UserId       string `db:"user_id" json:"user_id"`
+	Username     string `db:"username" json:"username"`
+	Email        string `db:"email" json:"email"`
+	ProductId    string `db:"product_id" json:"product_id"`
+	ProductTitle string `db:"product_title" json:"product_title"`
+}
@@ -0,0 +1,127 @@
+package wishlisthandlers
+
+import (
+	"strings"
+
+	"github.com/Tanapoowapat/GunplaShop/config"
+	"github.com/Tanapoowapat/GunplaShop/modules/entities"
+	"github.com/Tanapoowapat/GunplaShop/modules/wishlist"
+	wishlistusecase "github.com/Tanapoowapat/GunplaShop/modules/wishlist/wishlistUsecase"
+	"github.com/gofiber/fiber/v2"
+)
+
+type wishlistHandlersErr string
+
+const (
+	FindWishlistErr wishlistHandlersErr = "Wishlist-001"
+	AddItemErr      wishlistHandlersErr = "Wishlist-002"
+	UpdateNotifyErr wishlistHandlersErr = "Wishlist-003"
+	RemoveItemErr   wishlistHandlersErr = "Wishlist-004"
+)
+
+type IWishlistHandlers interface {
+	FindWishlist(c *fiber.Ctx) error
+	AddItem(c *fiber.Ctx) error
+	UpdateNotify(c *fiber.Ctx) error
+	RemoveItem(c *fiber.Ctx) error
+}
+
+type wishlistHandlers struct {
+	cfg             config.IConfig
+	wishlistUsecase wishlistusecase.IWishlistUsecase
+}
+
+func NewWishlistHandlers(cfg config.IConfig, wishlistUsecase wishlistusecase.IWishlistUsecase) IWishlistHandlers {
+	return &wishlistHandlers{
+		cfg:             cfg,
+		wishlistUsecase: wishlistUsecase,
+	}
+}
+
+func (h *wishlistHandlers) FindWishlist(c *fiber.Ctx) error {
+	userId := strings.Trim(c.Params("userId"), " ")
+
+	result, err := h.wishlistUsecase.FindItems(userId)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(FindWishlistErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, result).Res()
+}
+
+func (h *wishlistHandlers) AddItem(c *fiber.Ctx) error {
+	req := new(wishlist.ItemReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(AddItemErr),
+			err.Error(),
+		).Res()
+	}
+	req.UserId = strings.Trim(c.Params("userId"), " ")
+	req.ProductId = strings.Trim(req.ProductId, " ")
+
+	if req.ProductId == "" {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(AddItemErr),
+			"product_id is required",
+		).Res()
+	}
+
+	result, err := h.wishlistUsecase.AddItem(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(AddItemErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusCreated, result).Res()
+}
+
+func (h *wishlistHandlers) UpdateNotify(c *fiber.Ctx) error {
+	req := new(wishlist.ItemReq)
+	if err := c.BodyParser(req); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrBadRequest.Code,
+			string(UpdateNotifyErr),
+			err.Error(),
+		).Res()
+	}
+	req.UserId = strings.Trim(c.Params("userId"), " ")
+	req.ProductId = strings.Trim(c.Params("product_id"), " ")
+
+	result, err := h.wishlistUsecase.UpdateNotify(req)
+	if err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(UpdateNotifyErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(fiber.StatusOK, result).Res()
+}
+
+func (h *wishlistHandlers) RemoveItem(c *fiber.Ctx) error {
+	userId := strings.Trim(c.Params("userId"), " ")
+	productId := strings.Trim(c.Params("product_id"), " ")
+
+	if err := h.wishlistUsecase.RemoveItem(userId, productId); err != nil {
+		return entities.NewResponse(c).Error(
+			fiber.ErrInternalServerError.Code,
+			string(RemoveItemErr),
+			err.Error(),
+		).Res()
+	}
+	return entities.NewResponse(c).Sucess(
+		fiber.StatusOK,
+		&struct {
+			ProductId string `json:"product_id"`
+		}{
+			ProductId: productId,
+		}).Res()
+}
@@ -0,0 +1,61 @@
+package wishlistnotifiers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/wishlist"
+)
+
+const LogNotifier = "log"
+
+// logNotifier writes every notification as a JSON line, to a file when a path
+// is given or to the standard log otherwise. It is meant for tests and local development.
+type logNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewLogNotifier(path string) INotifier {
+	return &logNotifier{
+		path: path,
+	}
+}
+
+func (n *logNotifier) Name() string { return LogNotifier }
+
+func (n *logNotifier) Enqueue(notification *wishlist.Notification) error {
+	line, err := json.Marshal(&struct {
+		*wishlist.Notification
+		QueuedAt string `json:"queued_at"`
+	}{
+		Notification: notification,
+		QueuedAt:     time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		return fmt.Errorf("marshal notification failed: %v", err)
+	}
+
+	if n.path == "" {
+		log.Printf("notification: %s\n", line)
+		return nil
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open notification file failed: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write notification failed: %v", err)
+	}
+	return nil
+}
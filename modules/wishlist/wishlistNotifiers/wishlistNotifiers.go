@@ -0,0 +1,12 @@
+package wishlistnotifiers
+
+import (
+	"github.com/Tanapoowapat/GunplaShop/modules/wishlist"
+)
+
+// INotifier delivers the wishlist notifications, e.g. by email or LINE.
+// Enqueue must not block on the delivery itself.
+type INotifier interface {
+	Name() string
+	Enqueue(n *wishlist.Notification) error
+}
@@ -0,0 +1,164 @@
+package wishlistrepositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Tanapoowapat/GunplaShop/modules/wishlist"
+	"github.com/jmoiron/sqlx"
+)
+
+type IWishlistRepositories interface {
+	FindItems(userId string) ([]*wishlist.Item, error)
+	FindOneItem(userId, productId string) (*wishlist.Item, error)
+	InsertItem(req *wishlist.ItemReq) error
+	UpdateNotify(req *wishlist.ItemReq) error
+	DeleteItem(userId, productId string) error
+	ClaimSubscribers(productId string) ([]*wishlist.Notification, error)
+	RestoreSubscriber(userId, productId string) error
+}
+
+type wishlistRepositories struct {
+	db *sqlx.DB
+}
+
+func NewWishlistRepositories(db *sqlx.DB) IWishlistRepositories {
+	return &wishlistRepositories{
+		db: db,
+	}
+}
+
+func (repo *wishlistRepositories) FindItems(userId string) ([]*wishlist.Item, error) {
+	query := `
+	SELECT
+		"w"."id",
+		"w"."user_id",
+		"w"."product_id",
+		"w"."notify",
+		"w"."notified_at"::TEXT,
+		"w"."created_at"::TEXT
+	FROM "wishlists" "w"
+	WHERE "w"."user_id" = $1
+	ORDER BY "w"."created_at" DESC;`
+
+	items := make([]*wishlist.Item, 0)
+	if err := repo.db.Select(&items, query, userId); err != nil {
+		return nil, fmt.Errorf("get wishlist failed: %v", err)
+	}
+	return items, nil
+}
+
+func (repo *wishlistRepositories) FindOneItem(userId, productId string) (*wishlist.Item, error) {
+	query := `
+	SELECT
+		"w"."id",
+		"w"."user_id",
+		"w"."product_id",
+		"w"."notify",
+		"w"."notified_at"::TEXT,
+		"w"."created_at"::TEXT
+	FROM "wishlists" "w"
+	WHERE "w"."user_id" = $1 AND "w"."product_id" = $2;`
+
+	item := new(wishlist.Item)
+	if err := repo.db.Get(item, query, userId, productId); err != nil {
+		return nil, fmt.Errorf("wishlist item not found")
+	}
+	return item, nil
+}
+
+// Adding a product that is already in the wishlist only updates its subscription
+func (repo *wishlistRepositories) InsertItem(req *wishlist.ItemReq) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	query := `
+	INSERT INTO "wishlists" (
+		"user_id",
+		"product_id",
+		"notify"
+	)
+	VALUES ($1, $2, $3)
+	ON CONFLICT ("user_id", "product_id") DO UPDATE SET
+		"notify" = EXCLUDED."notify";`
+
+	if _, err := repo.db.ExecContext(ctx, query, req.UserId, req.ProductId, req.Notify); err != nil {
+		return fmt.Errorf("insert wishlist item failed: %v", err)
+	}
+	return nil
+}
+
+func (repo *wishlistRepositories) UpdateNotify(req *wishlist.ItemReq) error {
+	query := `
+	UPDATE "wishlists" SET
+		"notify" = $1
+	WHERE "user_id" = $2 AND "product_id" = $3;`
+
+	result, err := repo.db.ExecContext(context.Background(), query, req.Notify, req.UserId, req.ProductId)
+	if err != nil {
+		return fmt.Errorf("update wishlist item failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("wishlist item not found")
+	}
+	return nil
+}
+
+func (repo *wishlistRepositories) DeleteItem(userId, productId string) error {
+	query := `DELETE FROM "wishlists" WHERE "user_id" = $1 AND "product_id" = $2;`
+
+	result, err := repo.db.ExecContext(context.Background(), query, userId, productId)
+	if err != nil {
+		return fmt.Errorf("delete wishlist item failed: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("wishlist item not found")
+	}
+	return nil
+}
+
+// ClaimSubscribers ends every back-in-stock subscription of the product and returns who to notify,
+// a subscription is notified once and the user has to subscribe again for the next restock
+func (repo *wishlistRepositories) ClaimSubscribers(productId string) ([]*wishlist.Notification, error) {
+	query := `
+	WITH "claimed" AS (
+		UPDATE "wishlists" SET
+			"notify" = FALSE,
+			"notified_at" = now()
+		WHERE "product_id" = $1 AND "notify" = TRUE
+			RETURNING "user_id", "product_id"
+	)
+	SELECT
+		"c"."user_id",
+		"u"."username",
+		"u"."email",
+		"c"."product_id",
+		"p"."title" AS "product_title"
+	FROM "claimed" "c"
+		JOIN "users" "u" ON "u"."id" = "c"."user_id"
+		JOIN "products" "p" ON "p"."id" = "c"."product_id";`
+
+	notifications := make([]*wishlist.Notification, 0)
+	if err := repo.db.Select(&notifications, query, productId); err != nil {
+		return nil, fmt.Errorf("claim wishlist subscribers failed: %v", err)
+	}
+	for _, n := range notifications {
+		n.Event = wishlist.EventBackInStock
+	}
+	return notifications, nil
+}
+
+// RestoreSubscriber subscribes a claimed user again when their notification could not be enqueued
+func (repo *wishlistRepositories) RestoreSubscriber(userId, productId string) error {
+	query := `
+	UPDATE "wishlists" SET
+		"notify" = TRUE,
+		"notified_at" = NULL
+	WHERE "user_id" = $1 AND "product_id" = $2;`
+
+	if _, err := repo.db.ExecContext(context.Background(), query, userId, productId); err != nil {
+		return fmt.Errorf("restore wishlist subscriber failed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package wishlistusecase
+
+import (
+	"fmt"
+	"log"
+
+	productsrepositories "github.com/Tanapoowapat/GunplaShop/modules/products/productsRepositories"
+	"github.com/Tanapoowapat/GunplaShop/modules/wishlist"
+	wishlistnotifiers "github.com/Tanapoowapat/GunplaShop/modules/wishlist/wishlistNotifiers"
+	wishlistrepositories "github.com/Tanapoowapat/GunplaShop/modules/wishlist/wishlistRepositories"
+)
+
+type IWishlistUsecase interface {
+	FindItems(userId string) ([]*wishlist.Item, error)
+	AddItem(req *wishlist.ItemReq) (*wishlist.Item, error)
+	UpdateNotify(req *wishlist.ItemReq) (*wishlist.Item, error)
+	RemoveItem(userId, productId string) error
+	NotifyBackInStock(productId string) (int, error)
+}
+
+type wishlistUsecase struct {
+	wishlistRepo wishlistrepositories.IWishlistRepositories
+	productsRepo productsrepositories.IProductRepositorise
+	notifier     wishlistnotifiers.INotifier
+}
+
+func NewWishlistUsecase(wishlistRepo wishlistrepositories.IWishlistRepositories, productsRepo productsrepositories.IProductRepositorise, notifier wishlistnotifiers.INotifier) IWishlistUsecase {
+	return &wishlistUsecase{
+		wishlistRepo: wishlistRepo,
+		productsRepo: productsRepo,
+		notifier:     notifier,
+	}
+}
+
+// FindItems hides the products that were unpublished or deleted, they come back
+// if the product is published again
+func (u *wishlistUsecase) FindItems(userId string) ([]*wishlist.Item, error) {
+	items, err := u.wishlistRepo.FindItems(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	listed := make([]*wishlist.Item, 0, len(items))
+	for _, item := range items {
+		product, err := u.productsRepo.FindOneProducts(item.ProductId)
+		if err != nil {
+			return nil, err
+		}
+		if !product.Listed() {
+			continue
+		}
+		item.Product = product
+		listed = append(listed, item)
+	}
+	return listed, nil
+}
+
+func (u *wishlistUsecase) findOneItem(userId, productId string) (*wishlist.Item, error) {
+	item, err := u.wishlistRepo.FindOneItem(userId, productId)
+	if err != nil {
+		return nil, err
+	}
+	item.Product, err = u.productsRepo.FindOneProducts(productId)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (u *wishlistUsecase) AddItem(req *wishlist.ItemReq) (*wishlist.Item, error) {
+	//Check product exists, sold out products may be added to be notified of the restock
+	product, err := u.productsRepo.FindOneProducts(req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+	if !product.Listed() {
+		return nil, fmt.Errorf("product %s is not available", product.Id)
+	}
+
+	if err := u.wishlistRepo.InsertItem(req); err != nil {
+		return nil, err
+	}
+	return u.findOneItem(req.UserId, req.ProductId)
+}
+
+func (u *wishlistUsecase) UpdateNotify(req *wishlist.ItemReq) (*wishlist.Item, error) {
+	if err := u.wishlistRepo.UpdateNotify(req); err != nil {
+		return nil, err
+	}
+	return u.findOneItem(req.UserId, req.ProductId)
+}
+
+func (u *wishlistUsecase) RemoveItem(userId, productId string) error {
+	return u.wishlistRepo.DeleteItem(userId, productId)
+}
+
+// NotifyBackInStock enqueues a notification for every subscriber of the product
+// and returns how many were enqueued. Subscribers whose notification could not be
+// enqueued stay subscribed for the next restock
+func (u *wishlistUsecase) NotifyBackInStock(productId string) (int, error) {
+	notifications, err := u.wishlistRepo.ClaimSubscribers(productId)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, n := range notifications {
+		if err := u.notifier.Enqueue(n); err != nil {
+			log.Printf("enqueue %s notification for %s failed: %v\n", u.notifier.Name(), n.UserId, err)
+			if err := u.wishlistRepo.RestoreSubscriber(n.UserId, n.ProductId); err != nil {
+				log.Printf("%v\n", err)
+			}
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
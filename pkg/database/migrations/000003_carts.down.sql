@@ -0,0 +1,10 @@
+BEGIN;
+
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_carts_table ON "carts";
+
+
+DROP TABLE IF EXISTS "carts" CASCADE;
+
+
+COMMIT;
@@ -0,0 +1,29 @@
+BEGIN;
+
+
+CREATE TABLE "carts" ("id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+                      "user_id" VARCHAR NOT NULL,
+                      "product_id" VARCHAR NOT NULL,
+                      "qty" INT NOT NULL DEFAULT 1 CHECK ("qty" > 0),
+                      "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+                      "updated_at" TIMESTAMP NOT NULL DEFAULT now(),
+                      UNIQUE ("user_id", "product_id"));
+
+
+ALTER TABLE "carts" ADD
+FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON
+DELETE CASCADE;
+
+
+ALTER TABLE "carts" ADD
+FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON
+DELETE CASCADE;
+
+
+CREATE TRIGGER set_updated_at_timestamp_carts_table
+BEFORE
+UPDATE ON "carts"
+FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+
+COMMIT;
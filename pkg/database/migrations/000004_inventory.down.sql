@@ -0,0 +1,7 @@
+BEGIN;
+
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "stock";
+
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+
+ALTER TABLE "products" ADD COLUMN "stock" INT NOT NULL DEFAULT 0 CHECK ("stock" >= 0);
+
+
+COMMIT;
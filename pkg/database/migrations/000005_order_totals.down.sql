@@ -0,0 +1,11 @@
+BEGIN;
+
+
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "total_price";
+
+
+ALTER TABLE "products_orders" DROP COLUMN IF EXISTS "price",
+                              DROP COLUMN IF EXISTS "subtotal";
+
+
+COMMIT;
@@ -0,0 +1,23 @@
+BEGIN;
+
+
+ALTER TABLE "products_orders" ADD COLUMN "price" FLOAT NOT NULL DEFAULT 0,
+                              ADD COLUMN "subtotal" FLOAT NOT NULL DEFAULT 0;
+
+
+ALTER TABLE "orders" ADD COLUMN "total_price" FLOAT NOT NULL DEFAULT 0;
+
+
+UPDATE "products_orders"
+SET "price" = COALESCE(("product"->>'price')::FLOAT, 0),
+    "subtotal" = COALESCE(("product"->>'price')::FLOAT, 0) * "qty";
+
+
+UPDATE "orders" "o"
+SET "total_price" = COALESCE(
+                               (SELECT SUM("po"."subtotal")
+                                FROM "products_orders" "po"
+                                WHERE "po"."order_id" = "o"."id"), 0);
+
+
+COMMIT;
@@ -0,0 +1,26 @@
+BEGIN;
+
+
+DROP TABLE IF EXISTS "order_status_history" CASCADE;
+
+--Enum values cannot be dropped, so the type is rebuilt without 'paid'
+
+UPDATE "orders"
+SET "status" = 'waiting'
+WHERE "status" = 'paid';
+
+
+ALTER TYPE "order_status" RENAME TO "order_status_old";
+
+
+CREATE TYPE "order_status" AS ENUM ('waiting', 'shipping', 'completed', 'canceled');
+
+
+ALTER TABLE "orders"
+ALTER COLUMN "status" TYPE order_status USING "status"::TEXT::order_status;
+
+
+DROP TYPE "order_status_old";
+
+
+COMMIT;
@@ -0,0 +1,43 @@
+BEGIN;
+
+
+CREATE TYPE "slip_status" AS ENUM ('pending', 'approved', 'rejected');
+
+
+CREATE TABLE "transfer_slips" ("id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+                               "order_id" VARCHAR NOT NULL,
+                               "filename" VARCHAR NOT NULL,
+                               "url" VARCHAR NOT NULL,
+                               "status" slip_status NOT NULL DEFAULT 'pending',
+                               "reason" VARCHAR NOT NULL DEFAULT '',
+                               "reviewed_by" VARCHAR,
+                               "reviewed_at" TIMESTAMP,
+                               "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+                               "updated_at" TIMESTAMP NOT NULL DEFAULT now());
+
+
+ALTER TABLE "transfer_slips" ADD
+FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON
+DELETE CASCADE;
+
+
+ALTER TABLE "transfer_slips" ADD
+FOREIGN KEY ("reviewed_by") REFERENCES "users" ("id") ON
+DELETE SET NULL;
+
+
+CREATE INDEX "transfer_slips_order_id_idx" ON "transfer_slips" ("order_id");
+
+--Only one slip per order can wait for review
+
+CREATE UNIQUE INDEX "transfer_slips_pending_idx" ON "transfer_slips" ("order_id")
+WHERE "status" = 'pending';
+
+
+CREATE TRIGGER set_updated_at_timestamp_transfer_slips_table
+BEFORE
+UPDATE ON "transfer_slips"
+FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+
+COMMIT;
@@ -0,0 +1,23 @@
+BEGIN;
+
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_payment_intents_table ON "payment_intents";
+
+
+DELETE
+FROM "order_status_history"
+WHERE "changed_by" IS NULL;
+
+
+ALTER TABLE "order_status_history"
+ALTER COLUMN "changed_by"
+SET NOT NULL;
+
+
+DROP TABLE IF EXISTS "payment_intents" CASCADE;
+
+
+DROP TYPE IF EXISTS "payment_status";
+
+
+COMMIT;
@@ -0,0 +1,40 @@
+BEGIN;
+
+
+CREATE TYPE "payment_status" AS ENUM ('pending', 'succeeded', 'failed', 'refunded');
+
+
+CREATE TABLE "payment_intents" ("id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+                                "order_id" VARCHAR NOT NULL,
+                                "provider" VARCHAR NOT NULL,
+                                "provider_ref" VARCHAR NOT NULL DEFAULT '',
+                                "amount" FLOAT NOT NULL,
+                                "currency" VARCHAR NOT NULL DEFAULT 'THB',
+                                "payload" VARCHAR NOT NULL DEFAULT '',
+                                "status" payment_status NOT NULL DEFAULT 'pending',
+                                "refunded_amount" FLOAT NOT NULL DEFAULT 0,
+                                "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+                                "updated_at" TIMESTAMP NOT NULL DEFAULT now());
+
+
+ALTER TABLE "payment_intents" ADD
+FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON
+DELETE CASCADE;
+
+
+CREATE INDEX "payment_intents_order_id_idx" ON "payment_intents" ("order_id");
+
+--Status changes made by payment webhooks have no user
+
+ALTER TABLE "order_status_history"
+ALTER COLUMN "changed_by"
+DROP NOT NULL;
+
+
+CREATE TRIGGER set_updated_at_timestamp_payment_intents_table
+BEFORE
+UPDATE ON "payment_intents"
+FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_return_requests_table ON "return_requests";
+
+
+DROP TABLE IF EXISTS "refunds" CASCADE;
+
+
+DROP TABLE IF EXISTS "return_requests" CASCADE;
+
+
+DROP TYPE IF EXISTS "return_status";
+
+
+COMMIT;
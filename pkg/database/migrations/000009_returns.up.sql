@@ -0,0 +1,78 @@
+BEGIN;
+
+
+CREATE TYPE "return_status" AS ENUM ('pending', 'approved', 'rejected');
+
+
+CREATE TABLE "return_requests" ("id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+                                "order_id" VARCHAR NOT NULL,
+                                "product_order_id" uuid NOT NULL,
+                                "user_id" VARCHAR NOT NULL,
+                                "qty" INT NOT NULL CHECK ("qty" > 0),
+                                "reason" VARCHAR NOT NULL,
+                                "images" jsonb NOT NULL DEFAULT '[]'::jsonb,
+                                "status" return_status NOT NULL DEFAULT 'pending',
+                                "refund_amount" FLOAT NOT NULL DEFAULT 0,
+                                "admin_note" VARCHAR NOT NULL DEFAULT '',
+                                "reviewed_by" VARCHAR,
+                                "reviewed_at" TIMESTAMP,
+                                "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+                                "updated_at" TIMESTAMP NOT NULL DEFAULT now());
+
+
+CREATE TABLE "refunds" ("id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+                        "order_id" VARCHAR NOT NULL,
+                        "return_id" uuid NOT NULL,
+                        "amount" FLOAT NOT NULL,
+                        "created_by" VARCHAR,
+                        "created_at" TIMESTAMP NOT NULL DEFAULT now());
+
+
+ALTER TABLE "return_requests" ADD
+FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON
+DELETE CASCADE;
+
+
+ALTER TABLE "return_requests" ADD
+FOREIGN KEY ("product_order_id") REFERENCES "products_orders" ("id") ON
+DELETE CASCADE;
+
+
+ALTER TABLE "return_requests" ADD
+FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON
+DELETE CASCADE;
+
+
+ALTER TABLE "return_requests" ADD
+FOREIGN KEY ("reviewed_by") REFERENCES "users" ("id") ON
+DELETE SET NULL;
+
+
+ALTER TABLE "refunds" ADD
+FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON
+DELETE CASCADE;
+
+
+ALTER TABLE "refunds" ADD
+FOREIGN KEY ("return_id") REFERENCES "return_requests" ("id") ON
+DELETE CASCADE;
+
+
+ALTER TABLE "refunds" ADD
+FOREIGN KEY ("created_by") REFERENCES "users" ("id") ON
+DELETE SET NULL;
+
+
+CREATE INDEX "return_requests_order_id_idx" ON "return_requests" ("order_id");
+
+
+CREATE INDEX "refunds_order_id_idx" ON "refunds" ("order_id");
+
+
+CREATE TRIGGER set_updated_at_timestamp_return_requests_table
+BEFORE
+UPDATE ON "return_requests"
+FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+
+COMMIT;
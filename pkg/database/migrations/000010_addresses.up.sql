@@ -0,0 +1,36 @@
+BEGIN;
+
+
+CREATE TABLE "addresses" ("id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+                          "user_id" VARCHAR NOT NULL,
+                          "recipient" VARCHAR NOT NULL,
+                          "phone" VARCHAR NOT NULL,
+                          "line" VARCHAR NOT NULL,
+                          "subdistrict" VARCHAR NOT NULL,
+                          "district" VARCHAR NOT NULL,
+                          "province" VARCHAR NOT NULL,
+                          "postcode" VARCHAR(5) NOT NULL,
+                          "is_default" BOOLEAN NOT NULL DEFAULT FALSE,
+                          "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+                          "updated_at" TIMESTAMP NOT NULL DEFAULT now());
+
+
+ALTER TABLE "addresses" ADD
+FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON
+DELETE CASCADE;
+
+
+CREATE UNIQUE INDEX "addresses_one_default_per_user" ON "addresses" ("user_id")
+WHERE "is_default";
+
+
+CREATE TRIGGER set_updated_at_timestamp_addresses_table
+BEFORE
+UPDATE ON "addresses"
+FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+
+ALTER TABLE "orders" ADD COLUMN "shipping_address" jsonb;
+
+
+COMMIT;
@@ -0,0 +1,22 @@
+BEGIN;
+
+
+DROP TABLE IF EXISTS "shipments" CASCADE;
+
+
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "shipping_fee";
+
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_shipping_rates_table ON "shipping_rates";
+
+
+DROP TABLE IF EXISTS "shipping_rates" CASCADE;
+
+
+DROP TYPE IF EXISTS "shipping_rate_type";
+
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "weight";
+
+
+COMMIT;
@@ -0,0 +1,53 @@
+BEGIN;
+
+
+ALTER TABLE "products" ADD COLUMN "weight" INT NOT NULL DEFAULT 0 CHECK ("weight" >= 0);
+
+
+CREATE TYPE "shipping_rate_type" AS ENUM ('flat', 'weight');
+
+
+CREATE TABLE "shipping_rates" ("id" SERIAL PRIMARY KEY,
+                               "title" VARCHAR NOT NULL,
+                               "type" shipping_rate_type NOT NULL DEFAULT 'flat',
+                               "region" VARCHAR NOT NULL DEFAULT '',
+                               "min_weight" INT NOT NULL DEFAULT 0 CHECK ("min_weight" >= 0),
+                               "max_weight" INT NOT NULL DEFAULT 0 CHECK ("max_weight" >= 0),
+                               "price" FLOAT NOT NULL DEFAULT 0 CHECK ("price" >= 0),
+                               "free_over" FLOAT NOT NULL DEFAULT 0 CHECK ("free_over" >= 0),
+                               "is_active" BOOLEAN NOT NULL DEFAULT TRUE,
+                               "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+                               "updated_at" TIMESTAMP NOT NULL DEFAULT now());
+
+
+CREATE TRIGGER set_updated_at_timestamp_shipping_rates_table
+BEFORE
+UPDATE ON "shipping_rates"
+FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+
+INSERT INTO "shipping_rates" ("title",
+                              "type",
+                              "price")
+VALUES ('Standard',
+        'flat',
+        50);
+
+
+ALTER TABLE "orders" ADD COLUMN "shipping_fee" FLOAT NOT NULL DEFAULT 0;
+
+
+CREATE TABLE "shipments" ("id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+                          "order_id" VARCHAR NOT NULL UNIQUE,
+                          "carrier" VARCHAR NOT NULL,
+                          "tracking_number" VARCHAR NOT NULL,
+                          "created_by" VARCHAR,
+                          "created_at" TIMESTAMP NOT NULL DEFAULT now());
+
+
+ALTER TABLE "shipments" ADD
+FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON
+DELETE CASCADE;
+
+
+COMMIT;
@@ -0,0 +1,31 @@
+BEGIN;
+
+
+ALTER TABLE "products_orders" DROP COLUMN IF EXISTS "discount";
+
+
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "discounts";
+
+
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "discount";
+
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_promotions_table ON "promotions";
+
+
+DROP TABLE IF EXISTS "promotion_usages" CASCADE;
+
+
+DROP TABLE IF EXISTS "promotions_categories" CASCADE;
+
+
+DROP TABLE IF EXISTS "promotions_products" CASCADE;
+
+
+DROP TABLE IF EXISTS "promotions" CASCADE;
+
+
+DROP TYPE IF EXISTS "promotion_type";
+
+
+COMMIT;
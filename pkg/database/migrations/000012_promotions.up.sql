@@ -0,0 +1,91 @@
+BEGIN;
+
+
+CREATE TYPE "promotion_type" AS ENUM ('percentage', 'fixed');
+
+
+CREATE TABLE "promotions" ("id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+                           "code" VARCHAR UNIQUE NOT NULL,
+                           "title" VARCHAR NOT NULL DEFAULT '',
+                           "type" promotion_type NOT NULL,
+                           "value" FLOAT NOT NULL CHECK ("value" > 0),
+                           "max_discount" FLOAT NOT NULL DEFAULT 0 CHECK ("max_discount" >= 0),
+                           "min_spend" FLOAT NOT NULL DEFAULT 0 CHECK ("min_spend" >= 0),
+                           "usage_limit" INT NOT NULL DEFAULT 0 CHECK ("usage_limit" >= 0),
+                           "per_user_limit" INT NOT NULL DEFAULT 0 CHECK ("per_user_limit" >= 0),
+                           "starts_at" TIMESTAMP,
+                           "ends_at" TIMESTAMP,
+                           "is_active" BOOLEAN NOT NULL DEFAULT TRUE,
+                           "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+                           "updated_at" TIMESTAMP NOT NULL DEFAULT now());
+
+
+CREATE TABLE "promotions_products" ("id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+                                    "promotion_id" uuid NOT NULL,
+                                    "product_id" VARCHAR NOT NULL,
+                                    UNIQUE ("promotion_id", "product_id"));
+
+
+CREATE TABLE "promotions_categories" ("id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+                                      "promotion_id" uuid NOT NULL,
+                                      "category_id" INT NOT NULL,
+                                      UNIQUE ("promotion_id", "category_id"));
+
+
+CREATE TABLE "promotion_usages" ("id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+                                 "promotion_id" uuid NOT NULL,
+                                 "order_id" VARCHAR NOT NULL,
+                                 "user_id" VARCHAR NOT NULL,
+                                 "amount" FLOAT NOT NULL,
+                                 "created_at" TIMESTAMP NOT NULL DEFAULT now());
+
+
+ALTER TABLE "promotions_products" ADD
+FOREIGN KEY ("promotion_id") REFERENCES "promotions" ("id") ON
+DELETE CASCADE;
+
+
+ALTER TABLE "promotions_products" ADD
+FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON
+DELETE CASCADE;
+
+
+ALTER TABLE "promotions_categories" ADD
+FOREIGN KEY ("promotion_id") REFERENCES "promotions" ("id") ON
+DELETE CASCADE;
+
+
+ALTER TABLE "promotions_categories" ADD
+FOREIGN KEY ("category_id") REFERENCES "categories" ("id") ON
+DELETE CASCADE;
+
+
+ALTER TABLE "promotion_usages" ADD
+FOREIGN KEY ("promotion_id") REFERENCES "promotions" ("id") ON
+DELETE CASCADE;
+
+
+ALTER TABLE "promotion_usages" ADD
+FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON
+DELETE CASCADE;
+
+
+CREATE INDEX "promotion_usages_promotion_id_idx" ON "promotion_usages" ("promotion_id", "user_id");
+
+
+CREATE TRIGGER set_updated_at_timestamp_promotions_table
+BEFORE
+UPDATE ON "promotions"
+FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+
+ALTER TABLE "orders" ADD COLUMN "discount" FLOAT NOT NULL DEFAULT 0;
+
+
+ALTER TABLE "orders" ADD COLUMN "discounts" jsonb NOT NULL DEFAULT '[]'::jsonb;
+
+
+ALTER TABLE "products_orders" ADD COLUMN "discount" FLOAT NOT NULL DEFAULT 0;
+
+
+COMMIT;
@@ -0,0 +1,28 @@
+BEGIN;
+
+
+DROP INDEX IF EXISTS "products_manufacturer_idx";
+
+
+DROP INDEX IF EXISTS "products_series_idx";
+
+
+DROP INDEX IF EXISTS "products_scale_idx";
+
+
+DROP INDEX IF EXISTS "products_grade_idx";
+
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "manufacturer";
+
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "series";
+
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "scale";
+
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "grade";
+
+
+COMMIT;
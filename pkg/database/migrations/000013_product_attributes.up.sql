@@ -0,0 +1,28 @@
+BEGIN;
+
+
+ALTER TABLE "products" ADD COLUMN "grade" VARCHAR NOT NULL DEFAULT '';
+
+
+ALTER TABLE "products" ADD COLUMN "scale" VARCHAR NOT NULL DEFAULT '';
+
+
+ALTER TABLE "products" ADD COLUMN "series" VARCHAR NOT NULL DEFAULT '';
+
+
+ALTER TABLE "products" ADD COLUMN "manufacturer" VARCHAR NOT NULL DEFAULT '';
+
+
+CREATE INDEX "products_grade_idx" ON "products" ("grade");
+
+
+CREATE INDEX "products_scale_idx" ON "products" ("scale");
+
+
+CREATE INDEX "products_series_idx" ON "products" ("series");
+
+
+CREATE INDEX "products_manufacturer_idx" ON "products" ("manufacturer");
+
+
+COMMIT;
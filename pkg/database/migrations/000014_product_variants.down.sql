@@ -0,0 +1,33 @@
+BEGIN;
+
+
+DROP INDEX IF EXISTS "carts_user_product_variant_key";
+
+
+DELETE FROM "carts"
+WHERE "variant_id" IS NOT NULL;
+
+
+ALTER TABLE "carts" DROP COLUMN IF EXISTS "variant_id";
+
+
+ALTER TABLE "carts" ADD CONSTRAINT "carts_user_id_product_id_key" UNIQUE ("user_id", "product_id");
+
+
+ALTER TABLE "products_orders" DROP COLUMN IF EXISTS "variant";
+
+
+DELETE FROM "images"
+WHERE "variant_id" IS NOT NULL;
+
+
+ALTER TABLE "images" DROP COLUMN IF EXISTS "variant_id";
+
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_product_variants_table ON "product_variants";
+
+
+DROP TABLE IF EXISTS "product_variants" CASCADE;
+
+
+COMMIT;
@@ -0,0 +1,55 @@
+BEGIN;
+
+
+CREATE TABLE "product_variants" ("id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+                                 "product_id" VARCHAR NOT NULL,
+                                 "sku" VARCHAR UNIQUE NOT NULL,
+                                 "title" VARCHAR NOT NULL,
+                                 "price" FLOAT NOT NULL DEFAULT 0 CHECK ("price" >= 0),
+                                 "stock" INT NOT NULL DEFAULT 0 CHECK ("stock" >= 0),
+                                 "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+                                 "updated_at" TIMESTAMP NOT NULL DEFAULT now());
+
+
+ALTER TABLE "product_variants" ADD
+FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON
+DELETE CASCADE;
+
+
+CREATE INDEX "product_variants_product_id_idx" ON "product_variants" ("product_id");
+
+
+CREATE TRIGGER set_updated_at_timestamp_product_variants_table
+BEFORE
+UPDATE ON "product_variants"
+FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+--Variant images live with the product images, tagged with their variant
+
+ALTER TABLE "images" ADD COLUMN "variant_id" uuid;
+
+
+ALTER TABLE "images" ADD
+FOREIGN KEY ("variant_id") REFERENCES "product_variants" ("id") ON
+DELETE CASCADE;
+
+
+ALTER TABLE "products_orders" ADD COLUMN "variant" jsonb;
+
+
+ALTER TABLE "carts" ADD COLUMN "variant_id" uuid;
+
+
+ALTER TABLE "carts" ADD
+FOREIGN KEY ("variant_id") REFERENCES "product_variants" ("id") ON
+DELETE CASCADE;
+
+
+ALTER TABLE "carts"
+DROP CONSTRAINT IF EXISTS "carts_user_id_product_id_key";
+
+
+CREATE UNIQUE INDEX "carts_user_product_variant_key" ON "carts" ("user_id", "product_id", COALESCE("variant_id", '00000000-0000-0000-0000-000000000000'::uuid));
+
+
+COMMIT;
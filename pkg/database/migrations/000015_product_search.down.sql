@@ -0,0 +1,16 @@
+BEGIN;
+
+
+DROP INDEX IF EXISTS "products_search_vector_idx";
+
+
+DROP TRIGGER IF EXISTS set_search_vector_products_table ON "products";
+
+
+DROP FUNCTION IF EXISTS set_products_search_vector();
+
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "search_vector";
+
+
+COMMIT;
@@ -0,0 +1,37 @@
+BEGIN;
+
+
+ALTER TABLE "products" ADD COLUMN "search_vector" tsvector;
+
+--Title weighs most, then the kit attributes, then the description
+
+CREATE OR REPLACE FUNCTION set_products_search_vector() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search_vector =
+        setweight(to_tsvector('english', COALESCE(NEW.title, '')), 'A') ||
+        setweight(to_tsvector('english', CONCAT_WS(' ', NEW.grade, NEW.scale, NEW.series, NEW.manufacturer)), 'B') ||
+        setweight(to_tsvector('english', COALESCE(NEW.description, '')), 'C');
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+
+CREATE TRIGGER set_search_vector_products_table
+BEFORE
+INSERT
+OR
+UPDATE ON "products"
+FOR EACH ROW EXECUTE PROCEDURE set_products_search_vector();
+
+
+UPDATE "products"
+SET "search_vector" =
+        setweight(to_tsvector('english', COALESCE("title", '')), 'A') ||
+        setweight(to_tsvector('english', CONCAT_WS(' ', "grade", "scale", "series", "manufacturer")), 'B') ||
+        setweight(to_tsvector('english', COALESCE("description", '')), 'C');
+
+
+CREATE INDEX "products_search_vector_idx" ON "products" USING GIN ("search_vector");
+
+
+COMMIT;
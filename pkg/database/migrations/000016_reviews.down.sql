@@ -0,0 +1,19 @@
+BEGIN;
+
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "review_count";
+
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "rating_avg";
+
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_reviews_table ON "reviews";
+
+
+DROP TABLE IF EXISTS "reviews" CASCADE;
+
+
+DROP TYPE IF EXISTS "review_status";
+
+
+COMMIT;
@@ -0,0 +1,59 @@
+BEGIN;
+
+
+CREATE TYPE "review_status" AS ENUM ('pending', 'approved', 'rejected');
+
+
+CREATE TABLE "reviews" ("id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+                        "product_id" VARCHAR NOT NULL,
+                        "user_id" VARCHAR NOT NULL,
+                        "order_id" VARCHAR NOT NULL,
+                        "rating" INT NOT NULL CHECK ("rating" BETWEEN 1 AND 5),
+                        "comment" VARCHAR NOT NULL DEFAULT '',
+                        "images" jsonb NOT NULL DEFAULT '[]'::jsonb,
+                        "status" review_status NOT NULL DEFAULT 'pending',
+                        "admin_note" VARCHAR NOT NULL DEFAULT '',
+                        "moderated_by" VARCHAR,
+                        "moderated_at" TIMESTAMP,
+                        "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+                        "updated_at" TIMESTAMP NOT NULL DEFAULT now(),
+                        UNIQUE ("product_id", "user_id"));
+
+
+ALTER TABLE "reviews" ADD
+FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON
+DELETE CASCADE;
+
+
+ALTER TABLE "reviews" ADD
+FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON
+DELETE CASCADE;
+
+
+ALTER TABLE "reviews" ADD
+FOREIGN KEY ("order_id") REFERENCES "orders" ("id") ON
+DELETE CASCADE;
+
+
+ALTER TABLE "reviews" ADD
+FOREIGN KEY ("moderated_by") REFERENCES "users" ("id") ON
+DELETE SET NULL;
+
+
+CREATE INDEX "reviews_product_id_status_idx" ON "reviews" ("product_id", "status");
+
+
+CREATE TRIGGER set_updated_at_timestamp_reviews_table
+BEFORE
+UPDATE ON "reviews"
+FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+--Approved reviews only, kept on the product so it can be sorted by
+
+ALTER TABLE "products" ADD COLUMN "rating_avg" FLOAT NOT NULL DEFAULT 0;
+
+
+ALTER TABLE "products" ADD COLUMN "review_count" INT NOT NULL DEFAULT 0;
+
+
+COMMIT;
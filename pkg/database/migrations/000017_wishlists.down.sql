@@ -0,0 +1,13 @@
+BEGIN;
+
+
+DROP TRIGGER IF EXISTS set_updated_at_timestamp_wishlists_table ON "wishlists";
+
+
+DROP TABLE IF EXISTS "wishlists" CASCADE;
+
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "sold_out";
+
+
+COMMIT;
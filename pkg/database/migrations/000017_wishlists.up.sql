@@ -0,0 +1,37 @@
+BEGIN;
+
+
+ALTER TABLE "products" ADD COLUMN "sold_out" BOOLEAN NOT NULL DEFAULT FALSE;
+
+
+CREATE TABLE "wishlists" ("id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+                          "user_id" VARCHAR NOT NULL,
+                          "product_id" VARCHAR NOT NULL,
+                          "notify" BOOLEAN NOT NULL DEFAULT FALSE,
+                          "notified_at" TIMESTAMP,
+                          "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+                          "updated_at" TIMESTAMP NOT NULL DEFAULT now(),
+                          UNIQUE ("user_id", "product_id"));
+
+
+ALTER TABLE "wishlists" ADD
+FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON
+DELETE CASCADE;
+
+
+ALTER TABLE "wishlists" ADD
+FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON
+DELETE CASCADE;
+
+
+CREATE INDEX "wishlists_product_id_notify_idx" ON "wishlists" ("product_id")
+WHERE "notify" = TRUE;
+
+
+CREATE TRIGGER set_updated_at_timestamp_wishlists_table
+BEFORE
+UPDATE ON "wishlists"
+FOR EACH ROW EXECUTE PROCEDURE set_updated_at_column();
+
+
+COMMIT;
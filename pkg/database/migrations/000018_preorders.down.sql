@@ -0,0 +1,62 @@
+BEGIN;
+
+
+DROP INDEX IF EXISTS "orders_is_preorder_status_idx";
+
+
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "stock_reserved";
+
+
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "deposit";
+
+
+ALTER TABLE "orders" DROP COLUMN IF EXISTS "is_preorder";
+
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "deposit";
+
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "release_date";
+
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "preorder";
+
+--Enum values cannot be dropped, so the type is rebuilt without the pre-order statuses
+
+UPDATE "orders"
+SET "status" = 'paid'
+WHERE "status" IN ('deposit_paid', 'awaiting_release', 'ready_to_ship');
+
+
+UPDATE "order_status_history"
+SET "from_status" = 'paid'
+WHERE "from_status" IN ('deposit_paid', 'awaiting_release', 'ready_to_ship');
+
+
+UPDATE "order_status_history"
+SET "to_status" = 'paid'
+WHERE "to_status" IN ('deposit_paid', 'awaiting_release', 'ready_to_ship');
+
+
+ALTER TYPE "order_status" RENAME TO "order_status_old";
+
+
+CREATE TYPE "order_status" AS ENUM ('waiting', 'paid', 'shipping', 'completed', 'canceled');
+
+
+ALTER TABLE "orders"
+ALTER COLUMN "status" TYPE order_status USING "status"::TEXT::order_status;
+
+
+ALTER TABLE "order_status_history"
+ALTER COLUMN "from_status" TYPE order_status USING "from_status"::TEXT::order_status;
+
+
+ALTER TABLE "order_status_history"
+ALTER COLUMN "to_status" TYPE order_status USING "to_status"::TEXT::order_status;
+
+
+DROP TYPE "order_status_old";
+
+
+COMMIT;
@@ -0,0 +1,37 @@
+--ADD VALUE cannot be used inside the same transaction block
+
+ALTER TYPE "order_status" ADD VALUE IF NOT EXISTS 'deposit_paid' AFTER 'paid';
+
+
+ALTER TYPE "order_status" ADD VALUE IF NOT EXISTS 'awaiting_release' AFTER 'deposit_paid';
+
+
+ALTER TYPE "order_status" ADD VALUE IF NOT EXISTS 'ready_to_ship' AFTER 'awaiting_release';
+
+
+BEGIN;
+
+
+ALTER TABLE "products" ADD COLUMN "preorder" BOOLEAN NOT NULL DEFAULT FALSE;
+
+
+ALTER TABLE "products" ADD COLUMN "release_date" DATE;
+
+
+ALTER TABLE "products" ADD COLUMN "deposit" FLOAT NOT NULL DEFAULT 0;
+
+
+ALTER TABLE "orders" ADD COLUMN "is_preorder" BOOLEAN NOT NULL DEFAULT FALSE;
+
+
+ALTER TABLE "orders" ADD COLUMN "deposit" FLOAT NOT NULL DEFAULT 0;
+
+
+ALTER TABLE "orders" ADD COLUMN "stock_reserved" BOOLEAN NOT NULL DEFAULT TRUE;
+
+
+CREATE INDEX "orders_is_preorder_status_idx" ON "orders" ("status")
+WHERE "is_preorder" = TRUE;
+
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+
+
+DROP INDEX IF EXISTS "products_status_idx";
+
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "deleted_at";
+
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "status";
+
+
+DROP TYPE IF EXISTS "product_status";
+
+
+COMMIT;
@@ -0,0 +1,23 @@
+BEGIN;
+
+
+CREATE TYPE "product_status" AS ENUM ('draft', 'published', 'archived');
+
+--Products listed before the lifecycle stay visible, new ones start as drafts
+
+ALTER TABLE "products" ADD COLUMN "status" product_status NOT NULL DEFAULT 'published';
+
+
+ALTER TABLE "products"
+ALTER COLUMN "status"
+SET DEFAULT 'draft';
+
+
+ALTER TABLE "products" ADD COLUMN "deleted_at" TIMESTAMP;
+
+
+CREATE INDEX "products_status_idx" ON "products" ("status")
+WHERE "deleted_at" IS NULL;
+
+
+COMMIT;
@@ -0,0 +1,19 @@
+BEGIN;
+
+
+DROP FUNCTION IF EXISTS product_effective_status(product_status, TIMESTAMP, TIMESTAMP);
+
+
+DROP FUNCTION IF EXISTS product_effective_price(VARCHAR, FLOAT);
+
+
+DROP TABLE IF EXISTS "product_prices" CASCADE;
+
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "unpublish_at";
+
+
+ALTER TABLE "products" DROP COLUMN IF EXISTS "publish_at";
+
+
+COMMIT;
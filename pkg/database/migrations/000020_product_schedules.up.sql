@@ -0,0 +1,59 @@
+BEGIN;
+
+
+ALTER TABLE "products" ADD COLUMN "publish_at" TIMESTAMP;
+
+
+ALTER TABLE "products" ADD COLUMN "unpublish_at" TIMESTAMP;
+
+
+CREATE TABLE "product_prices" ("id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+                               "product_id" VARCHAR NOT NULL,
+                               "sale_price" FLOAT NOT NULL CHECK ("sale_price" > 0),
+                               "start_at" TIMESTAMP NOT NULL,
+                               "end_at" TIMESTAMP,
+                               "created_by" VARCHAR,
+                               "created_at" TIMESTAMP NOT NULL DEFAULT now(),
+                               CHECK ("end_at" IS NULL
+                                      OR "end_at" > "start_at"));
+
+
+ALTER TABLE "product_prices" ADD
+FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON
+DELETE CASCADE;
+
+
+ALTER TABLE "product_prices" ADD
+FOREIGN KEY ("created_by") REFERENCES "users" ("id") ON
+DELETE SET NULL;
+
+
+CREATE INDEX "product_prices_product_id_start_at_idx" ON "product_prices" ("product_id", "start_at");
+
+--The price a product sells at right now, the latest started entry wins
+
+CREATE OR REPLACE FUNCTION product_effective_price(product_id VARCHAR, base_price FLOAT) RETURNS FLOAT AS $$
+    SELECT COALESCE((
+        SELECT "pp"."sale_price"
+        FROM "product_prices" "pp"
+        WHERE "pp"."product_id" = $1
+            AND "pp"."start_at" <= now()
+            AND ("pp"."end_at" IS NULL OR "pp"."end_at" > now())
+        ORDER BY "pp"."start_at" DESC
+        LIMIT 1
+    ), $2);
+$$ language 'sql' STABLE;
+
+--publish_at only promotes a draft and unpublish_at archives a live product,
+--an archive done by hand is never undone by the schedule
+
+CREATE OR REPLACE FUNCTION product_effective_status(status product_status, publish_at TIMESTAMP, unpublish_at TIMESTAMP) RETURNS TEXT AS $$
+    SELECT CASE
+        WHEN $1 <> 'archived' AND $3 <= now() THEN 'archived'
+        WHEN $1 = 'draft' AND $2 <= now() THEN 'published'
+        ELSE $1::TEXT
+    END;
+$$ language 'sql' STABLE;
+
+
+COMMIT;
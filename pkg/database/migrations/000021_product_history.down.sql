@@ -0,0 +1,10 @@
+BEGIN;
+
+
+DROP FUNCTION IF EXISTS product_lowest_price(VARCHAR, FLOAT);
+
+
+DROP TABLE IF EXISTS "product_history" CASCADE;
+
+
+COMMIT;
@@ -0,0 +1,74 @@
+BEGIN;
+
+
+CREATE TABLE "product_history" ("id" uuid NOT NULL UNIQUE PRIMARY KEY DEFAULT uuid_generate_v4(),
+                                "product_id" VARCHAR NOT NULL,
+                                "field" VARCHAR NOT NULL,
+                                "old_value" TEXT NOT NULL DEFAULT '',
+                                "new_value" TEXT NOT NULL DEFAULT '',
+                                "changed_by" VARCHAR,
+                                "created_at" TIMESTAMP NOT NULL DEFAULT now());
+
+
+ALTER TABLE "product_history" ADD
+FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON
+DELETE CASCADE;
+
+
+ALTER TABLE "product_history" ADD
+FOREIGN KEY ("changed_by") REFERENCES "users" ("id") ON
+DELETE SET NULL;
+
+
+CREATE INDEX "product_history_product_id_created_at_idx" ON "product_history" ("product_id", "created_at");
+
+--The lowest price a product was sold at in the 30 days before its current sale started
+--(or before now without one), for sale labels: the base price the sale reduces, the base
+--prices replaced in that time and the other sale prices running in it. The current sale
+--price is left out, otherwise a reduction would always be its own lowest price
+
+CREATE OR REPLACE FUNCTION product_lowest_price(product_id VARCHAR, base_price FLOAT) RETURNS FLOAT AS $$
+    WITH "current_sale" AS (
+        SELECT
+            "pp"."id",
+            "pp"."start_at"
+        FROM "product_prices" "pp"
+        WHERE "pp"."product_id" = $1
+            AND "pp"."start_at" <= now()
+            AND ("pp"."end_at" IS NULL OR "pp"."end_at" > now())
+        ORDER BY "pp"."start_at" DESC
+        LIMIT 1
+    ), "reference" AS (
+        SELECT COALESCE((SELECT "start_at" FROM "current_sale"), now()::TIMESTAMP) AS "at"
+    )
+    SELECT LEAST(
+        COALESCE((
+            SELECT NULLIF("h"."old_value", '')::FLOAT
+            FROM "product_history" "h", "reference" "r"
+            WHERE "h"."product_id" = $1
+                AND "h"."field" = 'price'
+                AND "h"."created_at" > "r"."at"
+            ORDER BY "h"."created_at"
+            LIMIT 1
+        ), $2),
+        (
+            SELECT MIN(NULLIF("h"."old_value", '')::FLOAT)
+            FROM "product_history" "h", "reference" "r"
+            WHERE "h"."product_id" = $1
+                AND "h"."field" = 'price'
+                AND "h"."created_at" > "r"."at" - INTERVAL '30 days'
+                AND "h"."created_at" <= "r"."at"
+        ),
+        (
+            SELECT MIN("pp"."sale_price")
+            FROM "product_prices" "pp", "reference" "r"
+            WHERE "pp"."product_id" = $1
+                AND "pp"."id" NOT IN (SELECT "id" FROM "current_sale")
+                AND "pp"."start_at" < "r"."at"
+                AND ("pp"."end_at" IS NULL OR "pp"."end_at" > "r"."at" - INTERVAL '30 days')
+        )
+    );
+$$ language 'sql' STABLE;
+
+
+COMMIT;
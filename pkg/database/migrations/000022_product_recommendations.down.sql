@@ -0,0 +1,7 @@
+BEGIN;
+
+
+DROP MATERIALIZED VIEW IF EXISTS "product_co_purchases";
+
+
+COMMIT;
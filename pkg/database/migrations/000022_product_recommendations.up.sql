@@ -0,0 +1,24 @@
+BEGIN;
+
+--How many orders bought each pair of products together, for "frequently bought together".
+--The products module refreshes it periodically, see productsUsecase.StartCoPurchaseRefresh
+
+CREATE MATERIALIZED VIEW "product_co_purchases" AS
+SELECT
+    "a"."product"->>'id' AS "product_id",
+    "b"."product"->>'id' AS "related_id",
+    COUNT(DISTINCT "a"."order_id")::INT AS "orders"
+FROM "products_orders" "a"
+    JOIN "products_orders" "b" ON "b"."order_id" = "a"."order_id"
+        AND "b"."product"->>'id' <> "a"."product"->>'id'
+    JOIN "orders" "o" ON "o"."id" = "a"."order_id"
+WHERE "o"."status" <> 'canceled'
+GROUP BY "a"."product"->>'id', "b"."product"->>'id';
+
+
+--Required by REFRESH MATERIALIZED VIEW CONCURRENTLY
+
+CREATE UNIQUE INDEX "product_co_purchases_product_id_related_id_idx" ON "product_co_purchases" ("product_id", "related_id");
+
+
+COMMIT;
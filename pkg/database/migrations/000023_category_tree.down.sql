@@ -0,0 +1,32 @@
+BEGIN;
+
+
+DROP FUNCTION IF EXISTS category_descendants(INT[]);
+
+
+DROP INDEX IF EXISTS "products_categories_product_id_category_id_idx";
+
+
+DROP INDEX IF EXISTS "categories_parent_id_title_idx";
+
+
+--Subcategories move to the top level, a title used more than once gets its id to stay unique
+
+UPDATE "categories" "c" SET
+    "title" = "c"."title" || ' (' || "c"."id" || ')'
+FROM (
+    SELECT
+        "id",
+        ROW_NUMBER() OVER (PARTITION BY "title" ORDER BY "parent_id" IS NOT NULL, "id") AS "n"
+    FROM "categories"
+) "d"
+WHERE "d"."id" = "c"."id" AND "d"."n" > 1;
+
+
+ALTER TABLE "categories" DROP COLUMN IF EXISTS "parent_id";
+
+
+ALTER TABLE "categories" ADD CONSTRAINT "categories_title_key" UNIQUE ("title");
+
+
+COMMIT;
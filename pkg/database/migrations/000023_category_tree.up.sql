@@ -0,0 +1,48 @@
+BEGIN;
+
+
+ALTER TABLE "categories" ADD COLUMN "parent_id" INT;
+
+
+ALTER TABLE "categories" ADD
+FOREIGN KEY ("parent_id") REFERENCES "categories" ("id") ON
+DELETE RESTRICT;
+
+
+CREATE INDEX "categories_parent_id_idx" ON "categories" ("parent_id");
+
+--Titles only need to be unique among siblings, e.g. HG under several series
+
+ALTER TABLE "categories" DROP CONSTRAINT IF EXISTS "categories_title_key";
+
+
+CREATE UNIQUE INDEX "categories_parent_id_title_idx" ON "categories" (COALESCE("parent_id", 0), "title");
+
+--A product may be in several categories, but only once in each
+
+DELETE FROM "products_categories" "a"
+USING "products_categories" "b"
+WHERE "a"."product_id" = "b"."product_id"
+    AND "a"."category_id" = "b"."category_id"
+    AND "a"."id" > "b"."id";
+
+
+CREATE UNIQUE INDEX "products_categories_product_id_category_id_idx" ON "products_categories" ("product_id", "category_id");
+
+--The categories and every category below them, for searching a branch of the tree
+
+CREATE OR REPLACE FUNCTION category_descendants(category_ids INT[]) RETURNS SETOF INT AS $$
+    WITH RECURSIVE "tree" AS (
+        SELECT "c"."id"
+        FROM "categories" "c"
+        WHERE "c"."id" = ANY($1)
+        UNION
+        SELECT "c"."id"
+        FROM "categories" "c"
+            JOIN "tree" "t" ON "c"."parent_id" = "t"."id"
+    )
+    SELECT "id" FROM "tree";
+$$ language 'sql' STABLE;
+
+
+COMMIT;